@@ -37,17 +37,152 @@ func main() {
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_PATH\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Path to the archive directory containing log folders (default: /var/log/ct/archive)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_FOLDER_PATTERN\n")
-		_, _ = fmt.Fprintf(os.Stdout, "    Glob pattern for matching log folders, must end with '*' (default: ct_*)\n")
-		_, _ = fmt.Fprintf(os.Stdout, "    Example: ct_* matches folders like ct_digicert_nessie_2022/\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Pattern <prefix>*<suffix> with exactly one '*'; the log name is the text it matches (default: ct_*)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: ct_* matches folders like ct_digicert_nessie_2022/, *_ct matches digicert_nessie_2022_ct/\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_EXCLUDE_FOLDERS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Comma-separated folder names or glob patterns under CT_ARCHIVE_PATH that are never scanned\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: lost+found,.snapshot,ct_*.tmp\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOG_ALLOWLIST\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Comma-separated log names or glob patterns; if set, only matching logs are served\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Takes precedence over CT_LOG_DENYLIST. Example: digicert_*,google_argon2024\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOG_DENYLIST\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Comma-separated log names or glob patterns to exclude (ignored if CT_LOG_ALLOWLIST is set)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_RETIRED_LOGS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Comma-separated log names or glob patterns whose submission endpoints\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (/<log>/ct/v1/add-chain, add-pre-chain, get-roots) answer 410 Gone instead of 404\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENABLE_ISSUER_LISTING\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Serve /<log>/issuers.json listing issuer fingerprints (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ISSUER_READ_CONCURRENCY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum concurrent /<log>/issuer/<fp> reads; further ones get 503 with Retry-After\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: 0, unlimited). Keeps issuer bursts from starving tile reads\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENABLE_BULK_DOWNLOAD\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Serve /<log>/download.tar, a tar of every entry in the log's zip parts (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_BULK_DOWNLOAD_CONCURRENCY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum concurrent /<log>/download.tar streams; further ones get 503 with Retry-After\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: 2). Must be > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HEAVY_OP_CONCURRENCY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Shared limit on concurrent bulk downloads, issuer listings and zip part manifests;\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    further ones get 503 with Retry-After (default: 0, unlimited)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_UPSTREAM_BASE_URL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Another ct-archive-serve instance to fetch checkpoints, tiles and issuers of logs\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    missing from the local archive from; fetched entries go in the entry cache.\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Fetches count against CT_HEAVY_OP_CONCURRENCY (default: unset, disabled).\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Must not contain userinfo (user:password@)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_UPSTREAM_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum time for one CT_UPSTREAM_BASE_URL fetch (default: 10s)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_FOLLOW_SYMLINKS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Discover log folders that are symlinks to directories (default: false, skipped)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_COLLISION_POLICY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    What to do when two folders map to the same log name (default: fail)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    fail: the archive scan fails; skip: the colliding log is left out and answers 409\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_NESTED_DEPTH\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Levels of operator directories between CT_ARCHIVE_PATH and the log folders, e.g. 1 for\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    <operator>/ct_<log>/ (default: 0, flat)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_NESTED_LOG_NAMES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    How nested log folders are named (default: folder)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    folder: from the log folder alone; path: <operator>_<log>\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_METADATA_SOURCE\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Where log.v3.json is read from (default: auto)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    auto: the log folder's log.v3.json if present, else 000.zip; file: folder only; zip: 000.zip only\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_IMMUTABLE\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Treat the archive as immutable, e.g. a read-only mount (default: false)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Disables periodic archive and logs.v3.json refresh after the first successful build,\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    and zip parts that pass integrity checks are never re-tested\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_CHECKPOINT_ENTRY_NAME\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Name of the checkpoint entry in 000.zip served as /<log>/checkpoint (default: checkpoint)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: checkpoint.txt or sth. Must not contain slashes\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_ENTRY_PREFIX\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Directory inside each zip part holding tiles and metadata (default: empty, zip root)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: logdata/ serves /<log>/tile/0/000 from entry logdata/tile/0/000\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_VALIDATE_TILE_SIZE\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Check served tile sizes against the tile geometry; mismatches are logged and\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    counted but still served (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_VALIDATE_DATA_TILES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Check only partial data tiles (tile/data/<N>.p/<W>) hold exactly W entries; mismatches\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    are logged and counted but still served (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_TILE_ALLOW_UPPERCASE_X\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Accept tile index segments with an uppercase X prefix (tile/0/X001/234), as sent by\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    some older clients (default: false, lowercase x only per tlog-tiles)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ALLOW_PERCENT_ENCODING\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Decode percent-encoded request paths before routing instead of answering 404\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: false). Encoded traversal, slashes and control characters are still rejected\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENTRY_METADATA_HEADERS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Comma-separated zip entry metadata headers sent with tiles: Last-Modified (entry\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    mtime) and/or X-Archive-Entry-Comment (entry comment) (default: none)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_PARTIAL_FROM_FULL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Serve partial tiles by slicing the full tile when it is in the entry content\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    cache, instead of reading each stored partial width (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_EMIT_LINK_HEADERS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Add Link headers from tiles to the log's checkpoint and log.v3.json, and from\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    checkpoints to /logs.v3.json (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_EMIT_REFRESH_HEADERS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Add X-Archive-Refresh-Interval and X-LogList-Refresh-Interval (seconds) to the JSON\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    endpoints so clients can pick a poll rate (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_TILE_HASH_BYTES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Hash length in bytes of a hash tile entry, for CT_VALIDATE_TILE_SIZE only (default: 32)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_CHECKPOINT_LONGPOLL_MAX_WAIT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Upper bound for /<log>/checkpoint?wait=<seconds>&after=<treesize> (default: 30s)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Set to 0 to disable long-polling. Keep below CT_HTTP_WRITE_TIMEOUT\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_CHECKPOINT_LONGPOLL_MAX_WAITERS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum concurrent checkpoint long-polls; further ones get 503 (default: 1024)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_CHECKPOINT_HEADERS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Add X-Tlog-Tree-Size and X-Tlog-Origin to /<log>/checkpoint responses, so a HEAD\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    is enough to detect log growth (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_MAX_LOG_NAME_LENGTH\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum length of the <log> path segment (default: 128)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Log names may only contain letters, digits, '_' and '-'; other requests return 404\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Must be > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_MAX_ZIP_PARTS_PER_LOG\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum NNN.zip parts discovered per log (default: 1000)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Extra parts are ignored (lowest indices kept) and logged as a warning\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Must be > 0\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "Refresh Intervals:\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOGLISTV3_JSON_REFRESH_INTERVAL\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Interval for refreshing /logs.v3.json (default: 10m)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 10m, 5m, 30s, 1h)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Optimized for large archive sets (100+ logs, 10TB+ data)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_MONITOR_JSON_ALIAS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Also serve the log list at the legacy /monitor.json path and read\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    CT_MONITOR_JSON_REFRESH_INTERVAL (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_MONITOR_JSON_REFRESH_INTERVAL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Legacy name for CT_LOGLISTV3_JSON_REFRESH_INTERVAL, read only with CT_MONITOR_JSON_ALIAS;\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    if both are set, the shorter wins\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENABLE_LOGLISTV3_JSON\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Serve /logs.v3.json (and /monitor.json with CT_MONITOR_JSON_ALIAS) (default: true).\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    When false, they return 404\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    and its periodic refresh (a full archive scan) never runs\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOGLISTV3_JSON_ETAG\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Send content-hash ETags on /logs.v3.json and answer If-None-Match\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    with 304 (default: false). The hash is computed once per refresh\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_OPERATOR_MAP\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    JSON file mapping log names to operators for /logs.v3.json (default: unset, one operator)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: {\"argon2025h1\": {\"name\": \"Google\", \"email\": [\"ct@example.com\"]}}\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOGLISTV3_JSON_STATIC_GZ\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Pre-generated gzipped log list served as-is (Content-Encoding: gzip) from\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    /logs.v3.json and /monitor.json to clients that accept gzip (default: unset)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_BROTLI\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Compress /logs.v3.json and /monitor.json with Brotli (Content-Encoding: br) for\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    clients that accept br, in preference to gzip (default: false)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_REFRESH_INTERVAL\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Interval for refreshing archive index (default: 5m)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 5m, 1m, 30s)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Optimized for large archive sets to reduce disk I/O\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_REFRESH_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Abandon an archive refresh that takes longer than this and keep the previous\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    snapshot, e.g. on a hung NFS mount (default: 0, disabled)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 2m, 10m)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_NEW_LOG_GRACE_PERIOD\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Keep a log found by a refresh out of the index and /logs.v3.json until it has been\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    present this long (default: 0, disabled). Logs found at startup are served at once\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 10m, 1h)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_SNAPSHOT_EXPORT_PATH\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    File to write the archive index snapshot to, for cold-standby nodes (default: unset)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_SNAPSHOT_EXPORT_INTERVAL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    How often CT_ARCHIVE_SNAPSHOT_EXPORT_PATH is rewritten (default: 5m)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 1m, 5m)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_SNAPSHOT_SEED_PATH\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Exported snapshot to serve from at startup while the archive is scanned in the\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    background (default: unset). Unreadable seeds fall back to a normal startup scan\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "Zip Cache Configuration:\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_CACHE_MAX_OPEN\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum number of open zip parts to cache (default: 256)\n")
@@ -56,14 +191,56 @@ func main() {
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum concurrent zip.OpenReader calls (default: 8)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Limits I/O storms during cold starts when many zip parts are opened simultaneously\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Must be > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_ENTRY_FAILURE_THRESHOLD\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Consecutive failed entry reads, each retried once, before a cached zip part is\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    dropped and re-verified (default: 3). Must be > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZSTD_ZIP_PART_MAX_BYTES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Largest size a NNN.zip.zst part may decompress to; larger parts fail to open\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: 8589934592, i.e. 8 GiB). Must be > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_PREOPEN_NEW_PARTS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Open the newest zip part of each log in the background when a refresh discovers\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    it, so the first request for its tiles skips the cold open (default: false)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_INTEGRITY_FAIL_TTL\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    TTL for failed zip integrity checks (default: 5m)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Failed zip parts are re-tested after this interval\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 5m, 1m, 10m)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_INTEGRITY_PASS_TTL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    TTL for passed zip integrity checks (default: 0, never expire)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Passed zip parts are re-tested the next time they are opened after this interval\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 1h, 24h)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_INTEGRITY_VERIFY_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum time for one zip integrity check (default: 0, unbounded)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    A check still running at the deadline fails the part (503, re-tested after CT_ZIP_INTEGRITY_FAIL_TTL)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 10s, 1m)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_COMPLETE_MARKER\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Text that must appear in a zip part's archive comment for it to be served (default: unset)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Parts without it get 503 and are re-tested after CT_ZIP_INTEGRITY_FAIL_TTL. Example: COMPLETE\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ZIP_GROWTH_CHECK\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Stat each zip part twice, 100ms apart, before its integrity check and treat a size\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    change as still being written (503) (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOG_CIRCUIT_FAILURES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Consecutive zip integrity failures across a log's parts that open its circuit breaker\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: 0, disabled). While open, the log's requests get 503 without touching disk\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOG_CIRCUIT_WINDOW\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Time within which CT_LOG_CIRCUIT_FAILURES failures must occur (default: 1m)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_LOG_CIRCUIT_COOLDOWN\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    How long an open circuit breaker rejects requests before retrying (default: 1m)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENTRY_CACHE_MAX_BYTES\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum bytes of decompressed entry content to cache in memory (default: 268435456, 256MiB)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Set to 0 to disable entry content caching\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Higher values reduce decompression overhead for frequently accessed tiles\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENTRY_CACHE_FILL_CONCURRENCY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum entries read into memory at once to populate the entry cache (default: 64)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Further cache misses stream directly without caching. 0 means no limit\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_EMIT_CONTENT_HASH\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Add X-Content-SHA256 (base64) to tiles served from the entry cache, hashed once when\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    the entry is cached (default: false). Needs CT_ENTRY_CACHE_MAX_BYTES > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ENTRY_CACHE_COMPRESS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Store cached entries deflate-compressed when they shrink by at least 1/8, counting\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    the compressed size against CT_ENTRY_CACHE_MAX_BYTES (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_CACHE_STATS_INTERVAL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Log zip and entry cache statistics at INFO on this interval (default: 0, disabled)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: 5m\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "HTTP Server Configuration:\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_READ_HEADER_TIMEOUT\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum time to read request headers (default: 5s)\n")
@@ -74,18 +251,108 @@ func main() {
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_MAX_HEADER_BYTES\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum size of request headers in bytes (default: 8192)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Must be > 0\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_MAX_HEADER_COUNT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum number of request header lines; more get 431 (default: 0, unlimited)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_WRITE_TIMEOUT\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum time to write response (default: 60s)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 30s, 1m, 0 to disable)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_READ_TIMEOUT\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Maximum time to read request body (default: 0, disabled)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 30s, 1m, 0 to disable)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_STREAM_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Progress-based response write deadline (default: 0, disabled)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Bounds time-to-first-byte and is reset each time response bytes are written,\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    so slow-but-progressing downloads are not cut off. Pair with CT_HTTP_WRITE_TIMEOUT=0.\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 30s, 1m, 0 to disable)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_CONTENT_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Total time budget for tile, checkpoint, log.v3.json and issuer responses (default: 0, disabled)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    503 if nothing was written yet, otherwise the response is aborted. Checkpoint long-polls are exempt.\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 30s, 1m, 0 to disable)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_JSON_RESPONSE_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Total time budget for /logs.v3.json and /monitor.json responses (default: 0, disabled)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    503 if the list is not rendered in time, e.g. under memory pressure\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 5s, 30s, 0 to disable)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_MAX_CLIENT_WAIT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Upper bound for a client's \"Prefer: wait=<seconds>\" deadline on the same routes as\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    CT_HTTP_CONTENT_TIMEOUT (default: 0, header ignored)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Format: Go duration (e.g., 30s, 1m, 0 to disable)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_STREAM_FLUSH_INTERVAL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Flush responses to the client every this many bytes (default: 0, disabled)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: 65536 lets clients and proxies process large tiles as they arrive\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_DEFAULT_CACHE_CONTROL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Cache-Control for non-error responses without their own policy (default: unset)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Immutable archive content and no-store endpoints keep theirs. Example: public, max-age=60\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_TLS_CERT_FILE, CT_HTTP_TLS_KEY_FILE\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    PEM certificate chain and key; when both are set the listener serves HTTPS\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_TLS_MIN_VERSION\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Minimum TLS version, 1.2 or 1.3 (default: 1.2). 1.3 makes the server TLS 1.3 only\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_TLS_CLIENT_CA\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    PEM CA bundle; admin endpoints then require a client certificate it verifies (mTLS)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP2_H2C\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Also serve HTTP/2 cleartext (h2c, prior knowledge) on the same port (default: false)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Lets clients multiplex many tile requests over one connection\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_NETWORK\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Listener network: tcp (dual-stack), tcp4 (IPv4 only) or tcp6 (IPv6 only) (default: tcp)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_NOT_FOUND_BODY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Response body for 404 responses (default: \"404 page not found\")\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_NOT_FOUND_CONTENT_TYPE\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Content-Type for 404 responses (default: text/plain; charset=utf-8)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: CT_HTTP_NOT_FOUND_BODY='{\"error\":\"not found\"}' CT_HTTP_NOT_FOUND_CONTENT_TYPE=application/json\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_SERVE_WELLKNOWN\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Answer /favicon.ico (204) and /robots.txt instead of 404 (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ROBOTS_TXT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    /robots.txt body when CT_SERVE_WELLKNOWN=true; \\n escapes become newlines\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: disallow all crawlers)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ROOT_REDIRECT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Redirect GET / (302) to this absolute path or http(s) URL, e.g. /logs.v3.json\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: unset, / returns 404)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_TRUSTED_SOURCES\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    CSV list of trusted IP addresses or CIDR networks for X-Forwarded-* headers\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    If set, X-Forwarded-Host and X-Forwarded-Proto are trusted when request\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    source IP matches. If unset or empty, X-Forwarded-* headers are ignored.\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Format: comma-separated IPs or CIDRs (e.g., 127.0.0.1/32,10.0.0.0/8)\n")
 		_, _ = fmt.Fprintf(os.Stdout, "    Example: 127.0.0.1/32,10.0.0.0/8,172.16.0.0/12\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_REQUIRE_TLS_OR_TRUSTED_PROXY\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Refuse to start unless CT_HTTP_TLS_CERT_FILE/CT_HTTP_TLS_KEY_FILE or\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    CT_HTTP_TRUSTED_SOURCES is set (default: false). A guardrail for public deployments\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_HTTP_BLOCKED_USER_AGENTS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    CSV of User-Agent patterns answered with 403: case-insensitive substrings, or\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    regular expressions wrapped in slashes (default: unset)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Example: BadBot,/^python-requests/\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "Startup Configuration:\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_STARTUP_SELFTEST\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Before listening, serve the checkpoint, log.v3.json and a tile of the first\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    discovered log in-process and exit if any request fails (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_READY_MIN_LOGS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    /readyz returns 503 until at least this many logs are discovered (default: 0)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Keeps a node out of load balancer rotation while its archive mount is missing\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_MIN_FREE_DISK_BYTES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    /readyz returns 503 while the archive filesystem has less free space (default: 0, off)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Linux only\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_PROBE_INTERVAL\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Probe the archive mount this often, independently of refreshes; /readyz returns 503\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    while the probe fails (default: 0, off)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_PROBE_TIMEOUT\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Time a mount probe may take before it counts as failed (default: 5s)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ARCHIVE_PROBE_FILE\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Canary file, relative to CT_ARCHIVE_PATH, that probes read instead of stating\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    CT_ARCHIVE_PATH (default: unset)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "Admin Configuration:\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_ADMIN_TOKEN\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Bearer token enabling admin endpoints such as /<log>/parts/<NNN>/manifest.json\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    (default: unset, admin endpoints return 404). Requests must send Authorization: Bearer <token>\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "Metrics Configuration:\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_METRICS_SUMMARIES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Export per-log request duration quantiles (p50/p90/p99) as a summary (default: false)\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Summaries are more expensive than the default histogram; enable only if needed\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_METRICS_MAX_LOG_SERIES\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Maximum logs with per-log request series; beyond it the least recently requested\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    log's series are dropped (default: 0, no cap)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_METRICS_EXEMPLARS\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Attach the trace ID of a request's W3C traceparent header as an exemplar to the\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    request duration histograms, exposed in the OpenMetrics format (default: false)\n\n")
+		_, _ = fmt.Fprintf(os.Stdout, "  CT_METRICS_RUNTIME\n")
+		_, _ = fmt.Fprintf(os.Stdout, "    Export standard Go runtime (go_*) and process (process_*) metrics (default: true)\n\n")
 		_, _ = fmt.Fprintf(os.Stdout, "For more details, see README.md\n")
 		os.Exit(0)
 	}
@@ -108,7 +375,11 @@ func main() {
 	// Initialize metrics
 	logger.Debug("Initializing metrics")
 	reg := prometheus.NewRegistry()
-	metrics := ctarchiveserve.NewMetrics(reg)
+	metrics := ctarchiveserve.NewMetricsWithOptions(reg, ctarchiveserve.MetricsOptions{
+		Summaries:    cfg.MetricsSummaries,
+		Runtime:      cfg.MetricsRuntime,
+		MaxLogSeries: cfg.MetricsMaxLogSeries,
+	})
 
 	// Initialize archive index
 	logger.Debug("Initializing archive index", "archive_path", cfg.ArchivePath)
@@ -133,42 +404,119 @@ func main() {
 		nil, // use default verify function
 		metrics,
 	)
+	if cfg.ArchiveImmutable {
+		logger.Debug("Archive is immutable, passed zip integrity checks are never re-tested")
+		zipIntegrityCache.SetImmutable(true)
+	}
+	if cfg.ZipIntegrityPassTTL > 0 {
+		logger.Debug("Passed zip integrity checks expire", "pass_ttl", cfg.ZipIntegrityPassTTL)
+		zipIntegrityCache.SetPassTTL(cfg.ZipIntegrityPassTTL)
+	}
+	if cfg.ZipIntegrityVerifyTimeout > 0 {
+		logger.Debug("Zip integrity checks are bounded", "verify_timeout", cfg.ZipIntegrityVerifyTimeout)
+		zipIntegrityCache.SetVerifyTimeout(cfg.ZipIntegrityVerifyTimeout)
+	}
+	if cfg.ZipCompleteMarker != "" {
+		logger.Debug("Zip parts must carry a completion marker in their comment", "marker", cfg.ZipCompleteMarker)
+		zipIntegrityCache.SetCompleteMarker(cfg.ZipCompleteMarker)
+	}
+	if cfg.ZipGrowthCheck {
+		logger.Debug("Zip parts whose size changes during the integrity check are treated as still being written", "delay", ctarchiveserve.ZipGrowthCheckDelay)
+		zipIntegrityCache.SetGrowthCheck(ctarchiveserve.ZipGrowthCheckDelay)
+	}
+	if cfg.LogCircuitFailures > 0 {
+		logger.Debug("Per-log circuit breakers enabled", "failures", cfg.LogCircuitFailures,
+			"window", cfg.LogCircuitWindow, "cooldown", cfg.LogCircuitCooldown)
+		zipIntegrityCache.SetLogCircuitBreaker(cfg.LogCircuitFailures, cfg.LogCircuitWindow, cfg.LogCircuitCooldown)
+	}
 
 	// Initialize zip part cache (Phase 5 performance optimization)
 	logger.Debug("Initializing zip part cache", "max_open", cfg.ZipCacheMaxOpen, "max_concurrent_opens", cfg.ZipCacheMaxConcurrentOpens)
 	zipPartCache := ctarchiveserve.NewZipPartCache(cfg.ZipCacheMaxOpen, metrics, cfg.ZipCacheMaxConcurrentOpens)
+	archiveIndex.OnRefresh(zipPartCache.DropRemovedLogs)
 
 	// Initialize entry content cache (decompressed tile data cache)
 	var entryCache *ctarchiveserve.EntryContentCache
 	if cfg.EntryContentCacheMaxBytes > 0 {
 		logger.Debug("Initializing entry content cache", "max_bytes", cfg.EntryContentCacheMaxBytes)
 		entryCache = ctarchiveserve.NewEntryContentCache(cfg.EntryContentCacheMaxBytes, metrics)
+		entryCache.SetContentHash(cfg.EmitContentHash)
+		entryCache.SetCompress(cfg.EntryCacheCompress)
 	} else {
 		logger.Debug("Entry content cache disabled (CT_ENTRY_CACHE_MAX_BYTES=0)")
 	}
 
+	if cfg.CacheStatsInterval > 0 {
+		logger.Debug("Starting cache statistics logging", "interval", cfg.CacheStatsInterval)
+		ctarchiveserve.NewCacheStatsLogger(cfg.CacheStatsInterval, logger, metrics, zipPartCache, entryCache).Start(ctx)
+	}
+
 	// Initialize zip reader
 	logger.Debug("Initializing zip reader")
 	zipReader := ctarchiveserve.NewZipReader(zipIntegrityCache)
 	zipReader.SetZipPartCache(zipPartCache)
+	zipReader.SetEntryFailureThreshold(cfg.ZipEntryFailureThreshold)
+	ctarchiveserve.SetZstdZipPartMaxBytes(cfg.ZstdZipPartMaxBytes)
 	if entryCache != nil {
 		zipReader.SetEntryContentCache(entryCache)
+		zipReader.SetEntryCacheFillConcurrency(cfg.EntryCacheFillConcurrency)
+	}
+	if cfg.PreopenNewParts {
+		logger.Debug("Pre-opening newly discovered zip parts")
+		ctarchiveserve.NewZipPartPreopener(archiveIndex, zipReader, logger).Start(ctx)
 	}
 
-	// Initialize logs.v3.json builder
-	logger.Debug("Initializing logs.v3.json builder")
-	logListV3JSON := ctarchiveserve.NewLogListV3JSONBuilder(cfg, zipReader, archiveIndex, logger)
+	// Initialize logs.v3.json builder (skipped entirely when the endpoint is disabled)
+	var logListV3JSON *ctarchiveserve.LogListV3JSONBuilder
+	if cfg.DisableLogListV3JSON {
+		logger.Debug("Logs.v3.json disabled (CT_ENABLE_LOGLISTV3_JSON=false), not starting refresh loop")
+	} else {
+		logger.Debug("Initializing logs.v3.json builder")
+		logListV3JSON = ctarchiveserve.NewLogListV3JSONBuilder(cfg, zipReader, archiveIndex, logger)
+		if cfg.OperatorMapFile != "" {
+			operators, err := ctarchiveserve.LoadOperatorMap(cfg.OperatorMapFile)
+			if err != nil {
+				logger.Error("Invalid CT_OPERATOR_MAP", "error", err)
+				os.Exit(1) //nolint:gocritic // exitAfterDefer: startup failure
+			}
+			logger.Debug("Loaded operator map", "path", cfg.OperatorMapFile, "logs", len(operators))
+			logListV3JSON.SetOperatorMap(operators)
+		}
+		if cfg.LogListV3JSONStaticGz != "" {
+			if err := ctarchiveserve.ValidateLogListV3JSONStaticGz(cfg.LogListV3JSONStaticGz); err != nil {
+				logger.Error("Invalid CT_LOGLISTV3_JSON_STATIC_GZ", "error", err)
+				os.Exit(1) //nolint:gocritic // exitAfterDefer: startup failure
+			}
+			logger.Debug("Serving static logs.v3.json to gzip clients", "path", cfg.LogListV3JSONStaticGz)
+		}
 
-	// Start logs.v3.json refresh loop (URLs set per-request)
-	logger.Debug("Starting logs.v3.json refresh loop", "interval", cfg.LogListV3JSONRefreshInterval)
-	logger.Debug("Performing initial logs.v3.json refresh (this may take time with many archives)")
-	logListV3JSON.Start(ctx)
-	logger.Debug("Logs.v3.json initial refresh completed")
+		// Start logs.v3.json refresh loop (URLs set per-request)
+		logger.Debug("Starting logs.v3.json refresh loop", "interval", cfg.LogListV3JSONRefreshInterval)
+		logger.Debug("Performing initial logs.v3.json refresh (this may take time with many archives)")
+		logListV3JSON.Start(ctx)
+		logger.Debug("Logs.v3.json initial refresh completed")
+	}
 
 	// Create HTTP server
 	logger.Debug("Creating HTTP server")
 	server := ctarchiveserve.NewServer(cfg, logger, metrics, archiveIndex, zipReader, logListV3JSON)
 	server.SetVerbose(verboseEnabled)
+	if cfg.ArchiveProbeInterval > 0 {
+		logger.Debug("Starting archive mount probe", "interval", cfg.ArchiveProbeInterval,
+			"timeout", cfg.ArchiveProbeTimeout, "file", cfg.ArchiveProbeFile)
+		mountProbe := ctarchiveserve.NewMountProbe(cfg, logger, metrics)
+		mountProbe.Start(ctx)
+		server.SetMountProbe(mountProbe)
+	}
+
+	if cfg.StartupSelfTest {
+		logger.Debug("Running startup self-test")
+		if err := server.SelfTest(ctx); err != nil {
+			logger.Error("Startup self-test failed", "error", err)
+			os.Exit(1) //nolint:gocritic // exitAfterDefer: startup failure, nothing to clean up yet
+		}
+		logger.Info("Startup self-test passed")
+	}
 
 	// Configure http.Server with timeouts and limits per spec.md FR-012
 	httpServer := &http.Server{
@@ -179,6 +527,16 @@ func main() {
 		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
 		WriteTimeout:      cfg.HTTPWriteTimeout,
 		ReadTimeout:       cfg.HTTPReadTimeout,
+		Protocols:         ctarchiveserve.HTTPProtocols(cfg),
+	}
+	tlsEnabled := cfg.HTTPTLSCertFile != ""
+	if tlsEnabled {
+		tlsConfig, err := ctarchiveserve.NewTLSConfig(cfg)
+		if err != nil {
+			logger.Error("Invalid TLS configuration", "error", err)
+			os.Exit(1) //nolint:gocritic // exitAfterDefer: startup failure, nothing to clean up yet
+		}
+		httpServer.TLSConfig = tlsConfig
 	}
 	logger.Debug("HTTP server configured", "addr", httpServer.Addr)
 
@@ -197,15 +555,33 @@ func main() {
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			logger.Error("Error during server shutdown", "error", err)
 		}
+		if err := zipPartCache.Close(); err != nil {
+			logger.Error("Error closing zip part cache", "error", err)
+		}
+		if err := zipIntegrityCache.Close(); err != nil {
+			logger.Error("Error closing zip integrity cache", "error", err)
+		}
 	}()
 
-	logger.Info("Starting ct-archive-serve", "addr", httpServer.Addr)
-	logger.Debug("Attempting to bind HTTP listener", "addr", httpServer.Addr)
+	logger.Info("Starting ct-archive-serve", "addr", httpServer.Addr, "network", cfg.HTTPNetwork, "tls", tlsEnabled)
+	logger.Debug("Attempting to bind HTTP listener", "addr", httpServer.Addr, "network", cfg.HTTPNetwork)
+
+	ln, err := ctarchiveserve.Listen(cfg, httpServer.Addr)
+	if err != nil {
+		logger.Error("Failed to bind HTTP listener", "error", err)
+		os.Exit(1) //nolint:gocritic // exitAfterDefer: startup failure, nothing to clean up yet
+	}
 
-	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	var serveErr error
+	if tlsEnabled {
+		serveErr = httpServer.ServeTLS(ln, cfg.HTTPTLSCertFile, cfg.HTTPTLSKeyFile)
+	} else {
+		serveErr = httpServer.Serve(ln)
+	}
+	if err := serveErr; err != nil && err != http.ErrServerClosed {
 		logger.Error("Server error", "error", err)
 		//nolint:gocritic // exitAfterDefer: os.Exit is intentional here for fatal server errors
-		// The defer cancel() above is for graceful shutdown, but if ListenAndServe fails
+		// The defer cancel() above is for graceful shutdown, but if Serve fails
 		// during startup, we exit immediately rather than attempting shutdown.
 		os.Exit(1)
 	}
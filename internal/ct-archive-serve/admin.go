@@ -0,0 +1,193 @@
+package ctarchiveserve
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// requireAdmin gates admin/debug endpoints behind CT_ADMIN_TOKEN.
+//
+// When no token is configured the admin surface does not exist and the request gets a
+// plain 404. With CT_HTTP_TLS_CLIENT_CA, requests without a verified client certificate
+// get 403. Otherwise the request must carry "Authorization: Bearer <token>"; anything
+// else gets 401. Returns true if the handler may proceed.
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.cfg.AdminToken == "" {
+		s.notFound(w, r)
+		return false
+	}
+
+	if s.cfg.HTTPTLSClientCAFile != "" && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+		http.Error(w, "Client certificate required", http.StatusForbidden)
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.cfg.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ct-archive-serve"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// zipPartManifest is the JSON body of GET /<log>/parts/<NNN>/manifest.json.
+type zipPartManifest struct {
+	Log     string             `json:"log"`
+	Part    int                `json:"part"`
+	Entries []ZipManifestEntry `json:"entries"`
+}
+
+// handleZipPartManifest serves GET /<log>/parts/<NNN>/manifest.json (admin), listing the
+// entry names and sizes of one discovered zip part.
+func (s *Server) handleZipPartManifest(w http.ResponseWriter, r *http.Request, route Route) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.zipReader == nil || s.archiveIndex == nil {
+		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
+		return
+	}
+
+	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
+	if !ok || !slices.Contains(archiveLog.ZipParts, route.ZipPart) {
+		s.notFound(w, r)
+		return
+	}
+
+	release, ok := s.acquireHeavyOp(w)
+	if !ok {
+		return
+	}
+	defer release()
+
+	zipPath := archiveLog.ZipPartPath(route.ZipPart)
+	entries, err := s.zipReader.ListEntries(r.Context(), zipPath)
+	if err != nil {
+		s.writeOpenEntryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	if err := json.NewEncoder(w).Encode(zipPartManifest{Log: route.Log, Part: route.ZipPart, Entries: entries}); err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode zip part manifest", "log", route.Log, "part", route.ZipPart, "error", err)
+		}
+	}
+}
+
+// zipCacheSnapshot is the JSON body of GET /admin/zipcache.json.
+type zipCacheSnapshot struct {
+	Capacity int                `json:"capacity"`
+	Open     int                `json:"open"`
+	Parts    []ZipPartCacheItem `json:"parts"`
+}
+
+// handleZipCacheSnapshot serves GET /admin/zipcache.json (admin), listing the zip parts
+// currently held open by the zip part cache with their last use, most recent first. A
+// full cache whose oldest last_used is recent suggests CT_ZIP_CACHE_MAX_OPEN is too small
+// for the working set.
+func (s *Server) handleZipCacheSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var cache *ZipPartCache
+	if s.zipReader != nil {
+		cache = s.zipReader.cache
+	}
+	parts := cache.Snapshot()
+	if parts == nil {
+		parts = []ZipPartCacheItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	if err := json.NewEncoder(w).Encode(zipCacheSnapshot{Capacity: cache.Capacity(), Open: len(parts), Parts: parts}); err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode zip cache snapshot", "error", err)
+		}
+	}
+}
+
+// handleAdminConfig serves GET /admin/config.json (admin): the effective configuration
+// after env parsing and defaults, with secrets redacted (Config.Redacted).
+func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	if err := json.NewEncoder(w).Encode(s.cfg.Redacted()); err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode config", "error", err)
+		}
+	}
+}
+
+// handleAdminMetadata serves GET /admin/metadata.json (admin): for each log, the
+// log.v3.json the last /logs.v3.json build parsed, before URL rewriting, or the error that
+// left the log out of the list. Before the first build the list is empty.
+func (s *Server) handleAdminMetadata(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	dump := s.logListV3JSON.MetadataDump()
+	if dump == nil {
+		dump = &LogMetadataDump{Logs: []LogMetadataResult{}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	if err := json.NewEncoder(w).Encode(dump); err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode metadata dump", "error", err)
+		}
+	}
+}
+
+// handleAdminArchiveSnapshot serves GET /admin/archive-snapshot.json (admin): the served
+// archive index as ArchiveSnapshotExport, the format CT_ARCHIVE_SNAPSHOT_SEED_PATH reads.
+func (s *Server) handleAdminArchiveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.archiveIndex == nil {
+		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	if err := json.NewEncoder(w).Encode(s.archiveIndex.Export()); err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode archive snapshot", "error", err)
+		}
+	}
+}
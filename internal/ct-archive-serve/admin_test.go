@@ -0,0 +1,346 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newAdminTestServer creates a server over a single log with 000.zip and 001.zip.
+func newAdminTestServer(t *testing.T, adminToken string) *Server {
+	t.Helper()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint":  []byte("checkpoint data"),
+		"log.v3.json": []byte(`{}`),
+	})
+	mustCreateZip(t, filepath.Join(logFolder, "001.zip"), map[string][]byte{
+		"tile/data/x001/000": []byte("data tile"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		AdminToken:           adminToken,
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	zr.SetZipPartCache(NewZipPartCache(10, metrics, 1))
+	return NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+}
+
+func adminRequest(method, path, token string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestServer_ZipPartManifest(t *testing.T) {
+	t.Parallel()
+
+	server := newAdminTestServer(t, "s3cret")
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/test_log/parts/000/manifest.json", "s3cret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body=%q)", w.Code, http.StatusOK, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want %q", ct, "application/json")
+	}
+
+	var got zipPartManifest
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got.Log != "test_log" || got.Part != 0 {
+		t.Errorf("manifest log/part = %q/%d, want %q/%d", got.Log, got.Part, "test_log", 0)
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("manifest entries = %+v, want 2 entries", got.Entries)
+	}
+	if got.Entries[0].Name != "checkpoint" || got.Entries[0].Size != uint64(len("checkpoint data")) {
+		t.Errorf("entries[0] = %+v, want checkpoint with size %d", got.Entries[0], len("checkpoint data"))
+	}
+	if got.Entries[1].Name != "log.v3.json" {
+		t.Errorf("entries[1].Name = %q, want %q", got.Entries[1].Name, "log.v3.json")
+	}
+
+	// Second part is listed independently.
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/test_log/parts/001/manifest.json", "s3cret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("part 001 status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(got.Entries) != 1 || got.Entries[0].Name != "tile/data/x001/000" {
+		t.Errorf("part 001 entries = %+v, want [tile/data/x001/000]", got.Entries)
+	}
+}
+
+func TestServer_ZipPartManifest_HEAD(t *testing.T) {
+	t.Parallel()
+
+	server := newAdminTestServer(t, "s3cret")
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodHead, "/test_log/parts/000/manifest.json", "s3cret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("HEAD body length = %d, want 0", w.Body.Len())
+	}
+}
+
+func TestServer_ZipPartManifest_Access(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		adminToken string
+		reqToken   string
+		path       string
+		wantStatus int
+	}{
+		{name: "admin disabled", adminToken: "", reqToken: "anything", path: "/test_log/parts/000/manifest.json", wantStatus: http.StatusNotFound},
+		{name: "missing token", adminToken: "s3cret", reqToken: "", path: "/test_log/parts/000/manifest.json", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", adminToken: "s3cret", reqToken: "nope", path: "/test_log/parts/000/manifest.json", wantStatus: http.StatusUnauthorized},
+		{name: "absent part", adminToken: "s3cret", reqToken: "s3cret", path: "/test_log/parts/002/manifest.json", wantStatus: http.StatusNotFound},
+		{name: "unknown log", adminToken: "s3cret", reqToken: "s3cret", path: "/other_log/parts/000/manifest.json", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newAdminTestServer(t, tc.adminToken)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, adminRequest(http.MethodGet, tc.path, tc.reqToken))
+			if w.Code != tc.wantStatus {
+				t.Fatalf("GET %s status = %d, want %d", tc.path, w.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("401 response missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestServer_ZipCacheSnapshot(t *testing.T) {
+	t.Parallel()
+
+	server := newAdminTestServer(t, "s3cret")
+
+	// Open both parts through the cache, 000.zip last.
+	for _, path := range []string{"/test_log/parts/001/manifest.json", "/test_log/checkpoint"} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, adminRequest(http.MethodGet, path, "s3cret"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/zipcache.json", "s3cret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%q", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var got zipCacheSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal(%q) error = %v", w.Body.String(), err)
+	}
+	if got.Open != 2 || len(got.Parts) != 2 {
+		t.Fatalf("open = %d, parts = %v; want 2 open parts", got.Open, got.Parts)
+	}
+	if got.Capacity < got.Open {
+		t.Errorf("capacity = %d, want >= open (%d)", got.Capacity, got.Open)
+	}
+	if base := filepath.Base(got.Parts[0].Path); base != "000.zip" {
+		t.Errorf("most recently used part = %q, want 000.zip", got.Parts[0].Path)
+	}
+	if base := filepath.Base(got.Parts[1].Path); base != "001.zip" {
+		t.Errorf("least recently used part = %q, want 001.zip", got.Parts[1].Path)
+	}
+	for _, p := range got.Parts {
+		if p.LastUsed.IsZero() {
+			t.Errorf("%s last_used is zero", p.Path)
+		}
+	}
+
+	// Admin gate applies.
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/zipcache.json", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without token status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestServer_AdminConfig(t *testing.T) {
+	t.Parallel()
+
+	server := newAdminTestServer(t, "s3cret")
+	server.cfg.HTTPTLSKeyFile = "/etc/ct/key.pem"
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/config.json", "s3cret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%q", w.Code, http.StatusOK, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Fatalf("body leaks the admin token: %s", w.Body.String())
+	}
+
+	var got Config
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal(%q) error = %v", w.Body.String(), err)
+	}
+	if got.AdminToken != "" {
+		t.Errorf("AdminToken = %q, want redacted", got.AdminToken)
+	}
+	if got.ArchivePath != server.cfg.ArchivePath || got.ArchiveFolderPrefix != "ct_" {
+		t.Errorf("ArchivePath = %q, ArchiveFolderPrefix = %q; want %q, %q",
+			got.ArchivePath, got.ArchiveFolderPrefix, server.cfg.ArchivePath, "ct_")
+	}
+	if got.HTTPTLSKeyFile != "/etc/ct/key.pem" {
+		t.Errorf("HTTPTLSKeyFile = %q, want the key path", got.HTTPTLSKeyFile)
+	}
+	if server.cfg.AdminToken != "s3cret" {
+		t.Errorf("Redacted() modified the server config")
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/config.json", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without token status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestServer_AdminArchiveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	server := newAdminTestServer(t, "secret")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/archive-snapshot.json", "secret"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	var exp ArchiveSnapshotExport
+	if err := json.Unmarshal(w.Body.Bytes(), &exp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if exp.Version != archiveSnapshotExportVersion || len(exp.Logs) != 1 {
+		t.Fatalf("export = %+v, want version %d with one log", exp, archiveSnapshotExportVersion)
+	}
+	if l := exp.Logs[0]; l.Log != "test_log" || l.Folder != "ct_test_log" || !slices.Equal(l.ZipParts, []int{0, 1}) || l.FirstDiscovered.IsZero() {
+		t.Errorf("log = %+v, want test_log in ct_test_log with parts [0 1]", l)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/archive-snapshot.json", "wrong"))
+	if w.Code == http.StatusOK {
+		t.Errorf("wrong token: status = %d, want an error", w.Code)
+	}
+}
+
+func TestServer_AdminMetadata(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for log, entries := range map[string]map[string][]byte{
+		"good":    {"log.v3.json": []byte(`{"description":"Good log","log_id":"aWQ=","key":"a2V5","mmd":86400,"log_type":"prod","url":"https://ct.example/good/"}`), "issuer/0a": []byte("x")},
+		"bad":     {"log.v3.json": []byte(`{"description":`)},
+		"missing": {"checkpoint": []byte("checkpoint data")},
+	} {
+		mustMkdir(t, filepath.Join(root, "ct_"+log))
+		mustCreateZip(t, filepath.Join(root, "ct_"+log, "000.zip"), entries)
+	}
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		AdminToken:           "secret",
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, builder)
+
+	get := func() LogMetadataDump {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/metadata.json", "secret"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var dump LogMetadataDump
+		if err := json.Unmarshal(w.Body.Bytes(), &dump); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		return dump
+	}
+
+	// Before the first build there is nothing to report.
+	if dump := get(); len(dump.Logs) != 0 {
+		t.Fatalf("before build: logs = %+v, want none", dump.Logs)
+	}
+
+	builder.refreshOnce("http://example.com")
+	dump := get()
+	if len(dump.Logs) != 3 {
+		t.Fatalf("logs = %+v, want 3", dump.Logs)
+	}
+	byLog := make(map[string]LogMetadataResult)
+	for _, l := range dump.Logs {
+		byLog[l.Log] = l
+	}
+	if good := byLog["good"]; good.Error != "" || good.Entry == nil || good.Entry.Description != "Good log" ||
+		good.Entry.URL != "https://ct.example/good/" || good.Entry.MMD != 86400 || !good.HasIssuers {
+		t.Errorf("good = %+v, want parsed entry with its original url and has_issuers", good)
+	}
+	if bad := byLog["bad"]; bad.Entry != nil || !strings.Contains(bad.Error, "parse log.v3.json") {
+		t.Errorf("bad = %+v, want a parse error", bad)
+	}
+	if missing := byLog["missing"]; missing.Entry != nil || !strings.Contains(missing.Error, "not found") {
+		t.Errorf("missing = %+v, want a not found error", missing)
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/metadata.json", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -2,38 +2,102 @@ package ctarchiveserve
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // ArchiveSnapshot is an immutable view of the currently discovered archive state.
 type ArchiveSnapshot struct {
 	Logs map[string]ArchiveLog
+
+	// Collisions maps log names left out of Logs under CT_ARCHIVE_COLLISION_POLICY=skip
+	// to the folders that all map to that name.
+	Collisions map[string][]string
+
+	// Pending holds logs discovered by a refresh that are still within
+	// CT_NEW_LOG_GRACE_PERIOD. They are not served until a later refresh finds the grace
+	// period over and moves them to Logs.
+	Pending map[string]ArchiveLog
 }
 
+// CT_ARCHIVE_COLLISION_POLICY values: what buildArchiveSnapshot does when several
+// folders map to the same log name.
+const (
+	ArchiveCollisionFail = "fail" // fail the whole scan
+	ArchiveCollisionSkip = "skip" // leave the log out and index the rest
+)
+
+// CT_ARCHIVE_NESTED_LOG_NAMES values: how log folders below operator directories
+// (CT_ARCHIVE_NESTED_DEPTH) are named.
+const (
+	ArchiveNestedLogNamesFolder = "folder" // from the log folder alone, as in a flat archive
+	ArchiveNestedLogNamesPath   = "path"   // operator directories and log folder joined by '_'
+)
+
 // ArchiveLog describes one discovered log folder under CT_ARCHIVE_PATH.
 type ArchiveLog struct {
-	Log        string
+	Log string
+	// FolderName is the folder's path relative to CT_ARCHIVE_PATH: its name, preceded by
+	// the operator directories with CT_ARCHIVE_NESTED_DEPTH.
 	FolderName string
 	FolderPath string
 
 	// ZipParts are the discovered `NNN.zip` indices for this log, sorted ascending.
 	ZipParts []int
 
+	// ZstdZipParts holds the indices in ZipParts stored only as `NNN.zip.zst`.
+	// When both forms exist the uncompressed `NNN.zip` is used.
+	ZstdZipParts map[int]bool
+
 	// FirstDiscovered is the timestamp when this log was first discovered (when 000.zip was first found).
 	// This is used to set the "retired" state timestamp in logs.v3.json.
 	FirstDiscovered time.Time
 }
 
+// ZipPartPath returns the path of zip part idx, either `NNN.zip` or `NNN.zip.zst`.
+func (l ArchiveLog) ZipPartPath(idx int) string {
+	if l.ZstdZipParts[idx] {
+		return fmt.Sprintf("%s/%03d%s", l.FolderPath, idx, zstdZipPartSuffix)
+	}
+	return fmt.Sprintf("%s/%03d.zip", l.FolderPath, idx)
+}
+
+// logSelected reports whether a discovered log should be indexed. With a non-empty
+// allowlist only matching logs are selected and the denylist is not consulted, so the
+// allowlist takes precedence; otherwise logs matching the denylist are excluded.
+// Patterns were validated by parseLogGlobsCSV.
+func logSelected(log string, allow, deny []string) bool {
+	if len(allow) > 0 {
+		return matchesAnyGlob(log, allow)
+	}
+	return !matchesAnyGlob(log, deny)
+}
+
+func matchesAnyGlob(name string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // ArchiveIndex maintains an in-memory view of discovered logs and zip parts.
 //
 // The request hot path MUST consult this in-memory snapshot and MUST NOT rescan disk.
@@ -43,26 +107,59 @@ type ArchiveIndex struct {
 
 	logger  *slog.Logger
 	metrics *Metrics
+	now     func() time.Time
 
 	snap atomic.Value // stores ArchiveSnapshot
 
+	// refreshGroup coalesces overlapping refresh triggers into a single disk scan whose
+	// result is shared by all callers.
+	refreshGroup singleflight.Group
+
 	// refreshMu serializes refresh operations to prevent concurrent disk scans
 	// (e.g., if a refresh takes longer than the refresh interval)
 	refreshMu sync.Mutex
+
+	// refreshHooks run after each successful refresh, under refreshMu.
+	refreshHooks []func(ArchiveSnapshot)
+
+	// seeded is set when the snapshot was seeded from CT_ARCHIVE_SNAPSHOT_SEED_PATH and
+	// Start still has to replace it with a disk scan.
+	seeded bool
+
+	// abandonedScan is closed when a scan abandoned by CT_ARCHIVE_REFRESH_TIMEOUT finally
+	// returns; nil when none is outstanding. Guarded by refreshMu.
+	abandonedScan chan struct{}
 }
 
+// errArchiveRefreshTimeout is returned when a refresh exceeds CT_ARCHIVE_REFRESH_TIMEOUT.
+var errArchiveRefreshTimeout = errors.New("archive refresh timed out")
+
 func NewArchiveIndex(cfg Config, logger *slog.Logger, metrics *Metrics) (*ArchiveIndex, error) {
 	ai := &ArchiveIndex{
 		cfg:     cfg,
 		readDir: os.ReadDir,
 		logger:  logger,
 		metrics: metrics,
+		now:     time.Now,
+	}
+
+	if cfg.ArchiveSnapshotSeedPath != "" {
+		err := ai.seedSnapshot()
+		if err == nil {
+			if logger != nil {
+				logger.Info("Archive index seeded from snapshot, scanning the archive in the background", "path", cfg.ArchiveSnapshotSeedPath, "log_count", ai.LogCount())
+			}
+			return ai, nil
+		}
+		if logger != nil {
+			logger.Warn("Cannot seed archive index from snapshot, scanning the archive first", "path", cfg.ArchiveSnapshotSeedPath, "error", err)
+		}
 	}
 
 	if logger != nil {
-		logger.Debug("Building initial archive snapshot", "archive_path", cfg.ArchivePath, "folder_pattern", cfg.ArchiveFolderPrefix+"*")
+		logger.Debug("Building initial archive snapshot", "archive_path", cfg.ArchivePath, "folder_pattern", cfg.ArchiveFolderPrefix+"*"+cfg.ArchiveFolderSuffix)
 	}
-	snap, err := buildArchiveSnapshot(cfg, ai.readDir, logger, nil)
+	snap, err := buildArchiveSnapshot(cfg, ai.readDir, logger, metrics, nil, ai.now())
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +177,22 @@ func (ai *ArchiveIndex) Start(ctx context.Context) {
 		return
 	}
 
+	if ai.cfg.ArchiveSnapshotExportPath != "" {
+		go ai.exportLoop(ctx)
+	}
+	if ai.seeded {
+		go func() { _ = ai.rescanSeed() }() //nolint:errcheck // failures are logged by rescanSeed
+	}
+
+	// Immutable archives never change after the initial snapshot built by NewArchiveIndex,
+	// so periodic rescans would only cost disk I/O.
+	if ai.cfg.ArchiveImmutable {
+		if ai.logger != nil {
+			ai.logger.Debug("Archive is immutable, periodic archive refresh disabled")
+		}
+		return
+	}
+
 	t := time.NewTicker(ai.cfg.ArchiveRefreshInterval)
 	go func() {
 		defer t.Stop()
@@ -88,7 +201,7 @@ func (ai *ArchiveIndex) Start(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			case <-t.C:
-				ai.refreshOnce()
+				_ = ai.refreshOnce() //nolint:errcheck // failures are logged by refreshLocked
 			}
 		}
 	}()
@@ -111,6 +224,16 @@ func (ai *ArchiveIndex) LookupLog(log string) (ArchiveLog, bool) {
 	return l, ok
 }
 
+// LookupCollision reports whether log was left out of the index because several folders
+// map to its name (CT_ARCHIVE_COLLISION_POLICY=skip), and returns those folders.
+func (ai *ArchiveIndex) LookupCollision(log string) ([]string, bool) {
+	if ai == nil {
+		return nil, false
+	}
+	folders, ok := ai.GetAllLogs().Collisions[log]
+	return folders, ok
+}
+
 // SelectZipPart selects the appropriate zip part index for a tile request per spec.md FR-008.
 //
 // For hash tiles at level L with index N:
@@ -209,7 +332,22 @@ func (ai *ArchiveIndex) GetAllLogs() ArchiveSnapshot {
 	return snap
 }
 
-func (ai *ArchiveIndex) refreshOnce() {
+// LogCount returns the number of logs in the current snapshot.
+func (ai *ArchiveIndex) LogCount() int {
+	return len(ai.GetAllLogs().Logs)
+}
+
+// refreshOnce rescans the archive and stores the new snapshot. Overlapping calls are
+// coalesced via refreshGroup so only one scan runs; all callers receive its error.
+func (ai *ArchiveIndex) refreshOnce() error {
+	_, err, _ := ai.refreshGroup.Do("refresh", func() (interface{}, error) {
+		return nil, ai.refreshLocked()
+	})
+	return err //nolint:wrapcheck // already wrapped by buildArchiveSnapshot
+}
+
+// refreshLocked performs one rescan under refreshMu.
+func (ai *ArchiveIndex) refreshLocked() error {
 	ai.refreshMu.Lock()
 	defer ai.refreshMu.Unlock()
 
@@ -221,15 +359,131 @@ func (ai *ArchiveIndex) refreshOnce() {
 		}
 	}
 
-	snap, err := buildArchiveSnapshot(ai.cfg, ai.readDir, ai.logger, prevSnap)
+	snap, err := ai.buildSnapshot(prevSnap)
 	if err != nil {
 		if ai.logger != nil {
 			ai.logger.Error("archive refresh failed", "error", err)
 		}
-		return
+		return err
 	}
+	ai.storeSnapshot(prevSnap, snap)
+	return nil
+}
+
+// storeSnapshot publishes snap, replacing prevSnap, and runs the refresh hooks. Caller
+// must hold refreshMu.
+func (ai *ArchiveIndex) storeSnapshot(prevSnap *ArchiveSnapshot, snap ArchiveSnapshot) {
 	ai.snap.Store(snap)
 	ai.updateResourceMetrics(snap)
+	if prevSnap != nil {
+		ai.reportSnapshotDiff(*prevSnap, snap)
+	}
+	for _, fn := range ai.refreshHooks {
+		fn(snap)
+	}
+}
+
+// buildSnapshot runs buildArchiveSnapshot, bounded by CT_ARCHIVE_REFRESH_TIMEOUT when set.
+//
+// A scan blocked in the kernel (e.g. on a hung NFS mount) cannot be interrupted, so on
+// timeout it is abandoned: it keeps running in the background and its result is dropped,
+// while the caller keeps the previous snapshot and releases refreshMu. Until the abandoned
+// scan returns, later refreshes fail fast instead of piling up more blocked scans.
+func (ai *ArchiveIndex) buildSnapshot(prevSnap *ArchiveSnapshot) (ArchiveSnapshot, error) {
+	timeout := ai.cfg.ArchiveRefreshTimeout
+	if timeout <= 0 {
+		return buildArchiveSnapshot(ai.cfg, ai.readDir, ai.logger, ai.metrics, prevSnap, ai.now())
+	}
+
+	if ai.abandonedScan != nil {
+		select {
+		case <-ai.abandonedScan:
+			ai.abandonedScan = nil
+		default:
+			ai.metrics.IncArchiveRefreshTimeouts()
+			return ArchiveSnapshot{}, fmt.Errorf("%w: previous scan is still running", errArchiveRefreshTimeout)
+		}
+	}
+
+	type result struct {
+		snap ArchiveSnapshot
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		snap, err := buildArchiveSnapshot(ai.cfg, ai.readDir, ai.logger, ai.metrics, prevSnap, ai.now())
+		done <- result{snap: snap, err: err}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	select {
+	case res := <-done:
+		return res.snap, res.err
+	case <-ctx.Done():
+		finished := make(chan struct{})
+		ai.abandonedScan = finished
+		go func() {
+			<-done
+			close(finished)
+		}()
+		ai.metrics.IncArchiveRefreshTimeouts()
+		return ArchiveSnapshot{}, fmt.Errorf("%w after %s", errArchiveRefreshTimeout, timeout)
+	}
+}
+
+// OnRefresh registers fn to be called with the new snapshot after each successful
+// periodic refresh (not the initial scan). Hooks run synchronously on the refresh path.
+func (ai *ArchiveIndex) OnRefresh(fn func(ArchiveSnapshot)) {
+	if ai == nil {
+		return
+	}
+	ai.refreshMu.Lock()
+	defer ai.refreshMu.Unlock()
+	ai.refreshHooks = append(ai.refreshHooks, fn)
+}
+
+// reportSnapshotDiff logs logs that were added or removed and zip parts that appeared
+// since the previous refresh, and counts added/removed logs.
+func (ai *ArchiveIndex) reportSnapshotDiff(prev, next ArchiveSnapshot) {
+	added, removed := 0, 0
+	for name, l := range next.Logs {
+		old, ok := prev.Logs[name]
+		if !ok {
+			added++
+			if ai.logger != nil {
+				ai.logger.Info("Log added to archive", "log", name, "zip_parts", len(l.ZipParts))
+			}
+			continue
+		}
+		if newParts := zipPartsAdded(old.ZipParts, l.ZipParts); len(newParts) > 0 && ai.logger != nil {
+			ai.logger.Info("Zip parts added to log", "log", name, "zip_parts", newParts)
+		}
+	}
+	for name := range prev.Logs {
+		if _, ok := next.Logs[name]; !ok {
+			removed++
+			if ai.logger != nil {
+				ai.logger.Info("Log removed from archive", "log", name)
+			}
+		}
+	}
+	ai.metrics.AddArchiveLogsChanged(added, removed)
+}
+
+// zipPartsAdded returns the indices in next that are not in prev. Both are sorted ascending.
+func zipPartsAdded(prev, next []int) []int {
+	var out []int
+	i := 0
+	for _, idx := range next {
+		for i < len(prev) && prev[i] < idx {
+			i++
+		}
+		if i == len(prev) || prev[i] != idx {
+			out = append(out, idx)
+		}
+	}
+	return out
 }
 
 func (ai *ArchiveIndex) updateResourceMetrics(snap ArchiveSnapshot) {
@@ -244,51 +498,112 @@ func (ai *ArchiveIndex) updateResourceMetrics(snap ArchiveSnapshot) {
 	ai.metrics.SetArchiveDiscovered(logCount, zipPartCount)
 }
 
-func buildArchiveSnapshot(cfg Config, readDir func(string) ([]os.DirEntry, error), logger *slog.Logger, prevSnap *ArchiveSnapshot) (ArchiveSnapshot, error) {
+// isArchiveFolder reports whether the entry ent of dir is a directory. With
+// CT_ARCHIVE_FOLLOW_SYMLINKS, symlinks are resolved with os.Stat and included when they
+// point at a directory; dangling links and symlink loops (ELOOP) are skipped. Folders
+// are only read CT_ARCHIVE_NESTED_DEPTH levels deep, so a link to an ancestor cannot
+// cause an unbounded walk.
+func isArchiveFolder(cfg Config, dir string, ent os.DirEntry, logger *slog.Logger) bool {
+	if ent.IsDir() {
+		return true
+	}
+	if !cfg.ArchiveFollowSymlinks || ent.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(dir, ent.Name()))
+	if err != nil {
+		if logger != nil {
+			logger.Debug("Skipping unresolvable symlink", "folder", ent.Name(), "error", err)
+		}
+		return false
+	}
+	return fi.IsDir()
+}
+
+func buildArchiveSnapshot(cfg Config, readDir func(string) ([]os.DirEntry, error), logger *slog.Logger, metrics *Metrics, prevSnap *ArchiveSnapshot, now time.Time) (ArchiveSnapshot, error) {
 	if readDir == nil {
 		readDir = os.ReadDir
 	}
 
-	entries, err := readDir(cfg.ArchivePath)
+	entries, err := listArchiveFolders(cfg, readDir, logger, metrics)
 	if err != nil {
-		return ArchiveSnapshot{}, fmt.Errorf("read archive path: %w", err)
+		return ArchiveSnapshot{}, err
 	}
 
 	if logger != nil {
 		logger.Debug("Scanning archive directory", "path", cfg.ArchivePath, "entry_count", len(entries))
 	}
 
-	now := time.Now()
 	logs := make(map[string]ArchiveLog)
+	var collisions map[string][]string
 	discoveredCount := 0
-	for _, ent := range entries {
-		if !ent.IsDir() {
+	for _, entry := range entries {
+		ent := entry.ent
+		folderName := entry.rel
+		if matchesAnyGlob(ent.Name(), cfg.ArchiveExcludeFolders) {
+			if logger != nil {
+				logger.Debug("Skipping directory (excluded by CT_ARCHIVE_EXCLUDE_FOLDERS)", "folder", folderName)
+			}
+			continue
+		}
+		if !isArchiveFolder(cfg, entry.dir, ent, logger) {
 			continue
 		}
 
-		folderName := ent.Name()
-		if cfg.ArchiveFolderPrefix != "" && !strings.HasPrefix(folderName, cfg.ArchiveFolderPrefix) {
+		logName, ok := archiveFolderLogName(ent.Name(), cfg.ArchiveFolderPrefix, cfg.ArchiveFolderSuffix)
+		if !ok {
 			if logger != nil {
-				logger.Debug("Skipping directory (doesn't match pattern)", "folder", folderName, "pattern", cfg.ArchiveFolderPrefix+"*")
+				logger.Debug("Skipping directory (doesn't match pattern)", "folder", folderName, "pattern", cfg.ArchiveFolderPrefix+"*"+cfg.ArchiveFolderSuffix)
 			}
 			continue
 		}
-
-		logName := strings.TrimPrefix(folderName, cfg.ArchiveFolderPrefix)
 		if logName == "" {
 			// Empty <log> is not meaningful; ignore.
 			continue
 		}
+		if cfg.ArchiveNestedLogNames == ArchiveNestedLogNamesPath && len(entry.operators) > 0 {
+			logName = strings.Join(entry.operators, "_") + "_" + logName
+		}
 
+		if !logSelected(logName, cfg.LogAllowlist, cfg.LogDenylist) {
+			if logger != nil {
+				logger.Debug("Skipping log (excluded by CT_LOG_ALLOWLIST/CT_LOG_DENYLIST)", "log", logName, "folder", folderName)
+			}
+			continue
+		}
+
+		if !isValidLogName(logName, cfg.MaxLogNameLength) && logger != nil {
+			logger.Warn("Log name is not routable (must be letters, digits, '_' or '-' and within CT_MAX_LOG_NAME_LENGTH)", "log", logName, "folder", folderName)
+		}
+
+		if folders, ok := collisions[logName]; ok {
+			collisions[logName] = append(folders, folderName)
+			continue
+		}
 		if prev, ok := logs[logName]; ok {
-			return ArchiveSnapshot{}, fmt.Errorf("archive folder collision for log %q: %q and %q", logName, prev.FolderName, folderName)
+			if cfg.ArchiveCollisionPolicy != ArchiveCollisionSkip {
+				return ArchiveSnapshot{}, fmt.Errorf("archive folder collision for log %q: %q and %q", logName, prev.FolderName, folderName)
+			}
+			if collisions == nil {
+				collisions = make(map[string][]string)
+			}
+			collisions[logName] = []string{prev.FolderName, folderName}
+			delete(logs, logName)
+			discoveredCount--
+			continue
 		}
 
 		folderPath := filepath.Join(cfg.ArchivePath, folderName)
 		if logger != nil {
 			logger.Debug("Discovering zip parts", "log", logName, "folder", folderPath)
 		}
-		zipParts, err := discoverZipParts(folderPath, logger)
+		var zipParts []int
+		var zstdZipParts map[int]bool
+		err := retryTransientFS(folderPath, logger, metrics, func() error {
+			var err error
+			zipParts, zstdZipParts, err = discoverZipParts(folderPath, cfg.MaxZipPartsPerLog, logger)
+			return err
+		})
 		if err != nil {
 			return ArchiveSnapshot{}, fmt.Errorf("discover zip parts for %q: %w", folderName, err)
 		}
@@ -305,6 +620,8 @@ func buildArchiveSnapshot(cfg Config, readDir func(string) ([]os.DirEntry, error
 			if prevLog, ok := prevSnap.Logs[logName]; ok {
 				// Log existed before, preserve its discovery timestamp
 				firstDiscovered = prevLog.FirstDiscovered
+			} else if prevLog, ok := prevSnap.Pending[logName]; ok {
+				firstDiscovered = prevLog.FirstDiscovered
 			}
 		}
 		// If this is a new log and has 000.zip, set discovery timestamp
@@ -329,37 +646,219 @@ func buildArchiveSnapshot(cfg Config, readDir func(string) ([]os.DirEntry, error
 			FolderName:     folderName,
 			FolderPath:     folderPath,
 			ZipParts:       zipParts,
+			ZstdZipParts:   zstdZipParts,
 			FirstDiscovered: firstDiscovered,
 		}
 		discoveredCount++
 	}
 
+	for logName, folders := range collisions {
+		if logger != nil {
+			logger.Warn("Skipping log: several archive folders map to its name", "log", logName, "folders", folders)
+		}
+		// Count each collision once, not on every refresh while it persists.
+		if prevSnap == nil || prevSnap.Collisions[logName] == nil {
+			metrics.IncArchiveLogCollisions()
+		}
+	}
+
+	pending := holdNewLogs(cfg.NewLogGracePeriod, logs, prevSnap, now)
+	for logName, l := range pending {
+		discoveredCount--
+		if logger != nil {
+			logger.Debug("Holding back new log (CT_NEW_LOG_GRACE_PERIOD)", "log", logName, "discovered_at", l.FirstDiscovered)
+		}
+	}
+
 	if logger != nil {
 		logger.Debug("Archive snapshot complete", "discovered_logs", discoveredCount)
 	}
 
-	return ArchiveSnapshot{Logs: logs}, nil
+	return ArchiveSnapshot{Logs: logs, Collisions: collisions, Pending: pending}, nil
+}
+
+// archiveFolderEntry is a candidate log folder found by listArchiveFolders.
+type archiveFolderEntry struct {
+	ent       os.DirEntry
+	dir       string   // directory containing ent
+	rel       string   // path of ent relative to CT_ARCHIVE_PATH, e.g. "digicert/ct_yeti2025"
+	operators []string // operator directories above ent, outermost first
+}
+
+// listArchiveFolders returns the entries CT_ARCHIVE_NESTED_DEPTH levels below
+// CT_ARCHIVE_PATH, in directory order. Above that depth every directory not excluded by
+// CT_ARCHIVE_EXCLUDE_FOLDERS is an operator directory and is descended into; log folders
+// are only looked for at the configured depth.
+func listArchiveFolders(cfg Config, readDir func(string) ([]os.DirEntry, error), logger *slog.Logger, metrics *Metrics) ([]archiveFolderEntry, error) {
+	var out []archiveFolderEntry
+	var walk func(dir string, operators []string) error
+	walk = func(dir string, operators []string) error {
+		var entries []os.DirEntry
+		err := retryTransientFS(dir, logger, metrics, func() error {
+			var err error
+			entries, err = readDir(dir)
+			return err
+		})
+		if err != nil {
+			if len(operators) == 0 {
+				return fmt.Errorf("read archive path: %w", err)
+			}
+			return fmt.Errorf("read operator folder %q: %w", filepath.Join(operators...), err)
+		}
+
+		for _, ent := range entries {
+			if len(operators) == cfg.ArchiveNestedDepth {
+				out = append(out, archiveFolderEntry{
+					ent:       ent,
+					dir:       dir,
+					rel:       filepath.Join(append(slices.Clone(operators), ent.Name())...),
+					operators: operators,
+				})
+				continue
+			}
+			if matchesAnyGlob(ent.Name(), cfg.ArchiveExcludeFolders) || !isArchiveFolder(cfg, dir, ent, logger) {
+				continue
+			}
+			if err := walk(filepath.Join(dir, ent.Name()), append(slices.Clone(operators), ent.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(cfg.ArchivePath, nil); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// holdNewLogs moves logs that a refresh discovered less than grace ago out of logs and
+// returns them. Logs without 000.zip have no discovery time yet and are held too. Logs
+// already served by prevSnap stay, and the initial scan (nil prevSnap) holds nothing:
+// those logs were there before the process started.
+func holdNewLogs(grace time.Duration, logs map[string]ArchiveLog, prevSnap *ArchiveSnapshot, now time.Time) map[string]ArchiveLog {
+	if grace <= 0 || prevSnap == nil {
+		return nil
+	}
+	var pending map[string]ArchiveLog
+	for logName, l := range logs {
+		if _, served := prevSnap.Logs[logName]; served {
+			continue
+		}
+		if !l.FirstDiscovered.IsZero() && now.Sub(l.FirstDiscovered) >= grace {
+			continue
+		}
+		if pending == nil {
+			pending = make(map[string]ArchiveLog)
+		}
+		pending[logName] = l
+		delete(logs, logName)
+	}
+	return pending
 }
 
-func discoverZipParts(folderPath string, logger *slog.Logger) ([]int, error) {
-	ents, err := os.ReadDir(folderPath)
+// archiveReadMaxAttempts and archiveReadRetryBackoff bound retries of directory reads
+// that fail with a transient error (see isTransientFSError). The backoff doubles per retry.
+const archiveReadMaxAttempts = 3
+
+var archiveReadRetryBackoff = 50 * time.Millisecond
+
+// retryTransientFS runs op, retrying with backoff while it fails with a transient
+// filesystem error, so one flaky NFS read does not abort a whole archive refresh.
+// Permanent errors (e.g. ENOENT, EACCES) are returned immediately.
+func retryTransientFS(path string, logger *slog.Logger, metrics *Metrics, op func() error) error {
+	backoff := archiveReadRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= archiveReadMaxAttempts || !isTransientFSError(err) {
+			return err
+		}
+		metrics.IncArchiveRefreshRetries()
+		if logger != nil {
+			logger.Warn("Transient error reading archive directory, retrying", "path", path, "attempt", attempt, "backoff", backoff, "error", err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// isTransientFSError reports whether err is worth retrying: interrupted or would-block
+// syscalls, timeouts, and stale NFS file handles.
+func isTransientFSError(err error) bool {
+	return errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.ETIMEDOUT) ||
+		errors.Is(err, syscall.ESTALE)
+}
+
+// zipPartsReadDirBatch bounds how many directory entries discoverZipParts holds at once,
+// so a log folder cluttered with unrelated files cannot balloon memory during discovery.
+const zipPartsReadDirBatch = 1024
+
+// discoverZipParts returns the sorted NNN indices of the NNN.zip and NNN.zip.zst files in
+// folderPath, and the set of indices present only as NNN.zip.zst (nil if none).
+// If more than maxParts are found (maxParts > 0), the lowest maxParts indices are kept
+// and a warning is logged.
+func discoverZipParts(folderPath string, maxParts int, logger *slog.Logger) ([]int, map[int]bool, error) {
+	dir, err := os.Open(folderPath) //nolint:gosec // G304: folderPath is joined from CT_ARCHIVE_PATH and a discovered folder name
 	if err != nil {
-		return nil, fmt.Errorf("read zip parts directory: %w", err)
+		return nil, nil, fmt.Errorf("read zip parts directory: %w", err)
+	}
+	defer func() { _ = dir.Close() }()
+
+	found := make(map[int]bool) // index -> stored only as NNN.zip.zst
+	for {
+		ents, err := dir.ReadDir(zipPartsReadDirBatch)
+		addZipParts(found, ents, folderPath, logger)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read zip parts directory: %w", err)
+		}
 	}
 
-	var out []int
+	out := make([]int, 0, len(found))
+	for n := range found {
+		out = append(out, n)
+	}
+	sort.Ints(out)
+	if maxParts > 0 && len(out) > maxParts {
+		if logger != nil {
+			logger.Warn("Zip part count exceeds CT_MAX_ZIP_PARTS_PER_LOG; keeping lowest indices",
+				"folder", folderPath, "found", len(out), "max", maxParts)
+		}
+		out = out[:maxParts:maxParts]
+	}
+
+	var zstdParts map[int]bool
+	for _, n := range out {
+		if found[n] {
+			if zstdParts == nil {
+				zstdParts = make(map[int]bool)
+			}
+			zstdParts[n] = true
+		}
+	}
+	return out, zstdParts, nil
+}
+
+// addZipParts records the index of every NNN.zip and NNN.zip.zst file in ents. A plain
+// NNN.zip takes precedence over NNN.zip.zst for the same index.
+func addZipParts(found map[int]bool, ents []os.DirEntry, folderPath string, logger *slog.Logger) {
 	for _, ent := range ents {
 		if ent.IsDir() {
 			continue
 		}
 		name := ent.Name()
-		if len(name) != len("000.zip") || !strings.HasSuffix(name, ".zip") {
+		var zstd bool
+		switch {
+		case len(name) == len("000.zip") && name[3:] == ".zip":
+		case len(name) == len("000"+zstdZipPartSuffix) && name[3:] == zstdZipPartSuffix:
+			zstd = true
+		default:
 			continue
 		}
 		prefix := name[:3]
-		if name[3:] != ".zip" {
-			continue
-		}
 		if prefix[0] < '0' || prefix[0] > '9' || prefix[1] < '0' || prefix[1] > '9' || prefix[2] < '0' || prefix[2] > '9' {
 			continue
 		}
@@ -367,13 +866,12 @@ func discoverZipParts(folderPath string, logger *slog.Logger) ([]int, error) {
 		if err != nil {
 			continue
 		}
-		out = append(out, n)
+		if prev, ok := found[n]; !ok || prev {
+			found[n] = zstd
+		}
 		if logger != nil {
 			logger.Debug("Found zip part", "zip_file", name, "index", n, "folder", folderPath)
 		}
 	}
-
-	sort.Ints(out)
-	return out, nil
 }
 
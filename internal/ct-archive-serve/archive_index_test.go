@@ -1,10 +1,22 @@
 package ctarchiveserve
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestBuildArchiveSnapshot_DiscoversLogsAndZipParts(t *testing.T) {
@@ -28,7 +40,7 @@ func TestBuildArchiveSnapshot_DiscoversLogsAndZipParts(t *testing.T) {
 		ArchiveFolderPrefix: "ct_",
 	}
 
-	snap, err := buildArchiveSnapshot(cfg, os.ReadDir, nil, nil)
+	snap, err := buildArchiveSnapshot(cfg, os.ReadDir, nil, nil, nil, time.Now())
 	if err != nil {
 		t.Fatalf("buildArchiveSnapshot() error = %v", err)
 	}
@@ -60,6 +72,202 @@ func TestBuildArchiveSnapshot_DiscoversLogsAndZipParts(t *testing.T) {
 	}
 }
 
+func TestBuildArchiveSnapshot_FolderSuffix(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"ct_argon2025_v3", "ct_xenon2025", "argon2025_v3", "ct__v3"} {
+		mustMkdir(t, filepath.Join(root, dir))
+		mustWriteFile(t, filepath.Join(root, dir, "000.zip"), []byte("x"))
+	}
+
+	cfg := Config{
+		ArchivePath:         root,
+		ArchiveFolderPrefix: "ct_",
+		ArchiveFolderSuffix: "_v3",
+	}
+	snap, err := buildArchiveSnapshot(cfg, os.ReadDir, nil, nil, nil, time.Now())
+	if err != nil {
+		t.Fatalf("buildArchiveSnapshot() error = %v", err)
+	}
+
+	if got, want := len(snap.Logs), 1; got != want {
+		t.Fatalf("len(Logs) = %d, want %d (%v)", got, want, snap.Logs)
+	}
+	l, ok := snap.Logs["argon2025"]
+	if !ok {
+		t.Fatalf("expected argon2025 to be discovered from ct_argon2025_v3")
+	}
+	if got := filepath.Base(l.FolderPath); got != "ct_argon2025_v3" {
+		t.Fatalf("FolderPath base = %q, want %q", got, "ct_argon2025_v3")
+	}
+}
+
+func TestBuildArchiveSnapshot_FollowSymlinks(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_plain"))
+	mustWriteFile(t, filepath.Join(root, "ct_plain", "000.zip"), []byte("x"))
+
+	// The linked folder lives outside the archive path.
+	target := filepath.Join(t.TempDir(), "volume2")
+	mustMkdir(t, target)
+	mustWriteFile(t, filepath.Join(target, "000.zip"), []byte("x"))
+	for link, dest := range map[string]string{
+		"ct_linked":   target,
+		"ct_dangling": filepath.Join(root, "missing"),
+		"ct_loop":     filepath.Join(root, "ct_loop"),
+	} {
+		if err := os.Symlink(dest, filepath.Join(root, link)); err != nil {
+			t.Skipf("Symlink() error = %v", err)
+		}
+	}
+
+	for _, follow := range []bool{false, true} {
+		cfg := Config{
+			ArchivePath:           root,
+			ArchiveFolderPrefix:   "ct_",
+			ArchiveFollowSymlinks: follow,
+		}
+		snap, err := buildArchiveSnapshot(cfg, os.ReadDir, nil, nil, nil, time.Now())
+		if err != nil {
+			t.Fatalf("follow=%v: buildArchiveSnapshot() error = %v", follow, err)
+		}
+		if _, ok := snap.Logs["plain"]; !ok {
+			t.Errorf("follow=%v: plain folder not discovered", follow)
+		}
+		linked, ok := snap.Logs["linked"]
+		if ok != follow {
+			t.Errorf("follow=%v: linked discovered = %v, want %v", follow, ok, follow)
+		}
+		if ok && !slices.Equal(linked.ZipParts, []int{0}) {
+			t.Errorf("linked ZipParts = %v, want [0]", linked.ZipParts)
+		}
+		if got, want := len(snap.Logs), map[bool]int{false: 1, true: 2}[follow]; got != want {
+			t.Errorf("follow=%v: len(Logs) = %d, want %d (%v)", follow, got, want, snap.Logs)
+		}
+	}
+}
+
+func TestBuildArchiveSnapshot_LogAllowDenyLists(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range []string{"argon2024", "argon2025", "xenon2024", "nessie2024"} {
+		mustMkdir(t, filepath.Join(root, "ct_"+name))
+		mustWriteFile(t, filepath.Join(root, "ct_"+name, "000.zip"), []byte("x"))
+	}
+
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		want  []string
+	}{
+		{name: "none", want: []string{"argon2024", "argon2025", "nessie2024", "xenon2024"}},
+		{name: "allow only", allow: []string{"argon*", "nessie2024"}, want: []string{"argon2024", "argon2025", "nessie2024"}},
+		{name: "deny only", deny: []string{"*2024"}, want: []string{"argon2025"}},
+		// The allowlist takes precedence: argon2024 is served although it is also denied.
+		{name: "both", allow: []string{"argon*"}, deny: []string{"argon2024"}, want: []string{"argon2024", "argon2025"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := Config{
+				ArchivePath:         root,
+				ArchiveFolderPrefix: "ct_",
+				LogAllowlist:        tc.allow,
+				LogDenylist:         tc.deny,
+			}
+			snap, err := buildArchiveSnapshot(cfg, nil, nil, nil, nil, time.Now())
+			if err != nil {
+				t.Fatalf("buildArchiveSnapshot() error = %v", err)
+			}
+			got := make([]string, 0, len(snap.Logs))
+			for name := range snap.Logs {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("logs = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildArchiveSnapshot_ExcludeFolders(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, folder := range []string{"ct_good", "ct_partial.tmp", "ct_lost+found"} {
+		mustMkdir(t, filepath.Join(root, folder))
+		mustWriteFile(t, filepath.Join(root, folder, "000.zip"), []byte("x"))
+	}
+
+	tests := []struct {
+		name    string
+		exclude []string
+		want    []string
+	}{
+		{name: "none", want: []string{"good", "lost+found", "partial.tmp"}},
+		{name: "exact and glob", exclude: []string{"ct_lost+found", "*.tmp"}, want: []string{"good"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := Config{
+				ArchivePath:           root,
+				ArchiveFolderPrefix:   "ct_",
+				ArchiveExcludeFolders: tc.exclude,
+			}
+			snap, err := buildArchiveSnapshot(cfg, nil, nil, nil, nil, time.Now())
+			if err != nil {
+				t.Fatalf("buildArchiveSnapshot() error = %v", err)
+			}
+			got := make([]string, 0, len(snap.Logs))
+			for name := range snap.Logs {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("logs = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildArchiveSnapshot_MaxZipPartsPerLog(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_log1"))
+	for i := 0; i < 5; i++ {
+		mustWriteFile(t, filepath.Join(root, "ct_log1", fmt.Sprintf("%03d.zip", i)), []byte("x"))
+	}
+
+	cfg := Config{
+		ArchivePath:         root,
+		ArchiveFolderPrefix: "ct_",
+		MaxZipPartsPerLog:   3,
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	snap, err := buildArchiveSnapshot(cfg, os.ReadDir, logger, nil, nil, time.Now())
+	if err != nil {
+		t.Fatalf("buildArchiveSnapshot() error = %v", err)
+	}
+
+	if got, want := snap.Logs["log1"].ZipParts, []int{0, 1, 2}; !intSlicesEqual(got, want) {
+		t.Fatalf("log1 ZipParts = %v, want %v", got, want)
+	}
+	if got := logs.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, "CT_MAX_ZIP_PARTS_PER_LOG") {
+		t.Fatalf("expected truncation warning, got logs:\n%s", got)
+	}
+}
+
 func TestBuildArchiveSnapshot_LogCollisionFails(t *testing.T) {
 	t.Parallel()
 
@@ -81,12 +289,475 @@ func TestBuildArchiveSnapshot_LogCollisionFails(t *testing.T) {
 		return append(ents, ents...), nil
 	}
 
-	_, err := buildArchiveSnapshot(cfg, dupReadDir, nil, nil)
+	_, err := buildArchiveSnapshot(cfg, dupReadDir, nil, nil, nil, time.Now())
 	if err == nil {
 		t.Fatalf("buildArchiveSnapshot() error = nil, want non-nil")
 	}
 }
 
+func TestArchiveIndex_LogCollisionPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []string{ArchiveCollisionFail, ArchiveCollisionSkip} {
+		t.Run(policy, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			for _, folder := range []string{"ct_a", "ct_b"} {
+				mustMkdir(t, filepath.Join(root, folder))
+				mustWriteFile(t, filepath.Join(root, folder, "000.zip"), []byte("x"))
+			}
+
+			cfg := Config{
+				ArchivePath:            root,
+				ArchiveFolderPrefix:    "ct_",
+				ArchiveCollisionPolicy: policy,
+			}
+			reg := prometheus.NewRegistry()
+			metrics := NewMetrics(reg)
+			ai, err := NewArchiveIndex(cfg, nil, metrics)
+			if err != nil {
+				t.Fatalf("NewArchiveIndex() error = %v", err)
+			}
+
+			// Report ct_a twice to force a collision for log "a".
+			ai.readDir = func(path string) ([]os.DirEntry, error) {
+				ents, err := os.ReadDir(path)
+				if err != nil {
+					return nil, fmt.Errorf("read directory: %w", err)
+				}
+				for _, ent := range ents {
+					if ent.Name() == "ct_a" {
+						ents = append(ents, ent)
+						break
+					}
+				}
+				return ents, nil
+			}
+
+			for i := 0; i < 2; i++ {
+				err := ai.refreshOnce()
+				if policy == ArchiveCollisionFail {
+					if err == nil {
+						t.Fatalf("refreshOnce() error = nil, want collision error")
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("refreshOnce() error = %v", err)
+				}
+			}
+
+			_, hasA := ai.LookupLog("a")
+			_, hasB := ai.LookupLog("b")
+			folders, collided := ai.LookupCollision("a")
+			switch policy {
+			case ArchiveCollisionFail:
+				// The failed refreshes keep the previous snapshot.
+				if !hasA || !hasB || collided {
+					t.Fatalf("a=%v b=%v collided=%v, want previous snapshot with both logs", hasA, hasB, collided)
+				}
+			case ArchiveCollisionSkip:
+				if hasA || !hasB || !collided {
+					t.Fatalf("a=%v b=%v collided=%v, want only b indexed and a collided", hasA, hasB, collided)
+				}
+				if want := []string{"ct_a", "ct_a"}; !slices.Equal(folders, want) {
+					t.Errorf("collision folders = %v, want %v", folders, want)
+				}
+			}
+
+			mfs, err := reg.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+			want := 0.0
+			if policy == ArchiveCollisionSkip {
+				want = 1 // counted once, not on every refresh
+			}
+			if got := counterValue(t, mfs, "ct_archive_serve_log_collisions_total", ""); got != want {
+				t.Errorf("log_collisions_total = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestBuildArchiveSnapshot_RetriesTransientReadDirErrors(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_log1"))
+	mustWriteFile(t, filepath.Join(root, "ct_log1", "000.zip"), []byte("x"))
+
+	cfg := Config{
+		ArchivePath:         root,
+		ArchiveFolderPrefix: "ct_",
+	}
+
+	var calls atomic.Int64
+	flakyReadDir := func(path string) ([]os.DirEntry, error) {
+		if calls.Add(1) == 1 {
+			return nil, &os.PathError{Op: "readdirent", Path: path, Err: syscall.ESTALE}
+		}
+		return os.ReadDir(path)
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	snap, err := buildArchiveSnapshot(cfg, flakyReadDir, nil, metrics, nil, time.Now())
+	if err != nil {
+		t.Fatalf("buildArchiveSnapshot() error = %v", err)
+	}
+	if _, ok := snap.Logs["log1"]; !ok {
+		t.Fatalf("expected log1 to be discovered after retry")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("readDir calls = %d, want 2", got)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_archive_refresh_retries_total", ""); got != 1 {
+		t.Fatalf("archive_refresh_retries_total = %v, want 1", got)
+	}
+}
+
+func TestBuildArchiveSnapshot_PermanentReadDirErrorNotRetried(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int64
+	missingReadDir := func(path string) ([]os.DirEntry, error) {
+		calls.Add(1)
+		return nil, &os.PathError{Op: "open", Path: path, Err: syscall.ENOENT}
+	}
+
+	_, err := buildArchiveSnapshot(Config{ArchivePath: "/nonexistent"}, missingReadDir, nil, nil, nil, time.Now())
+	if err == nil {
+		t.Fatalf("buildArchiveSnapshot() error = nil, want non-nil")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("readDir calls = %d, want 1", got)
+	}
+}
+
+func TestArchiveIndex_RefreshReportsSnapshotDiff(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_log1"))
+	mustWriteFile(t, filepath.Join(root, "ct_log1", "000.zip"), []byte("x"))
+	mustMkdir(t, filepath.Join(root, "ct_log2"))
+	mustWriteFile(t, filepath.Join(root, "ct_log2", "000.zip"), []byte("x"))
+
+	cfg := Config{
+		ArchivePath:         root,
+		ArchiveFolderPrefix: "ct_",
+	}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	reg := prometheus.NewRegistry()
+	ai, err := NewArchiveIndex(cfg, logger, NewMetrics(reg))
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	// Add log3 and a part to log1, remove log2.
+	mustMkdir(t, filepath.Join(root, "ct_log3"))
+	mustWriteFile(t, filepath.Join(root, "ct_log3", "000.zip"), []byte("x"))
+	mustWriteFile(t, filepath.Join(root, "ct_log1", "001.zip"), []byte("x"))
+	if err := os.RemoveAll(filepath.Join(root, "ct_log2")); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	if err := ai.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_logs_added_total", ""); got != 1 {
+		t.Errorf("logs_added_total = %v, want 1", got)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_logs_removed_total", ""); got != 1 {
+		t.Errorf("logs_removed_total = %v, want 1", got)
+	}
+	for _, want := range []string{
+		`msg="Log added to archive" log=log3`,
+		`msg="Log removed from archive" log=log2`,
+		`msg="Zip parts added to log" log=log1 zip_parts=[1]`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log output missing %q:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestArchiveIndex_NewLogGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_old"))
+	mustWriteFile(t, filepath.Join(root, "ct_old", "000.zip"), []byte("x"))
+
+	cfg := Config{
+		ArchivePath:         root,
+		ArchiveFolderPrefix: "ct_",
+		NewLogGracePeriod:   10 * time.Minute,
+	}
+	ai, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	now := time.Now()
+	ai.now = func() time.Time { return now }
+
+	// Logs found by the initial scan are served at once.
+	if _, ok := ai.LookupLog("old"); !ok {
+		t.Fatalf("LookupLog(old) = false, want true")
+	}
+
+	// A folder without 000.zip yet, then with it: held back either way.
+	mustMkdir(t, filepath.Join(root, "ct_new"))
+	if err := ai.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+	mustWriteFile(t, filepath.Join(root, "ct_new", "000.zip"), []byte("x"))
+	now = now.Add(time.Minute)
+	if err := ai.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+	if _, ok := ai.LookupLog("new"); ok {
+		t.Fatalf("LookupLog(new) = true within the grace period, want false")
+	}
+	if _, ok := ai.GetAllLogs().Logs["new"]; ok {
+		t.Fatalf("GetAllLogs() lists new within the grace period")
+	}
+	discovered := now
+
+	now = discovered.Add(10*time.Minute - time.Second)
+	if err := ai.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+	if _, ok := ai.LookupLog("new"); ok {
+		t.Fatalf("LookupLog(new) = true just before the grace period ends, want false")
+	}
+
+	now = discovered.Add(10 * time.Minute)
+	if err := ai.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+	l, ok := ai.LookupLog("new")
+	if !ok {
+		t.Fatalf("LookupLog(new) = false after the grace period, want true")
+	}
+	if !l.FirstDiscovered.Equal(discovered) {
+		t.Errorf("FirstDiscovered = %v, want %v", l.FirstDiscovered, discovered)
+	}
+	if _, ok := ai.LookupLog("old"); !ok {
+		t.Errorf("LookupLog(old) = false, want true")
+	}
+}
+
+func TestBuildArchiveSnapshot_NestedOperators(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, part := range []string{
+		"digicert/ct_yeti/000.zip",
+		"digicert/ct_yeti/001.zip",
+		"digicert/ct_shared/000.zip",
+		"sectigo/ct_mammoth/000.zip",
+		"sectigo/ct_shared/000.zip",
+	} {
+		mustMkdir(t, filepath.Join(root, filepath.Dir(part)))
+		mustWriteFile(t, filepath.Join(root, part), []byte("x"))
+	}
+	mustWriteFile(t, filepath.Join(root, "README"), []byte("not an operator"))
+	mustWriteFile(t, filepath.Join(root, "sectigo", "notes.txt"), []byte("not a log"))
+
+	logNames := func(snap ArchiveSnapshot) []string {
+		names := make([]string, 0, len(snap.Logs))
+		for name := range snap.Logs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	base := Config{
+		ArchivePath:            root,
+		ArchiveFolderPrefix:    "ct_",
+		ArchiveNestedDepth:     1,
+		ArchiveNestedLogNames:  ArchiveNestedLogNamesFolder,
+		ArchiveCollisionPolicy: ArchiveCollisionSkip,
+	}
+
+	// Folder names: "shared" exists under both operators and collides.
+	snap, err := buildArchiveSnapshot(base, nil, nil, nil, nil, time.Now())
+	if err != nil {
+		t.Fatalf("buildArchiveSnapshot() error = %v", err)
+	}
+	if got, want := logNames(snap), []string{"mammoth", "yeti"}; !slices.Equal(got, want) {
+		t.Fatalf("logs = %v, want %v", got, want)
+	}
+	yeti := snap.Logs["yeti"]
+	if yeti.FolderName != filepath.Join("digicert", "ct_yeti") || yeti.FolderPath != filepath.Join(root, "digicert", "ct_yeti") ||
+		!slices.Equal(yeti.ZipParts, []int{0, 1}) {
+		t.Errorf("yeti = %+v, want folder digicert/ct_yeti with parts [0 1]", yeti)
+	}
+	if got, want := snap.Collisions["shared"], []string{filepath.Join("digicert", "ct_shared"), filepath.Join("sectigo", "ct_shared")}; !slices.Equal(got, want) {
+		t.Errorf("collisions[shared] = %v, want %v", got, want)
+	}
+
+	fail := base
+	fail.ArchiveCollisionPolicy = ArchiveCollisionFail
+	if _, err := buildArchiveSnapshot(fail, nil, nil, nil, nil, time.Now()); err == nil || !strings.Contains(err.Error(), "collision") {
+		t.Errorf("buildArchiveSnapshot(fail policy) error = %v, want a collision", err)
+	}
+
+	// Path names keep the operators apart.
+	path := base
+	path.ArchiveNestedLogNames = ArchiveNestedLogNamesPath
+	snap, err = buildArchiveSnapshot(path, nil, nil, nil, nil, time.Now())
+	if err != nil {
+		t.Fatalf("buildArchiveSnapshot(path names) error = %v", err)
+	}
+	if got, want := logNames(snap), []string{"digicert_shared", "digicert_yeti", "sectigo_mammoth", "sectigo_shared"}; !slices.Equal(got, want) {
+		t.Fatalf("path names: logs = %v, want %v", got, want)
+	}
+	if got := snap.Logs["sectigo_shared"].FolderPath; got != filepath.Join(root, "sectigo", "ct_shared") {
+		t.Errorf("sectigo_shared FolderPath = %q", got)
+	}
+
+	// Flat (the default) sees only the operator directories, which are not log folders.
+	flat := base
+	flat.ArchiveNestedDepth = 0
+	snap, err = buildArchiveSnapshot(flat, nil, nil, nil, nil, time.Now())
+	if err != nil {
+		t.Fatalf("buildArchiveSnapshot(flat) error = %v", err)
+	}
+	if len(snap.Logs) != 0 {
+		t.Errorf("flat: logs = %v, want none", logNames(snap))
+	}
+}
+
+func TestArchiveIndex_SnapshotExportAndSeed(t *testing.T) {
+	t.Parallel()
+
+	// The primary exports its index through the periodic writer.
+	primary := t.TempDir()
+	for _, part := range []string{"ct_a/000.zip", "ct_a/001.zip.zst", "ct_b/000.zip"} {
+		mustMkdir(t, filepath.Join(primary, filepath.Dir(part)))
+		mustWriteFile(t, filepath.Join(primary, part), []byte("x"))
+	}
+	exportPath := filepath.Join(t.TempDir(), "archive-snapshot.json")
+	primaryCfg := Config{
+		ArchivePath:                   primary,
+		ArchiveFolderPrefix:           "ct_",
+		ArchiveRefreshInterval:        time.Hour,
+		ArchiveSnapshotExportPath:     exportPath,
+		ArchiveSnapshotExportInterval: time.Hour,
+	}
+	primaryIndex, err := NewArchiveIndex(primaryCfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex(primary) error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	primaryIndex.Start(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(exportPath); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not written", exportPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	wantA, _ := primaryIndex.LookupLog("a")
+
+	// The standby's copy of the archive has moved on: a grew, b is gone and c is new.
+	standby := t.TempDir()
+	for _, part := range []string{"ct_a/000.zip", "ct_a/001.zip.zst", "ct_a/002.zip", "ct_c/000.zip"} {
+		mustMkdir(t, filepath.Join(standby, filepath.Dir(part)))
+		mustWriteFile(t, filepath.Join(standby, part), []byte("x"))
+	}
+	standbyCfg := Config{
+		ArchivePath:             standby,
+		ArchiveFolderPrefix:     "ct_",
+		ArchiveSnapshotSeedPath: exportPath,
+		NewLogGracePeriod:       time.Hour,
+	}
+	ai, err := NewArchiveIndex(standbyCfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex(standby) error = %v", err)
+	}
+
+	// Seeded: the primary's logs, resolved against the standby's archive path.
+	a, ok := ai.LookupLog("a")
+	if !ok {
+		t.Fatalf("seeded LookupLog(a) = false, want true")
+	}
+	if !slices.Equal(a.ZipParts, []int{0, 1}) || !a.ZstdZipParts[1] || a.FolderPath != filepath.Join(standby, "ct_a") ||
+		!a.FirstDiscovered.Equal(wantA.FirstDiscovered) {
+		t.Errorf("seeded a = %+v, want parts [0 1] (1 zstd) under %s, first discovered %v", a, standby, wantA.FirstDiscovered)
+	}
+	if got := a.ZipPartPath(1); got != filepath.Join(standby, "ct_a", "001.zip.zst") {
+		t.Errorf("seeded ZipPartPath(1) = %q", got)
+	}
+	if _, ok := ai.LookupLog("b"); !ok {
+		t.Errorf("seeded LookupLog(b) = false, want true")
+	}
+	if _, ok := ai.LookupLog("c"); ok {
+		t.Errorf("seeded LookupLog(c) = true before the scan, want false")
+	}
+
+	// The background scan replaces the seed, keeping FirstDiscovered and holding back
+	// nothing, like the startup scan.
+	if err := ai.rescanSeed(); err != nil {
+		t.Fatalf("rescanSeed() error = %v", err)
+	}
+	a, _ = ai.LookupLog("a")
+	if !slices.Equal(a.ZipParts, []int{0, 1, 2}) || !a.FirstDiscovered.Equal(wantA.FirstDiscovered) {
+		t.Errorf("scanned a = %+v, want parts [0 1 2], first discovered %v", a, wantA.FirstDiscovered)
+	}
+	if _, ok := ai.LookupLog("b"); ok {
+		t.Errorf("scanned LookupLog(b) = true, want false")
+	}
+	if _, ok := ai.LookupLog("c"); !ok {
+		t.Errorf("scanned LookupLog(c) = false, want true")
+	}
+
+	// A missing seed falls back to the startup scan.
+	standbyCfg.ArchiveSnapshotSeedPath = filepath.Join(t.TempDir(), "missing.json")
+	ai, err = NewArchiveIndex(standbyCfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex(missing seed) error = %v", err)
+	}
+	if _, ok := ai.LookupLog("c"); !ok || ai.seeded {
+		t.Errorf("missing seed: LookupLog(c) = %v, seeded = %v, want a scanned index", ok, ai.seeded)
+	}
+}
+
+func TestReadArchiveSnapshotSeed_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"garbage":   `{"version":`,
+		"version":   `{"version":2,"logs":[]}`,
+		"traversal": `{"version":1,"logs":[{"log":"a","folder":"../etc","zip_parts":[0]}]}`,
+		"duplicate": `{"version":1,"logs":[{"log":"a","folder":"ct_a"},{"log":"a","folder":"ct_b"}]}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		mustWriteFile(t, path, []byte(body))
+		if _, err := readArchiveSnapshotSeed(Config{ArchivePath: dir}, path); err == nil {
+			t.Errorf("readArchiveSnapshotSeed(%s) error = nil, want error", name)
+		}
+	}
+}
+
 func mustMkdir(t *testing.T, path string) {
 	t.Helper()
 	if err := os.MkdirAll(path, 0o700); err != nil {
@@ -269,3 +940,162 @@ func TestArchiveIndex_SelectZipPart_HashTiles_Level3Plus_No000_UseLowest(t *test
 		t.Errorf("SelectZipPart() zipIndex = %d, want 1 (lowest available)", zipIndex)
 	}
 }
+
+func TestArchiveIndex_Immutable_NoRescanAfterFirstBuild(t *testing.T) {
+	t.Parallel()
+
+	for _, immutable := range []bool{false, true} {
+		t.Run(fmt.Sprintf("immutable=%v", immutable), func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			mustMkdir(t, filepath.Join(root, "ct_log1"))
+			mustWriteFile(t, filepath.Join(root, "ct_log1", "000.zip"), []byte("x"))
+
+			cfg := Config{
+				ArchivePath:            root,
+				ArchiveFolderPattern:   "ct_*",
+				ArchiveFolderPrefix:    "ct_",
+				ArchiveRefreshInterval: 5 * time.Millisecond,
+				ArchiveImmutable:       immutable,
+			}
+			ai, err := NewArchiveIndex(cfg, nil, nil)
+			if err != nil {
+				t.Fatalf("NewArchiveIndex() error = %v", err)
+			}
+
+			var scans atomic.Int64
+			ai.readDir = func(path string) ([]os.DirEntry, error) {
+				scans.Add(1)
+				return os.ReadDir(path)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ai.Start(ctx)
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+
+			got := scans.Load()
+			if immutable && got != 0 {
+				t.Fatalf("archive rescans after first build = %d, want 0 in immutable mode", got)
+			}
+			if !immutable && got == 0 {
+				t.Fatalf("archive rescans after first build = 0, want > 0 in mutable mode")
+			}
+			if _, ok := ai.LookupLog("log1"); !ok {
+				t.Fatalf("LookupLog(log1) = false, want true")
+			}
+		})
+	}
+}
+
+func TestArchiveIndex_ConcurrentRefreshesCoalesce(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_log1"))
+	mustWriteFile(t, filepath.Join(root, "ct_log1", "000.zip"), []byte("x"))
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	ai, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	// Block the scan until all callers have piled up behind it.
+	release := make(chan struct{})
+	var scans atomic.Int64
+	ai.readDir = func(path string) ([]os.DirEntry, error) {
+		scans.Add(1)
+		<-release
+		return os.ReadDir(path)
+	}
+
+	const callers = 20
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() { errs <- ai.refreshOnce() }()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("refreshOnce() error = %v", err)
+		}
+	}
+	if got, want := scans.Load(), int64(1); got != want {
+		t.Fatalf("archive scans = %d, want %d", got, want)
+	}
+}
+
+func TestArchiveIndex_RefreshTimeoutKeepsPreviousSnapshot(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_log1"))
+	mustWriteFile(t, filepath.Join(root, "ct_log1", "000.zip"), []byte("x"))
+
+	cfg := Config{
+		ArchivePath:           root,
+		ArchiveFolderPrefix:   "ct_",
+		ArchiveRefreshTimeout: 50 * time.Millisecond,
+	}
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	ai, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	// Simulate a wedged mount: the scan blocks until released.
+	release := make(chan struct{})
+	ai.readDir = func(path string) ([]os.DirEntry, error) {
+		<-release
+		return os.ReadDir(path)
+	}
+
+	if err := ai.refreshOnce(); !errors.Is(err, errArchiveRefreshTimeout) {
+		t.Fatalf("refreshOnce() error = %v, want %v", err, errArchiveRefreshTimeout)
+	}
+	if _, ok := ai.LookupLog("log1"); !ok {
+		t.Fatalf("previous snapshot lost after refresh timeout")
+	}
+
+	// The abandoned scan is still blocked: the next refresh fails fast.
+	start := time.Now()
+	if err := ai.refreshOnce(); !errors.Is(err, errArchiveRefreshTimeout) {
+		t.Fatalf("refreshOnce() while scan stuck error = %v, want %v", err, errArchiveRefreshTimeout)
+	}
+	if d := time.Since(start); d >= cfg.ArchiveRefreshTimeout {
+		t.Errorf("refresh while scan stuck took %v, want immediate failure", d)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_archive_refresh_timeouts_total", ""); got != 2 {
+		t.Fatalf("archive_refresh_timeouts_total = %v, want 2", got)
+	}
+
+	// Once the mount recovers, refreshes succeed again.
+	mustMkdir(t, filepath.Join(root, "ct_log2"))
+	mustWriteFile(t, filepath.Join(root, "ct_log2", "000.zip"), []byte("x"))
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for ai.refreshOnce() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("refresh did not recover after the scan was released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := ai.LookupLog("log2"); !ok {
+		t.Fatalf("log2 not discovered after recovery")
+	}
+}
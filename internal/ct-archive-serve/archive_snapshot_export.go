@@ -0,0 +1,190 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// archiveSnapshotExportVersion is the format version of ArchiveSnapshotExport. Seed files
+// with another version are rejected.
+const archiveSnapshotExportVersion = 1
+
+// ArchiveSnapshotExport is the serialized form of the served logs of an ArchiveSnapshot,
+// written for cold-standby nodes (CT_ARCHIVE_SNAPSHOT_EXPORT_PATH) and read back to seed
+// the index at startup (CT_ARCHIVE_SNAPSHOT_SEED_PATH).
+type ArchiveSnapshotExport struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Logs       []ArchiveLogExport `json:"logs"`
+}
+
+// ArchiveLogExport is one log of an ArchiveSnapshotExport. Folder is relative to
+// CT_ARCHIVE_PATH (including any operator directories), so a standby may mount the
+// archive elsewhere.
+type ArchiveLogExport struct {
+	Log             string    `json:"log"`
+	Folder          string    `json:"folder"`
+	ZipParts        []int     `json:"zip_parts"`
+	ZstdZipParts    []int     `json:"zstd_zip_parts,omitempty"`
+	FirstDiscovered time.Time `json:"first_discovered"`
+}
+
+// Export returns the logs of the current snapshot, sorted by name. Pending logs
+// (CT_NEW_LOG_GRACE_PERIOD) and collisions are not included.
+func (ai *ArchiveIndex) Export() ArchiveSnapshotExport {
+	snap := ai.GetAllLogs()
+	exp := ArchiveSnapshotExport{
+		Version:    archiveSnapshotExportVersion,
+		ExportedAt: ai.now().UTC(),
+		Logs:       make([]ArchiveLogExport, 0, len(snap.Logs)),
+	}
+	for _, l := range snap.Logs {
+		var zstd []int
+		for idx := range l.ZstdZipParts {
+			zstd = append(zstd, idx)
+		}
+		sort.Ints(zstd)
+		exp.Logs = append(exp.Logs, ArchiveLogExport{
+			Log:             l.Log,
+			Folder:          l.FolderName,
+			ZipParts:        l.ZipParts,
+			ZstdZipParts:    zstd,
+			FirstDiscovered: l.FirstDiscovered,
+		})
+	}
+	sort.Slice(exp.Logs, func(i, j int) bool { return exp.Logs[i].Log < exp.Logs[j].Log })
+	return exp
+}
+
+// writeArchiveSnapshotExport writes exp to path through a temporary file in the same
+// directory, so a standby reading path never sees a partial file.
+func writeArchiveSnapshotExport(path string, exp ArchiveSnapshotExport) error {
+	data, err := json.Marshal(exp)
+	if err != nil {
+		return fmt.Errorf("encode archive snapshot: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create archive snapshot: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write archive snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write archive snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename archive snapshot: %w", err)
+	}
+	return nil
+}
+
+// readArchiveSnapshotSeed reads an ArchiveSnapshotExport from path and turns it into a
+// snapshot for cfg. Folder paths are resolved against cfg.ArchivePath, and logs that
+// CT_LOG_ALLOWLIST/CT_LOG_DENYLIST would not select are dropped.
+func readArchiveSnapshotSeed(cfg Config, path string) (ArchiveSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ArchiveSnapshot{}, fmt.Errorf("read archive snapshot seed: %w", err)
+	}
+	var exp ArchiveSnapshotExport
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return ArchiveSnapshot{}, fmt.Errorf("parse archive snapshot seed: %w", err)
+	}
+	if exp.Version != archiveSnapshotExportVersion {
+		return ArchiveSnapshot{}, fmt.Errorf("archive snapshot seed: unsupported version %d", exp.Version)
+	}
+
+	logs := make(map[string]ArchiveLog, len(exp.Logs))
+	for _, l := range exp.Logs {
+		if l.Log == "" || !filepath.IsLocal(l.Folder) {
+			return ArchiveSnapshot{}, fmt.Errorf("archive snapshot seed: invalid log %q in folder %q", l.Log, l.Folder)
+		}
+		if _, dup := logs[l.Log]; dup {
+			return ArchiveSnapshot{}, fmt.Errorf("archive snapshot seed: duplicate log %q", l.Log)
+		}
+		if !logSelected(l.Log, cfg.LogAllowlist, cfg.LogDenylist) {
+			continue
+		}
+		zipParts := append([]int(nil), l.ZipParts...)
+		sort.Ints(zipParts)
+		var zstd map[int]bool
+		for _, idx := range l.ZstdZipParts {
+			if zstd == nil {
+				zstd = make(map[int]bool)
+			}
+			zstd[idx] = true
+		}
+		logs[l.Log] = ArchiveLog{
+			Log:             l.Log,
+			FolderName:      l.Folder,
+			FolderPath:      filepath.Join(cfg.ArchivePath, l.Folder),
+			ZipParts:        zipParts,
+			ZstdZipParts:    zstd,
+			FirstDiscovered: l.FirstDiscovered,
+		}
+	}
+	return ArchiveSnapshot{Logs: logs}, nil
+}
+
+// exportLoop writes the current snapshot to CT_ARCHIVE_SNAPSHOT_EXPORT_PATH right away
+// and then every CT_ARCHIVE_SNAPSHOT_EXPORT_INTERVAL until ctx is done.
+func (ai *ArchiveIndex) exportLoop(ctx context.Context) {
+	t := time.NewTicker(ai.cfg.ArchiveSnapshotExportInterval)
+	defer t.Stop()
+	for {
+		if err := writeArchiveSnapshotExport(ai.cfg.ArchiveSnapshotExportPath, ai.Export()); err != nil && ai.logger != nil {
+			ai.logger.Error("Failed to export archive snapshot", "path", ai.cfg.ArchiveSnapshotExportPath, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// rescanSeed replaces a snapshot seeded from CT_ARCHIVE_SNAPSHOT_SEED_PATH with a disk
+// scan. Like the initial scan it holds back no logs under CT_NEW_LOG_GRACE_PERIOD and is
+// not bounded by CT_ARCHIVE_REFRESH_TIMEOUT. FirstDiscovered is kept from the seed. On
+// failure the seed keeps being served until the next periodic refresh.
+func (ai *ArchiveIndex) rescanSeed() error {
+	ai.refreshMu.Lock()
+	defer ai.refreshMu.Unlock()
+
+	seed := ai.GetAllLogs()
+	cfg := ai.cfg
+	cfg.NewLogGracePeriod = 0
+	snap, err := buildArchiveSnapshot(cfg, ai.readDir, ai.logger, ai.metrics, &seed, ai.now())
+	if err != nil {
+		if ai.logger != nil {
+			ai.logger.Error("Archive scan after seeding failed, serving the seed", "error", err)
+		}
+		return err
+	}
+	if ai.logger != nil {
+		ai.logger.Info("Archive scan replaced the seeded snapshot", "log_count", len(snap.Logs))
+	}
+	ai.storeSnapshot(&seed, snap)
+	return nil
+}
+
+// seedSnapshot stores the snapshot read from CT_ARCHIVE_SNAPSHOT_SEED_PATH; Start then
+// replaces it with a disk scan in the background.
+func (ai *ArchiveIndex) seedSnapshot() error {
+	snap, err := readArchiveSnapshotSeed(ai.cfg, ai.cfg.ArchiveSnapshotSeedPath)
+	if err != nil {
+		return err
+	}
+	ai.snap.Store(snap)
+	ai.updateResourceMetrics(snap)
+	ai.seeded = true
+	return nil
+}
@@ -0,0 +1,131 @@
+package ctarchiveserve
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestServer_LogListV3JSONBrotli(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint":  checkpointBody(1),
+		"log.v3.json": []byte(`{"description":"Test log","log_id":"aWQ=","key":"a2V5","mmd":86400}`),
+	})
+	staticPath := filepath.Join(t.TempDir(), "logs.v3.json.gz")
+	mustWriteFile(t, staticPath, gzipBytes(t, []byte(`{"version":"static","operators":[]}`)))
+
+	newServer := func(brotliOn bool) *Server {
+		cfg := Config{
+			ArchivePath:           root,
+			ArchiveFolderPattern:  "ct_*",
+			ArchiveFolderPrefix:   "ct_",
+			LogListV3JSONETag:     true,
+			LogListV3JSONStaticGz: staticPath,
+			MonitorJSONAlias:      true,
+			HTTPBrotli:            brotliOn,
+		}
+		archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+		builder.refreshOnce("http://placeholder")
+		return NewServer(cfg, nil, nil, archiveIndex, zr, builder)
+	}
+	server := newServer(true)
+
+	do := func(server *Server, method, path, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s (Accept-Encoding %q) status = %d, want 200", method, path, acceptEncoding, w.Code)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("%s %s Vary = %q, want Accept-Encoding", method, path, got)
+		}
+		return w
+	}
+
+	identity := do(server, http.MethodGet, "/logs.v3.json", "")
+	if got := identity.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("identity Content-Encoding = %q, want none", got)
+	}
+	if !strings.Contains(identity.Body.String(), "test_log") {
+		t.Fatalf("identity body = %q, want the built list", identity.Body.String())
+	}
+
+	// br is preferred over gzip, even with the static gzip file configured, on both paths.
+	for _, path := range []string{"/logs.v3.json", "/monitor.json"} {
+		w := do(server, http.MethodGet, path, "gzip, br;q=0.5")
+		if got := w.Header().Get("Content-Encoding"); got != "br" {
+			t.Fatalf("GET %s Content-Encoding = %q, want br", path, got)
+		}
+		if got := w.Header().Get("Content-Length"); got != "" {
+			t.Errorf("GET %s Content-Length = %q, want none", path, got)
+		}
+		body, err := io.ReadAll(brotli.NewReader(w.Body))
+		if err != nil || string(body) != identity.Body.String() {
+			t.Fatalf("GET %s decompressed body = %q (error %v), want %q", path, body, err, identity.Body.String())
+		}
+		etag := w.Header().Get("ETag")
+		if etag == "" || etag == identity.Header().Get("ETag") || !strings.HasSuffix(etag, `-br"`) {
+			t.Errorf("GET %s ETag = %q, want the identity ETag %q with -br", path, etag, identity.Header().Get("ETag"))
+		}
+	}
+
+	// A conditional br request matches the br ETag only.
+	br := do(server, http.MethodGet, "/logs.v3.json", "br")
+	req := httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	req.Header.Set("If-None-Match", br.Header().Get("ETag"))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("GET with the br ETag status = %d, want 304", w.Code)
+	}
+	req.Header.Set("If-None-Match", identity.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET br with the identity ETag status = %d, want 200", w.Code)
+	}
+
+	if w := do(server, http.MethodHead, "/logs.v3.json", "br"); w.Header().Get("Content-Encoding") != "br" || w.Body.Len() != 0 {
+		t.Errorf("HEAD br Content-Encoding = %q, body length = %d, want br and 0", w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+
+	// br excluded with q=0, directly or against "*", falls back to gzip or identity.
+	for _, tc := range []struct{ acceptEncoding, want string }{
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"br;q=0", ""},
+		{"identity", ""},
+	} {
+		w := do(server, http.MethodGet, "/logs.v3.json", tc.acceptEncoding)
+		if got := w.Header().Get("Content-Encoding"); got != tc.want {
+			t.Errorf("GET (Accept-Encoding %q) Content-Encoding = %q, want %q", tc.acceptEncoding, got, tc.want)
+		}
+	}
+
+	// Without CT_HTTP_BROTLI, br clients get gzip.
+	if w := do(newServer(false), http.MethodGet, "/logs.v3.json", "br, gzip"); w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("GET br without CT_HTTP_BROTLI Content-Encoding = %q, want gzip", w.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,107 @@
+package ctarchiveserve
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DefaultBulkDownloadConcurrency is the default CT_BULK_DOWNLOAD_CONCURRENCY.
+const DefaultBulkDownloadConcurrency = 2
+
+// bulkDownloadRetryAfterSeconds is the Retry-After sent when CT_BULK_DOWNLOAD_CONCURRENCY
+// is reached. A whole-log download takes a while, so there is no point retrying soon.
+const bulkDownloadRetryAfterSeconds = "60"
+
+// handleBulkDownload serves GET /<log>/download.tar (CT_ENABLE_BULK_DOWNLOAD): a tar of
+// every entry in the log's zip parts, in part order, streamed without touching disk.
+func (s *Server) handleBulkDownload(w http.ResponseWriter, r *http.Request, route Route) {
+	if !s.cfg.EnableBulkDownload {
+		s.notFound(w, r)
+		return
+	}
+	if s.zipReader == nil || s.archiveIndex == nil {
+		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
+		return
+	}
+
+	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
+	if !ok {
+		s.notFound(w, r)
+		return
+	}
+
+	if s.bulkSem != nil {
+		if !s.bulkSem.TryAcquire(1) {
+			w.Header().Set("Retry-After", bulkDownloadRetryAfterSeconds)
+			http.Error(w, "Too many bulk downloads", http.StatusServiceUnavailable)
+			return
+		}
+		defer s.bulkSem.Release(1)
+	}
+	release, ok := s.acquireHeavyOp(w)
+	if !ok {
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", route.Log+".tar"))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
+	defer copyBufPool.Put(bufp)
+
+	tw := tar.NewWriter(w)
+	for _, idx := range archiveLog.ZipParts {
+		zipPath := archiveLog.ZipPartPath(idx)
+		err := s.zipReader.WalkEntries(r.Context(), zipPath, func(f *zip.File) error {
+			return s.writeTarEntry(r, tw, route.Log, f, *bufp)
+		})
+		if err != nil {
+			s.logCopyError(r, "Failed to write bulk download", "log", route.Log, "part", idx, "error", err)
+			// The status line is already out; abort so the client sees a broken
+			// transfer rather than a tar that merely ends early.
+			panic(http.ErrAbortHandler)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		s.logCopyError(r, "Failed to write bulk download", "log", route.Log, "error", err)
+	}
+}
+
+// writeTarEntry copies zip entry f into tw as <log>/<entry>. Directories and entries
+// outside CT_ZIP_ENTRY_PREFIX are skipped.
+func (s *Server) writeTarEntry(r *http.Request, tw *tar.Writer, log string, f *zip.File, buf []byte) error {
+	name, ok := strings.CutPrefix(normalizeZipEntryName(f.Name), s.cfg.ZipEntryPrefix)
+	if !ok || name == "" || strings.HasSuffix(name, "/") {
+		return nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	hdr := &tar.Header{
+		Name:    path.Join(log, name),
+		Mode:    0o644,
+		Size:    int64(f.UncompressedSize64), //nolint:gosec // G115: zip entry sizes fit in int64
+		ModTime: f.Modified,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("write tar header %s: %w", name, err)
+	}
+	if _, err := copyWithContext(r.Context(), tw, rc, buf); err != nil {
+		return fmt.Errorf("copy %s: %w", f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package ctarchiveserve
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_BulkDownload(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	parts := map[string]map[string][]byte{
+		"000.zip": {
+			"checkpoint":    checkpointBody(2),
+			"log.v3.json":   []byte(`{"description":"test"}`),
+			"issuer/0a1b":   []byte("issuer"),
+			"tile/0/000":    bytes.Repeat([]byte{1}, 64),
+			"tile/data/000": []byte("data tile 0"),
+		},
+		"001.zip": {
+			"tile/0/001": bytes.Repeat([]byte{2}, 64),
+		},
+	}
+	want := make(map[string][]byte)
+	for part, entries := range parts {
+		mustCreateZip(t, filepath.Join(logFolder, part), entries)
+		for name, data := range entries {
+			want["test_log/"+name] = data
+		}
+	}
+
+	cfg := Config{
+		ArchivePath:             root,
+		ArchiveFolderPattern:    "ct_*",
+		ArchiveFolderPrefix:     "ct_",
+		EnableBulkDownload:      true,
+		BulkDownloadConcurrency: 1,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	zr.SetZipPartCache(NewZipPartCache(16, nil, 4))
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/download.tar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="test_log.tar"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+
+	got := make(map[string][]byte)
+	tr := tar.NewReader(w.Body)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("tar read %s error = %v", hdr.Name, err)
+		}
+		got[hdr.Name] = data
+	}
+	if len(got) != len(want) {
+		t.Errorf("tar has %d entries, want %d", len(got), len(want))
+	}
+	for name, data := range want {
+		if !bytes.Equal(got[name], data) {
+			t.Errorf("tar entry %s = %q, want %q", name, got[name], data)
+		}
+	}
+
+	// The only download slot is taken.
+	server.bulkSem.TryAcquire(1)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/download.tar", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != bulkDownloadRetryAfterSeconds {
+		t.Errorf("busy: status = %d, Retry-After = %q; want %d, %q", w.Code, w.Header().Get("Retry-After"), http.StatusServiceUnavailable, bulkDownloadRetryAfterSeconds)
+	}
+	server.bulkSem.Release(1)
+
+	// Disabled by default.
+	cfg.EnableBulkDownload = false
+	server = NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/download.tar", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
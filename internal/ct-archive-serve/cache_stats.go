@@ -0,0 +1,68 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// CacheStatsLogger periodically logs zip part and entry content cache statistics at INFO
+// (CT_CACHE_STATS_INTERVAL), giving operators a time series without Prometheus scraping.
+// Counters are logged as deltas since the previous line.
+type CacheStatsLogger struct {
+	interval   time.Duration
+	logger     *slog.Logger
+	metrics    *Metrics
+	zipCache   *ZipPartCache
+	entryCache *EntryContentCache
+
+	prevEvictions, prevPassed, prevFailed uint64
+}
+
+// NewCacheStatsLogger constructs a CacheStatsLogger. zipCache and entryCache may be nil.
+func NewCacheStatsLogger(interval time.Duration, logger *slog.Logger, metrics *Metrics, zipCache *ZipPartCache, entryCache *EntryContentCache) *CacheStatsLogger {
+	c := &CacheStatsLogger{
+		interval:   interval,
+		logger:     logger,
+		metrics:    metrics,
+		zipCache:   zipCache,
+		entryCache: entryCache,
+	}
+	c.prevEvictions, c.prevPassed, c.prevFailed = metrics.zipCacheCounters()
+	return c
+}
+
+// Start logs statistics every interval until ctx is done. It is a no-op when the
+// interval is <= 0 or there is no logger.
+func (c *CacheStatsLogger) Start(ctx context.Context) {
+	if c == nil || c.interval <= 0 || c.logger == nil {
+		return
+	}
+
+	t := time.NewTicker(c.interval)
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				c.logOnce()
+			}
+		}
+	}()
+}
+
+func (c *CacheStatsLogger) logOnce() {
+	evictions, passed, failed := c.metrics.zipCacheCounters()
+	entryBytes, entryItems := c.entryCache.Stats()
+	c.logger.Info("Cache statistics",
+		"zip_cache_open", c.zipCache.OpenCount(),
+		"zip_cache_evictions", evictions-c.prevEvictions,
+		"entry_cache_bytes", entryBytes,
+		"entry_cache_items", entryItems,
+		"zip_integrity_passed", passed-c.prevPassed,
+		"zip_integrity_failed", failed-c.prevFailed,
+	)
+	c.prevEvictions, c.prevPassed, c.prevFailed = evictions, passed, failed
+}
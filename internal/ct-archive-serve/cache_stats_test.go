@@ -0,0 +1,74 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// lockedBuffer is a bytes.Buffer safe for a logger goroutine and a polling test.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestCacheStatsLogger_LogsOnInterval(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics(prometheus.NewRegistry())
+	metrics.IncZipCacheEvictions() // before construction: not part of the first delta
+	entryCache := NewEntryContentCache(1<<20, metrics)
+	entryCache.Put("/a/000.zip", "checkpoint", []byte("12345"))
+
+	var logs lockedBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	c := NewCacheStatsLogger(10*time.Millisecond, logger, metrics, NewZipPartCache(16, metrics, 1), entryCache)
+
+	metrics.IncZipIntegrityPassed()
+	metrics.IncZipIntegrityPassed()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "Cache statistics") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no cache statistics line logged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	line := strings.SplitN(logs.String(), "\n", 2)[0]
+	for _, want := range []string{
+		"zip_cache_open=0",
+		"zip_cache_evictions=0",
+		"entry_cache_bytes=5",
+		"entry_cache_items=1",
+		"zip_integrity_passed=2",
+		"zip_integrity_failed=0",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("stats line %q missing %q", line, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ctarchiveserve
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Response headers set on /<log>/checkpoint with CT_CHECKPOINT_HEADERS, so monitors can
+// detect log growth with a HEAD request.
+const (
+	treeSizeHeader = "X-Tlog-Tree-Size"
+	originHeader   = "X-Tlog-Origin"
+)
+
+// checkpointInfo is the parsed origin and tree size of one checkpoint body.
+type checkpointInfo struct {
+	body   string
+	origin string
+	size   uint64
+}
+
+// checkpointInfoCache remembers the last parsed checkpoint per log, keyed by its bytes,
+// so repeated HEADs of an unchanged checkpoint skip parsing.
+type checkpointInfoCache struct {
+	mu    sync.Mutex
+	byLog map[string]checkpointInfo
+}
+
+func newCheckpointInfoCache() *checkpointInfoCache {
+	return &checkpointInfoCache{byLog: make(map[string]checkpointInfo)}
+}
+
+// get returns the origin and tree size of body, the current checkpoint of log.
+func (c *checkpointInfoCache) get(log string, body []byte) (checkpointInfo, bool) {
+	c.mu.Lock()
+	info, ok := c.byLog[log]
+	c.mu.Unlock()
+	if ok && info.body == string(body) {
+		return info, true
+	}
+
+	size, ok := checkpointTreeSize(body)
+	if !ok {
+		return checkpointInfo{}, false
+	}
+	origin, _, _ := strings.Cut(string(body), "\n")
+	info = checkpointInfo{body: string(body), origin: origin, size: size}
+
+	c.mu.Lock()
+	c.byLog[log] = info
+	c.mu.Unlock()
+	return info, true
+}
+
+// setCheckpointHeaders sets X-Tlog-Tree-Size and X-Tlog-Origin from body. A body that is
+// not a parseable checkpoint is served without them.
+func (s *Server) setCheckpointHeaders(w http.ResponseWriter, log string, body []byte) {
+	info, ok := s.checkpointInfo.get(log, body)
+	if !ok {
+		return
+	}
+	w.Header().Set(treeSizeHeader, strconv.FormatUint(info.size, 10))
+	if isPrintableASCII(info.origin) {
+		w.Header().Set(originHeader, info.origin)
+	}
+}
+
+// serveCheckpointWithHeaders reads the checkpoint entry into memory to derive the
+// CT_CHECKPOINT_HEADERS values before anything is written.
+func (s *Server) serveCheckpointWithHeaders(w http.ResponseWriter, r *http.Request, route Route, rc io.Reader) {
+	body, err := io.ReadAll(io.LimitReader(rc, maxCheckpointSize))
+	if err != nil {
+		s.logCopyError(r, "Failed to read checkpoint", "log", route.Log, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.setCheckpointHeaders(w, route.Log, body)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+	if _, err := w.Write(body); err != nil {
+		s.logCopyError(r, "Failed to write checkpoint response", "log", route.Log, "error", err)
+	}
+}
+
+// isPrintableASCII reports whether v is non-empty and consists of printable ASCII only,
+// i.e. is safe to echo as a header value.
+func isPrintableASCII(v string) bool {
+	if v == "" {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_CheckpointHeaders(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{"checkpoint": checkpointBody(1234)})
+
+	newServer := func(enabled bool) *Server {
+		cfg := Config{
+			ArchivePath:          root,
+			ArchiveFolderPattern: "ct_*",
+			ArchiveFolderPrefix:  "ct_",
+			CheckpointHeaders:    enabled,
+		}
+		metrics := NewMetrics(prometheus.NewRegistry())
+		archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+		return NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+	}
+
+	server := newServer(true)
+	for _, method := range []string{http.MethodHead, http.MethodHead, http.MethodGet} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(method, "/test_log/checkpoint", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status = %d, want %d", method, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get(treeSizeHeader); got != "1234" {
+			t.Errorf("%s %s = %q, want %q", method, treeSizeHeader, got, "1234")
+		}
+		if got := w.Header().Get(originHeader); got != "example.com/test" {
+			t.Errorf("%s %s = %q, want %q", method, originHeader, got, "example.com/test")
+		}
+		wantBody := ""
+		if method == http.MethodGet {
+			wantBody = string(checkpointBody(1234))
+		}
+		if got := w.Body.String(); got != wantBody {
+			t.Errorf("%s body = %q, want %q", method, got, wantBody)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	newServer(false).ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/test_log/checkpoint", nil))
+	if got := w.Header().Get(treeSizeHeader); got != "" {
+		t.Errorf("disabled: %s = %q, want unset", treeSizeHeader, got)
+	}
+}
+
+func TestCheckpointInfoCache_ReparsesChangedBody(t *testing.T) {
+	t.Parallel()
+
+	c := newCheckpointInfoCache()
+	if info, ok := c.get("test_log", checkpointBody(10)); !ok || info.size != 10 {
+		t.Fatalf("get() = %+v, %v; want size 10", info, ok)
+	}
+	if info, ok := c.get("test_log", checkpointBody(11)); !ok || info.size != 11 {
+		t.Fatalf("get() after change = %+v, %v; want size 11", info, ok)
+	}
+	if _, ok := c.get("test_log", []byte("not a checkpoint")); ok {
+		t.Errorf("get(garbage) ok = true, want false")
+	}
+}
@@ -0,0 +1,248 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxCheckpointSize bounds how much of a checkpoint entry is read into memory. Signed
+// checkpoints are a few hundred bytes.
+const maxCheckpointSize = 64 << 10
+
+var errTooManyCheckpointWaiters = errors.New("too many checkpoint long-polls")
+
+// checkpointWatcher lets GET /<log>/checkpoint?wait=&after= block until a larger checkpoint
+// appears. After each archive refresh it re-reads the checkpoint of every log that has
+// waiters and wakes them when the tree size grew.
+type checkpointWatcher struct {
+	entryName  string
+	maxWaiters int64
+	logger     *slog.Logger
+
+	waiters atomic.Int64
+
+	mu   sync.Mutex
+	logs map[string]*checkpointWaitState // only logs with waiters
+}
+
+// checkpointWaitState is the per-log condition. changed is closed and replaced whenever a
+// larger checkpoint is observed, waking all waiters like sync.Cond.Broadcast while still
+// letting them select on their deadline.
+type checkpointWaitState struct {
+	waiters int
+	size    uint64
+	body    []byte // nil until the first re-read after the state was created
+	changed chan struct{}
+}
+
+func newCheckpointWatcher(entryName string, maxWaiters int, logger *slog.Logger) *checkpointWatcher {
+	if maxWaiters <= 0 {
+		maxWaiters = DefaultCheckpointLongPollMaxWaiters
+	}
+	return &checkpointWatcher{
+		entryName:  entryName,
+		maxWaiters: int64(maxWaiters),
+		logger:     logger,
+		logs:       make(map[string]*checkpointWaitState),
+	}
+}
+
+// wait blocks until a checkpoint with a tree size greater than after is observed for log,
+// or ctx is done. It returns errTooManyCheckpointWaiters when the waiter cap is reached.
+func (cw *checkpointWatcher) wait(ctx context.Context, log string, after uint64) ([]byte, error) {
+	if cw.waiters.Add(1) > cw.maxWaiters {
+		cw.waiters.Add(-1)
+		return nil, errTooManyCheckpointWaiters
+	}
+	defer cw.waiters.Add(-1)
+
+	cw.mu.Lock()
+	st := cw.logs[log]
+	if st == nil {
+		st = &checkpointWaitState{changed: make(chan struct{})}
+		cw.logs[log] = st
+	}
+	st.waiters++
+	cw.mu.Unlock()
+
+	defer func() {
+		cw.mu.Lock()
+		st.waiters--
+		if st.waiters == 0 {
+			delete(cw.logs, log)
+		}
+		cw.mu.Unlock()
+	}()
+
+	for {
+		cw.mu.Lock()
+		if st.body != nil && st.size > after {
+			body := st.body
+			cw.mu.Unlock()
+			return body, nil
+		}
+		changed := st.changed
+		cw.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err() //nolint:wrapcheck // callers check context errors directly
+		}
+	}
+}
+
+// refresh re-reads the checkpoints of logs with waiters from snap. It reads the zip part
+// directly rather than through the zip caches, which may still hold the previous file.
+func (cw *checkpointWatcher) refresh(snap ArchiveSnapshot) {
+	cw.mu.Lock()
+	names := make([]string, 0, len(cw.logs))
+	for name := range cw.logs {
+		names = append(names, name)
+	}
+	cw.mu.Unlock()
+
+	for _, name := range names {
+		l, ok := snap.Logs[name]
+		if !ok {
+			continue
+		}
+		body, err := readCheckpoint(l.ZipPartPath(0), cw.entryName)
+		if err != nil {
+			if cw.logger != nil {
+				cw.logger.Debug("Checkpoint re-read failed", "log", name, "error", err)
+			}
+			continue
+		}
+		size, ok := checkpointTreeSize(body)
+		if !ok {
+			continue
+		}
+
+		cw.mu.Lock()
+		if st := cw.logs[name]; st != nil && (st.body == nil || size > st.size) {
+			st.size = size
+			st.body = body
+			close(st.changed)
+			st.changed = make(chan struct{})
+		}
+		cw.mu.Unlock()
+	}
+}
+
+// readCheckpoint reads entryName from the zip part at path, bypassing the zip caches.
+func readCheckpoint(path, entryName string) ([]byte, error) {
+	zr, err := openZipPart(path)
+	if err != nil {
+		return nil, fmt.Errorf("open zip part: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	f := findZipEntry(zr.File, entryName)
+	if f == nil {
+		return nil, fmt.Errorf("%w: zip entry missing", ErrNotFound)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open zip entry: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+	b, err := io.ReadAll(io.LimitReader(rc, maxCheckpointSize))
+	if err != nil {
+		return nil, fmt.Errorf("read zip entry: %w", err)
+	}
+	return b, nil
+}
+
+// checkpointTreeSize returns the tree size from the second line of a checkpoint
+// (origin, tree size, root hash, ...; see c2sp.org/tlog-checkpoint).
+func checkpointTreeSize(b []byte) (uint64, bool) {
+	lines := strings.SplitN(string(b), "\n", 3)
+	if len(lines) < 3 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// isCheckpointLongPoll reports whether r, routed to route, is a checkpoint long-poll:
+// it blocks without writing on purpose, so progress and content timeouts do not apply.
+func (s *Server) isCheckpointLongPoll(r *http.Request, route Route) bool {
+	return route.Kind == RouteCheckpoint && s.checkpointWatcher != nil && r.URL.Query().Has("wait")
+}
+
+// handleCheckpointLongPoll serves GET /<log>/checkpoint?wait=<seconds>&after=<treesize>.
+//
+// The current checkpoint is returned at once if its tree size already exceeds after;
+// otherwise the request blocks until a refresh observes a larger one, or answers 304 once
+// wait (capped by CT_CHECKPOINT_LONGPOLL_MAX_WAIT) elapses. Responses are never cached,
+// since the same URL yields different checkpoints over time.
+func (s *Server) handleCheckpointLongPoll(w http.ResponseWriter, r *http.Request, route Route, archiveLog ArchiveLog, entryName string) {
+	q := r.URL.Query()
+	waitSecs, err := strconv.ParseUint(q.Get("wait"), 10, 32)
+	if err != nil || waitSecs == 0 {
+		http.Error(w, "wait must be a positive number of seconds", http.StatusBadRequest)
+		return
+	}
+	after, err := strconv.ParseUint(q.Get("after"), 10, 64)
+	if err != nil {
+		http.Error(w, "after must be a tree size", http.StatusBadRequest)
+		return
+	}
+	wait := min(time.Duration(waitSecs)*time.Second, s.cfg.CheckpointLongPollMaxWait)
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	rc, err := s.openMetadataEntry(r, archiveLog.ZipPartPath(0), s.zipEntryName(entryName))
+	if err != nil {
+		s.writeOpenEntryError(w, r, err)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(rc, maxCheckpointSize))
+	_ = rc.Close()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if size, ok := checkpointTreeSize(body); !ok || size <= after {
+		ctx, cancel := context.WithTimeout(r.Context(), wait)
+		defer cancel()
+		body, err = s.checkpointWatcher.wait(ctx, route.Log, after)
+		switch {
+		case errors.Is(err, errTooManyCheckpointWaiters):
+			http.Error(w, "Too many checkpoint long-polls", http.StatusServiceUnavailable)
+			return
+		case r.Context().Err() != nil:
+			w.WriteHeader(statusClientClosedRequest)
+			return
+		case err != nil:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if s.checkpointInfo != nil {
+		s.setCheckpointHeaders(w, route.Log, body)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+	if _, err := w.Write(body); err != nil {
+		s.logCopyError(r, "Failed to write checkpoint response", "log", route.Log, "error", err)
+	}
+}
@@ -0,0 +1,173 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func checkpointBody(size int) []byte {
+	return []byte("example.com/test\n" + strconv.Itoa(size) + "\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n")
+}
+
+func newLongPollTestServer(t *testing.T, size int) (*Server, *ArchiveIndex, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	zipPath := filepath.Join(logFolder, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{"checkpoint": checkpointBody(size)})
+
+	cfg := Config{
+		ArchivePath:               root,
+		ArchiveFolderPattern:      "ct_*",
+		ArchiveFolderPrefix:       "ct_",
+		CheckpointLongPollMaxWait: 10 * time.Second,
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	return NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil), archiveIndex, zipPath
+}
+
+func TestServer_CheckpointLongPoll_RefreshUnblocksWaiter(t *testing.T) {
+	t.Parallel()
+
+	server, archiveIndex, zipPath := newLongPollTestServer(t, 10)
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint?wait=10&after=10", nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		done <- w
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.checkpointWatcher.waiters.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("long-poll did not start waiting")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mustCreateZip(t, zipPath, map[string][]byte{"checkpoint": checkpointBody(11)})
+	if err := archiveIndex.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+
+	select {
+	case w := <-done:
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got, want := w.Body.String(), string(checkpointBody(11)); got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("Cache-Control = %q, want %q", cc, "no-store")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("long-poll was not unblocked by refresh")
+	}
+}
+
+func TestServer_CheckpointLongPoll_Immediate(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newLongPollTestServer(t, 10)
+
+	req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint?wait=10&after=9", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Body.String(), string(checkpointBody(10)); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestServer_CheckpointLongPoll_TimeoutNotModified(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newLongPollTestServer(t, 10)
+
+	req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint?wait=1&after=10", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if got := server.checkpointWatcher.waiters.Load(); got != 0 {
+		t.Errorf("waiters after timeout = %d, want 0", got)
+	}
+}
+
+func TestServer_CheckpointLongPoll_OutlastsStreamTimeout(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newLongPollTestServer(t, 10)
+	// Shorter than wait: the long-poll writes nothing until it answers, and must not lose
+	// its connection to the progress deadline. A real connection is needed for deadlines.
+	server.cfg.HTTPStreamTimeout = 300 * time.Millisecond
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	resp, err := ts.Client().Get(ts.URL + "/test_log/checkpoint?wait=1&after=10")
+	if err != nil {
+		t.Fatalf("GET long-poll error = %v, want a 304", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusNotModified)
+	}
+
+	// Other requests keep the progress deadline.
+	resp, err = ts.Client().Get(ts.URL + "/test_log/checkpoint")
+	if err != nil {
+		t.Fatalf("GET checkpoint error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET checkpoint status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestServer_CheckpointLongPoll_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newLongPollTestServer(t, 10)
+
+	for _, query := range []string{"wait=0&after=1", "wait=x&after=1", "wait=5", "wait=5&after=-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint?"+query, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET ?%s status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestCheckpointWatcher_MaxWaiters(t *testing.T) {
+	t.Parallel()
+
+	cw := newCheckpointWatcher(DefaultCheckpointEntryName, 1, nil)
+	cw.waiters.Store(1)
+	if _, err := cw.wait(t.Context(), "test_log", 0); !errors.Is(err, errTooManyCheckpointWaiters) {
+		t.Fatalf("wait() error = %v, want %v", err, errTooManyCheckpointWaiters)
+	}
+}
@@ -0,0 +1,43 @@
+package ctarchiveserve
+
+import "net/http"
+
+// checkpointWitnessedEntryName is the 000.zip entry holding the checkpoint together with
+// its witness cosignatures, served as /<log>/checkpoint.witnessed.
+const checkpointWitnessedEntryName = "checkpoint.witnessed"
+
+// handleCheckpointWitnessed serves GET /<log>/checkpoint.witnessed: the checkpoint with
+// witness cosignatures, for monitors verifying a witness quorum. Archives without the
+// entry get 404.
+func (s *Server) handleCheckpointWitnessed(w http.ResponseWriter, r *http.Request, route Route) {
+	if s.zipReader == nil || s.archiveIndex == nil {
+		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
+		return
+	}
+
+	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
+	if !ok {
+		s.notFound(w, r)
+		return
+	}
+
+	rc, err := s.openMetadataEntry(r, archiveLog.ZipPartPath(0), s.zipEntryName(checkpointWitnessedEntryName))
+	if err != nil {
+		s.writeOpenEntryError(w, r, err)
+		return
+	}
+	defer func() { _ = rc.Close() }()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
+	defer copyBufPool.Put(bufp)
+	if _, err := copyWithContext(r.Context(), w, rc, *bufp); err != nil {
+		s.logCopyError(r, "Failed to write witnessed checkpoint response", "log", route.Log, "error", err)
+	}
+}
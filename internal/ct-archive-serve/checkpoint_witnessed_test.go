@@ -0,0 +1,55 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_CheckpointWitnessed(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	witnessed := append(checkpointBody(1234), []byte("\n— witness.example/w1 AAAAAQ==\n")...)
+	mustMkdir(t, filepath.Join(root, "ct_witnessed"))
+	mustCreateZip(t, filepath.Join(root, "ct_witnessed", "000.zip"), map[string][]byte{
+		"checkpoint":           checkpointBody(1234),
+		"checkpoint.witnessed": witnessed,
+	})
+	mustMkdir(t, filepath.Join(root, "ct_plain"))
+	mustCreateZip(t, filepath.Join(root, "ct_plain", "000.zip"), map[string][]byte{
+		"checkpoint": checkpointBody(1234),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/witnessed/checkpoint.witnessed", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	if got := w.Body.String(); got != string(witnessed) {
+		t.Errorf("body = %q, want %q", got, witnessed)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plain/checkpoint.witnessed", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("without entry: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,47 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clientWait returns the deadline requested with a "Prefer: wait=<seconds>" header
+// (RFC 7240), capped at CT_HTTP_MAX_CLIENT_WAIT. It returns false when the cap is unset
+// or the request has no usable wait preference.
+func (s *Server) clientWait(r *http.Request) (time.Duration, bool) {
+	maxWait := s.cfg.HTTPMaxClientWait
+	if maxWait <= 0 {
+		return 0, false
+	}
+	seconds, ok := preferWaitSeconds(r.Header.Values("Prefer"))
+	if !ok {
+		return 0, false
+	}
+	if seconds > int64(maxWait/time.Second) {
+		return maxWait, true
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// preferWaitSeconds finds the first wait preference in Prefer header values, e.g.
+// "respond-async, wait=5". Preference parameters after ';' are ignored, and so are
+// waits that are not a positive whole number of seconds.
+func preferWaitSeconds(values []string) (int64, bool) {
+	for _, v := range values {
+		for _, pref := range strings.Split(v, ",") {
+			pref, _, _ = strings.Cut(pref, ";")
+			name, value, ok := strings.Cut(pref, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "wait") {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64)
+			if err != nil || n <= 0 {
+				return 0, false
+			}
+			return n, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPreferWaitSeconds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		values []string
+		want   int64
+		wantOK bool
+	}{
+		{values: nil, wantOK: false},
+		{values: []string{"wait=5"}, want: 5, wantOK: true},
+		{values: []string{"respond-async, Wait=10"}, want: 10, wantOK: true},
+		{values: []string{"return=minimal", `wait="3"; foo=bar`}, want: 3, wantOK: true},
+		{values: []string{"wait=0"}, wantOK: false},
+		{values: []string{"wait=-1"}, wantOK: false},
+		{values: []string{"wait=soon"}, wantOK: false},
+		{values: []string{"waiting=5"}, wantOK: false},
+	}
+	for _, tc := range tests {
+		got, ok := preferWaitSeconds(tc.values)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("preferWaitSeconds(%q) = %d, %v; want %d, %v", tc.values, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestServer_PreferWait(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint": checkpointBody(1),
+		"tile/0/000": []byte("tile"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		HTTPMaxClientWait:    time.Minute,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	// A read stuck on a bad disk, as in TestServer_ContentTimeout.
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	verify := func(string) error {
+		<-release
+		return nil
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, verify, nil))
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	start := time.Now()
+	req := httptest.NewRequest(http.MethodGet, "/test_log/tile/0/000", nil)
+	req.Header.Set("Prefer", "wait=1")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 10*time.Second {
+		t.Fatalf("request took %v, want about the requested 1s", elapsed)
+	}
+
+	// The server maximum caps the client's wait.
+	server.cfg.HTTPMaxClientWait = 50 * time.Millisecond
+	if got := server.contentTimeout(req, Route{Kind: RouteHashTile}); got != 50*time.Millisecond {
+		t.Errorf("contentTimeout() with wait above the cap = %v, want 50ms", got)
+	}
+	// The shorter of CT_HTTP_CONTENT_TIMEOUT and the client's wait wins.
+	server.cfg.HTTPContentTimeout = 20 * time.Millisecond
+	if got := server.contentTimeout(req, Route{Kind: RouteHashTile}); got != 20*time.Millisecond {
+		t.Errorf("contentTimeout() with shorter content timeout = %v, want 20ms", got)
+	}
+	// Without CT_HTTP_MAX_CLIENT_WAIT the header is ignored.
+	server.cfg.HTTPMaxClientWait = 0
+	server.cfg.HTTPContentTimeout = 0
+	if got := server.contentTimeout(req, Route{Kind: RouteHashTile}); got != 0 {
+		t.Errorf("contentTimeout() without CT_HTTP_MAX_CLIENT_WAIT = %v, want 0", got)
+	}
+}
@@ -1,10 +1,16 @@
 package ctarchiveserve
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/netip"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,24 +23,325 @@ type Config struct {
 	ArchivePath          string
 	ArchiveFolderPattern string
 	ArchiveFolderPrefix  string
+	// ArchiveFolderSuffix is the text after the '*' in ArchiveFolderPattern (e.g. "_ct"
+	// for "*_ct"); the log name is whatever lies between prefix and suffix.
+	ArchiveFolderSuffix string
+	ArchiveImmutable    bool
+	// ArchiveFollowSymlinks includes log folders that are symlinks to directories
+	// (CT_ARCHIVE_FOLLOW_SYMLINKS).
+	ArchiveFollowSymlinks bool
+	// ArchiveCollisionPolicy is ArchiveCollisionFail or ArchiveCollisionSkip
+	// (CT_ARCHIVE_COLLISION_POLICY).
+	ArchiveCollisionPolicy string
+	// ArchiveNestedDepth is how many levels of operator directories sit between
+	// CT_ARCHIVE_PATH and the log folders; 0 is a flat archive (CT_ARCHIVE_NESTED_DEPTH).
+	ArchiveNestedDepth int
+	// ArchiveNestedLogNames is ArchiveNestedLogNamesFolder or ArchiveNestedLogNamesPath
+	// (CT_ARCHIVE_NESTED_LOG_NAMES).
+	ArchiveNestedLogNames string
+	// MetadataSource is MetadataSourceAuto, MetadataSourceFile or MetadataSourceZip: where
+	// log.v3.json is read from (CT_METADATA_SOURCE).
+	MetadataSource string
+
+	// LogAllowlist and LogDenylist are log name globs (path.Match syntax) selecting which
+	// discovered logs are served (CT_LOG_ALLOWLIST, CT_LOG_DENYLIST). A non-empty
+	// allowlist takes precedence; see logSelected.
+	LogAllowlist []string
+	LogDenylist  []string
+
+	// ArchiveExcludeFolders are folder name globs (path.Match syntax) under
+	// CT_ARCHIVE_PATH that are never scanned, even if they match the folder pattern
+	// (CT_ARCHIVE_EXCLUDE_FOLDERS).
+	ArchiveExcludeFolders []string
+
+	// RetiredLogs are log name globs (CT_RETIRED_LOGS) whose submission endpoints answer
+	// 410 Gone; their archive content is served as usual.
+	RetiredLogs []string
+
+	// CheckpointEntryName is the zip entry in 000.zip served as /<log>/checkpoint.
+	CheckpointEntryName string
+
+	// ZipEntryPrefix (CT_ZIP_ENTRY_PREFIX) is prepended to every entry name looked up in a
+	// zip part, for archives that nest tiles and metadata under a directory. It is empty
+	// (entries at the zip root) or ends in "/".
+	ZipEntryPrefix string
+
+	// ValidateTileSize checks each served tile's size against its geometry and logs and
+	// counts mismatches; the tile is still served (CT_VALIDATE_TILE_SIZE).
+	ValidateTileSize bool
+	// TileHashBytes is the hash length of a hash tile entry, used only by
+	// CT_VALIDATE_TILE_SIZE (CT_TILE_HASH_BYTES).
+	TileHashBytes int
+	// ValidateDataTiles checks that each served partial data tile tile/data/<N>.p/<W>
+	// holds exactly W entries, like ValidateTileSize does for all tiles
+	// (CT_VALIDATE_DATA_TILES).
+	ValidateDataTiles bool
+	// TileAllowUppercaseX accepts tile index segments with an uppercase X prefix, e.g.
+	// tile/0/X001/234 (CT_TILE_ALLOW_UPPERCASE_X).
+	TileAllowUppercaseX bool
+	// AllowPercentEncoding decodes percent-encoded request paths before routing instead of
+	// answering them with 404 (CT_ALLOW_PERCENT_ENCODING).
+	AllowPercentEncoding bool
+	// EntryMetadataHeaders lists the zip entry metadata response headers sent with tiles,
+	// canonicalized: Last-Modified and/or X-Archive-Entry-Comment (CT_ENTRY_METADATA_HEADERS).
+	EntryMetadataHeaders []string
+	// EmitLinkHeaders adds Link headers to tiles and checkpoints pointing to related
+	// resources (CT_EMIT_LINK_HEADERS).
+	EmitLinkHeaders bool
+	// EmitRefreshHeaders adds X-Archive-Refresh-Interval and X-LogList-Refresh-Interval to
+	// the JSON endpoints (CT_EMIT_REFRESH_HEADERS).
+	EmitRefreshHeaders bool
+	// PartialFromFull serves partial tiles by slicing the full tile when it is in the
+	// entry content cache (CT_PARTIAL_FROM_FULL).
+	PartialFromFull bool
+
+	// CheckpointLongPollMaxWait bounds the ?wait= of checkpoint long-polls; 0 disables
+	// long-polling (CT_CHECKPOINT_LONGPOLL_MAX_WAIT).
+	CheckpointLongPollMaxWait time.Duration
+	// CheckpointLongPollMaxWaiters caps concurrent long-polls across all logs; <= 0 means
+	// DefaultCheckpointLongPollMaxWaiters (CT_CHECKPOINT_LONGPOLL_MAX_WAITERS).
+	CheckpointLongPollMaxWaiters int
+	// CheckpointHeaders adds X-Tlog-Tree-Size and X-Tlog-Origin to /<log>/checkpoint
+	// responses (CT_CHECKPOINT_HEADERS).
+	CheckpointHeaders bool
 
 	LogListV3JSONRefreshInterval time.Duration
 	ArchiveRefreshInterval     time.Duration
+	// ArchiveRefreshTimeout abandons a periodic archive scan that runs longer than this,
+	// keeping the previous snapshot; 0 disables (CT_ARCHIVE_REFRESH_TIMEOUT).
+	ArchiveRefreshTimeout time.Duration
+	// NewLogGracePeriod holds a log discovered by a refresh out of the index until it has
+	// been present this long; 0 disables (CT_NEW_LOG_GRACE_PERIOD).
+	NewLogGracePeriod time.Duration
+	// ArchiveSnapshotExportPath, when set, is where the served archive snapshot is written
+	// every ArchiveSnapshotExportInterval for cold-standby nodes
+	// (CT_ARCHIVE_SNAPSHOT_EXPORT_PATH, CT_ARCHIVE_SNAPSHOT_EXPORT_INTERVAL).
+	ArchiveSnapshotExportPath     string
+	ArchiveSnapshotExportInterval time.Duration
+	// ArchiveSnapshotSeedPath, when set, seeds the archive index from an exported snapshot
+	// at startup and scans the archive in the background (CT_ARCHIVE_SNAPSHOT_SEED_PATH).
+	ArchiveSnapshotSeedPath string
+
+	// DisableLogListV3JSON turns off /logs.v3.json and its refresh loop
+	// (CT_ENABLE_LOGLISTV3_JSON=false).
+	DisableLogListV3JSON bool
+	// MonitorJSONAlias serves the log list at the legacy /monitor.json path too, and reads
+	// the legacy CT_MONITOR_JSON_REFRESH_INTERVAL (CT_MONITOR_JSON_ALIAS).
+	MonitorJSONAlias bool
+	// LogListV3JSONETag adds content-hash ETags to /logs.v3.json (and its alias),
+	// computed once per snapshot build (CT_LOGLISTV3_JSON_ETAG).
+	LogListV3JSONETag bool
+	// OperatorMapFile is a JSON file mapping log names to the operators they are listed
+	// under in /logs.v3.json (CT_OPERATOR_MAP); see LoadOperatorMap.
+	OperatorMapFile string
+	// LogListV3JSONStaticGz is a gzipped log list served as-is to clients that accept gzip,
+	// instead of the built one (CT_LOGLISTV3_JSON_STATIC_GZ).
+	LogListV3JSONStaticGz string
+	// HTTPBrotli compresses /logs.v3.json (and /monitor.json) with Brotli for clients that
+	// accept br, in preference to gzip (CT_HTTP_BROTLI).
+	HTTPBrotli bool
 
 	ZipCacheMaxOpen            int
 	ZipCacheMaxConcurrentOpens int
-	ZipIntegrityFailTTL        time.Duration
-	EntryContentCacheMaxBytes  int64
+	// ZipEntryFailureThreshold is how many consecutive requests must fail to open an entry
+	// of a cached zip part, each after one retry, before the part is dropped and
+	// re-verified (CT_ZIP_ENTRY_FAILURE_THRESHOLD).
+	ZipEntryFailureThreshold int
+	// ZstdZipPartMaxBytes is the most a NNN.zip.zst part may decompress to; larger parts
+	// fail to open (CT_ZSTD_ZIP_PART_MAX_BYTES).
+	ZstdZipPartMaxBytes int64
+	// PreopenNewParts opens the newest zip part of each log into the zip part cache in the
+	// background when a refresh discovers it (CT_PREOPEN_NEW_PARTS).
+	PreopenNewParts     bool
+	ZipIntegrityFailTTL time.Duration
+	// ZipIntegrityPassTTL expires passed zip integrity checks so the part is re-verified;
+	// 0 keeps them for the process lifetime (CT_ZIP_INTEGRITY_PASS_TTL).
+	ZipIntegrityPassTTL time.Duration
+	// ZipIntegrityVerifyTimeout bounds each zip integrity verification; 0 disables the
+	// bound (CT_ZIP_INTEGRITY_VERIFY_TIMEOUT).
+	ZipIntegrityVerifyTimeout time.Duration
+	// ZipCompleteMarker, when set, must appear in a zip part's archive comment for the
+	// part to pass the integrity check (CT_ZIP_COMPLETE_MARKER).
+	ZipCompleteMarker         string
+	// ZipGrowthCheck fails the integrity check of a zip part whose size changes between
+	// two stats ZipGrowthCheckDelay apart (CT_ZIP_GROWTH_CHECK).
+	ZipGrowthCheck bool
+	// LogCircuitFailures is the number of consecutive zip integrity failures across a log's
+	// parts within LogCircuitWindow that makes its reads fail fast with 503 for
+	// LogCircuitCooldown; 0 disables (CT_LOG_CIRCUIT_FAILURES, CT_LOG_CIRCUIT_WINDOW,
+	// CT_LOG_CIRCUIT_COOLDOWN).
+	LogCircuitFailures        int
+	LogCircuitWindow          time.Duration
+	LogCircuitCooldown        time.Duration
+	EntryContentCacheMaxBytes int64
+	// EmitContentHash adds X-Content-SHA256 to tiles served from the entry content cache,
+	// hashed once when the entry is cached (CT_EMIT_CONTENT_HASH).
+	EmitContentHash bool
+	// EntryCacheCompress stores entry content cache entries compressed when they shrink
+	// enough, trading CPU for capacity (CT_ENTRY_CACHE_COMPRESS).
+	EntryCacheCompress bool
+	// EntryCacheFillConcurrency bounds concurrent full reads that populate the entry
+	// content cache; 0 means no limit (CT_ENTRY_CACHE_FILL_CONCURRENCY).
+	EntryCacheFillConcurrency int
+
+	// CacheStatsInterval is how often cache statistics are logged; 0 disables
+	// (CT_CACHE_STATS_INTERVAL).
+	CacheStatsInterval time.Duration
 
 	HTTPReadHeaderTimeout time.Duration
 	HTTPIdleTimeout       time.Duration
 	HTTPMaxHeaderBytes    int
 	HTTPWriteTimeout      time.Duration
 	HTTPReadTimeout       time.Duration
+	HTTPStreamTimeout     time.Duration
+	// HTTPContentTimeout bounds the total time spent serving archive content (tiles,
+	// checkpoints, log.v3.json, issuers); 0 disables (CT_HTTP_CONTENT_TIMEOUT).
+	HTTPContentTimeout time.Duration
+	// JSONResponseTimeout bounds the time spent rendering and writing /logs.v3.json
+	// (and /monitor.json); 0 disables (CT_JSON_RESPONSE_TIMEOUT).
+	JSONResponseTimeout time.Duration
+	// HTTPMaxClientWait caps the deadline a client can ask for with a "Prefer: wait=<seconds>"
+	// request header; 0 ignores the header (CT_HTTP_MAX_CLIENT_WAIT).
+	HTTPMaxClientWait time.Duration
+	// HTTPStreamFlushInterval flushes responses every this many bytes written; 0 leaves
+	// flushing to net/http (CT_HTTP_STREAM_FLUSH_INTERVAL).
+	HTTPStreamFlushInterval int
+	// HTTPMaxHeaderCount caps the number of request header lines; 0 means unlimited
+	// (CT_HTTP_MAX_HEADER_COUNT).
+	HTTPMaxHeaderCount int
+
+	// HTTPDefaultCacheControl is the Cache-Control for non-error responses that do not set
+	// their own (CT_HTTP_DEFAULT_CACHE_CONTROL); empty leaves them without one.
+	HTTPDefaultCacheControl string
+
+	// HTTPTLSCertFile and HTTPTLSKeyFile enable native TLS on the main listener
+	// (CT_HTTP_TLS_CERT_FILE, CT_HTTP_TLS_KEY_FILE); both or neither must be set.
+	HTTPTLSCertFile string
+	HTTPTLSKeyFile  string
+	// HTTPTLSMinVersion is the minimum TLS version (CT_HTTP_TLS_MIN_VERSION); zero means
+	// TLS 1.2. With TLS 1.3 the server is TLS 1.3 only.
+	HTTPTLSMinVersion uint16
+	// HTTPTLSClientCAFile is a PEM bundle of CAs for client certificates; when set, admin
+	// endpoints require a verified client certificate (CT_HTTP_TLS_CLIENT_CA).
+	HTTPTLSClientCAFile string
+
+	// HTTP2H2C serves unencrypted HTTP/2 (h2c) alongside HTTP/1.1 on the main listener
+	// (CT_HTTP2_H2C).
+	HTTP2H2C bool
+	// HTTPNetwork is the main listener's network: tcp (dual-stack), tcp4 or tcp6
+	// (CT_HTTP_NETWORK).
+	HTTPNetwork string
 
 	HTTPTrustedSources []netip.Prefix
+
+	// RequireTLSOrTrustedProxy refuses to start unless TLS or HTTPTrustedSources is
+	// configured (CT_REQUIRE_TLS_OR_TRUSTED_PROXY).
+	RequireTLSOrTrustedProxy bool
+
+	// HTTPBlockedUserAgents are the compiled CT_HTTP_BLOCKED_USER_AGENTS patterns; a
+	// request whose User-Agent matches any of them gets a 403.
+	HTTPBlockedUserAgents []*regexp.Regexp
+
+	// HTTPNotFoundBody and HTTPNotFoundContentType customize 404 responses.
+	// Empty values keep http.NotFound's default body and content type.
+	HTTPNotFoundBody        string
+	HTTPNotFoundContentType string
+
+	MaxLogNameLength int
+
+	// MaxZipPartsPerLog caps the NNN.zip parts discovered per log; the lowest indices
+	// are kept (CT_MAX_ZIP_PARTS_PER_LOG). Values <= 0 disable the cap.
+	MaxZipPartsPerLog int
+
+	// ServeWellKnown answers /favicon.ico (204) and /robots.txt (RobotsTXT) instead of
+	// 404 (CT_SERVE_WELLKNOWN).
+	ServeWellKnown bool
+	// RobotsTXT is the /robots.txt body; empty means DefaultRobotsTXT (CT_ROBOTS_TXT).
+	RobotsTXT string
+
+	// RootRedirect is the target of a 302 from GET /, an absolute path or http(s) URL;
+	// empty leaves / a 404 (CT_ROOT_REDIRECT).
+	RootRedirect string
+
+	// EnableIssuerListing serves /<log>/issuers.json (CT_ENABLE_ISSUER_LISTING).
+	EnableIssuerListing bool
+
+	// IssuerReadConcurrency caps concurrent /<log>/issuer/<fp> reads; further requests get
+	// 503 with Retry-After. 0 means no limit (CT_ISSUER_READ_CONCURRENCY).
+	IssuerReadConcurrency int
+
+	// EnableBulkDownload serves /<log>/download.tar (CT_ENABLE_BULK_DOWNLOAD).
+	EnableBulkDownload bool
+	// BulkDownloadConcurrency caps concurrent /<log>/download.tar streams; further
+	// requests get 503 with Retry-After (CT_BULK_DOWNLOAD_CONCURRENCY).
+	BulkDownloadConcurrency int
+
+	// HeavyOpConcurrency caps bulk downloads, issuer listings and zip part manifests
+	// together; further requests get 503 with Retry-After. 0 means no limit
+	// (CT_HEAVY_OP_CONCURRENCY).
+	HeavyOpConcurrency int
+
+	// UpstreamBaseURL is another ct-archive-serve instance that serves checkpoints, tiles
+	// and issuers of logs missing from the local archive index; empty disables it
+	// (CT_UPSTREAM_BASE_URL). Each fetch is bounded by UpstreamTimeout (CT_UPSTREAM_TIMEOUT).
+	UpstreamBaseURL string
+	UpstreamTimeout time.Duration
+
+	// ReadyMinLogs is the number of discovered logs required before /readyz reports
+	// ready (CT_READY_MIN_LOGS).
+	ReadyMinLogs int
+	// MinFreeDiskBytes makes /readyz report not ready while the archive filesystem has
+	// less free space than this; 0 disables the check (CT_MIN_FREE_DISK_BYTES).
+	MinFreeDiskBytes uint64
+	// ArchiveProbeInterval runs an archive mount probe this often, feeding /readyz; 0
+	// disables it (CT_ARCHIVE_PROBE_INTERVAL). Each probe is bounded by
+	// ArchiveProbeTimeout (CT_ARCHIVE_PROBE_TIMEOUT) and reads ArchiveProbeFile, relative
+	// to ArchivePath, instead of stating ArchivePath when set (CT_ARCHIVE_PROBE_FILE).
+	ArchiveProbeInterval time.Duration
+	ArchiveProbeTimeout  time.Duration
+	ArchiveProbeFile     string
+
+	// AdminToken enables admin/debug endpoints when non-empty. Requests must send
+	// "Authorization: Bearer <AdminToken>".
+	AdminToken string
+
+	MetricsSummaries bool
+
+	// MetricsMaxLogSeries caps the logs with per-log request series; 0 means no cap
+	// (CT_METRICS_MAX_LOG_SERIES).
+	MetricsMaxLogSeries int
+
+	// MetricsExemplars attaches trace ID exemplars from the request's traceparent header
+	// to the request duration histograms (CT_METRICS_EXEMPLARS).
+	MetricsExemplars bool
+
+	// MetricsRuntime exports the standard go_* and process_* metrics (CT_METRICS_RUNTIME).
+	MetricsRuntime bool
+
+	// StartupSelfTest serves a known log end-to-end before listening (CT_STARTUP_SELFTEST).
+	StartupSelfTest bool
 }
 
+// DefaultCheckpointEntryName is the default zip entry name for checkpoints
+// (CT_CHECKPOINT_ENTRY_NAME).
+const DefaultCheckpointEntryName = "checkpoint"
+
+// DefaultCheckpointLongPollMaxWaiters is the default CT_CHECKPOINT_LONGPOLL_MAX_WAITERS.
+const DefaultCheckpointLongPollMaxWaiters = 1024
+
+// DefaultEntryCacheFillConcurrency is the default CT_ENTRY_CACHE_FILL_CONCURRENCY. With
+// full-size tiles of a few KiB to a few hundred KiB this bounds transient fill memory to
+// tens of MiB.
+const DefaultEntryCacheFillConcurrency = 64
+
+// DefaultMaxZipPartsPerLog is the default CT_MAX_ZIP_PARTS_PER_LOG. It matches the
+// NNN.zip namespace (000-999), so the cap only takes effect when lowered.
+const DefaultMaxZipPartsPerLog = 1000
+
+// DefaultRobotsTXT is served at /robots.txt when CT_SERVE_WELLKNOWN is enabled and
+// CT_ROBOTS_TXT is unset. It asks all crawlers to stay away.
+const DefaultRobotsTXT = "User-agent: *\nDisallow: /\n"
+
 type envLookup func(key string) (string, bool)
 
 // LoadConfig loads configuration from environment variables.
@@ -71,17 +378,39 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 	cfg := Config{
 		ArchivePath:          "/var/log/ct/archive",
 		ArchiveFolderPattern: "ct_*",
+		CheckpointEntryName:  DefaultCheckpointEntryName,
+		ArchiveCollisionPolicy: ArchiveCollisionFail,
+		ArchiveNestedLogNames:  ArchiveNestedLogNamesFolder,
+		MetadataSource:         MetadataSourceAuto,
+		CheckpointLongPollMaxWait:    30 * time.Second,
+		CheckpointLongPollMaxWaiters: DefaultCheckpointLongPollMaxWaiters,
 		LogListV3JSONRefreshInterval: 10 * time.Minute,
 		ArchiveRefreshInterval:     5 * time.Minute,
+		ArchiveSnapshotExportInterval: 5 * time.Minute,
 		ZipCacheMaxOpen:            2048,
 		ZipCacheMaxConcurrentOpens: 64,
+		ZipEntryFailureThreshold:   DefaultZipEntryFailureThreshold,
+		ZstdZipPartMaxBytes:        DefaultZstdZipPartMaxBytes,
 		ZipIntegrityFailTTL:        5 * time.Minute,
+		LogCircuitWindow:           time.Minute,
+		LogCircuitCooldown:         time.Minute,
 		EntryContentCacheMaxBytes:  256 * 1024 * 1024, // 256 MiB default
+		EntryCacheFillConcurrency:  DefaultEntryCacheFillConcurrency,
 		HTTPReadHeaderTimeout:      5 * time.Second,
 		HTTPIdleTimeout:            60 * time.Second,
 		HTTPMaxHeaderBytes:         8192,
 		HTTPWriteTimeout:           60 * time.Second,
 		HTTPReadTimeout:            0,
+		HTTPStreamTimeout:          0,
+		MaxLogNameLength:           DefaultMaxLogNameLength,
+		MaxZipPartsPerLog:          DefaultMaxZipPartsPerLog,
+		MetricsRuntime:             true,
+		HTTPTLSMinVersion:          tls.VersionTLS12,
+		BulkDownloadConcurrency:    DefaultBulkDownloadConcurrency,
+		TileHashBytes:              DefaultTileHashBytes,
+		HTTPNetwork:                HTTPNetworkTCP,
+		ArchiveProbeTimeout:        5 * time.Second,
+		UpstreamTimeout:            DefaultUpstreamTimeout,
 	}
 
 	if v, ok := lookup("CT_ARCHIVE_PATH"); ok && v != "" {
@@ -95,12 +424,231 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.ArchiveFolderPattern = v
 	}
 
-	prefix, err := parseArchiveFolderPrefix(cfg.ArchiveFolderPattern)
+	if v, ok := lookup("CT_ARCHIVE_IMMUTABLE"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_IMMUTABLE: %w", err)
+		}
+		cfg.ArchiveImmutable = b
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_FOLLOW_SYMLINKS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_FOLLOW_SYMLINKS: %w", err)
+		}
+		cfg.ArchiveFollowSymlinks = b
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_COLLISION_POLICY"); ok && v != "" {
+		switch v {
+		case ArchiveCollisionFail, ArchiveCollisionSkip:
+			cfg.ArchiveCollisionPolicy = v
+		default:
+			return Config{}, fmt.Errorf("CT_ARCHIVE_COLLISION_POLICY: unsupported policy %q (want %s or %s)", v, ArchiveCollisionFail, ArchiveCollisionSkip)
+		}
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_NESTED_DEPTH"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_NESTED_DEPTH: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_ARCHIVE_NESTED_DEPTH: must be >= 0 (0 is a flat archive)")
+		}
+		cfg.ArchiveNestedDepth = n
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_NESTED_LOG_NAMES"); ok && v != "" {
+		switch v {
+		case ArchiveNestedLogNamesFolder, ArchiveNestedLogNamesPath:
+			cfg.ArchiveNestedLogNames = v
+		default:
+			return Config{}, fmt.Errorf("CT_ARCHIVE_NESTED_LOG_NAMES: unsupported value %q (want %s or %s)", v, ArchiveNestedLogNamesFolder, ArchiveNestedLogNamesPath)
+		}
+	}
+
+	if v, ok := lookup("CT_METADATA_SOURCE"); ok && v != "" {
+		switch v {
+		case MetadataSourceAuto, MetadataSourceFile, MetadataSourceZip:
+			cfg.MetadataSource = v
+		default:
+			return Config{}, fmt.Errorf("CT_METADATA_SOURCE: unsupported source %q (want %s, %s or %s)", v, MetadataSourceAuto, MetadataSourceFile, MetadataSourceZip)
+		}
+	}
+
+	if v, ok := lookup("CT_LOG_ALLOWLIST"); ok {
+		globs, err := parseLogGlobsCSV(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_LOG_ALLOWLIST: %w", err)
+		}
+		cfg.LogAllowlist = globs
+	}
+
+	if v, ok := lookup("CT_LOG_DENYLIST"); ok {
+		globs, err := parseLogGlobsCSV(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_LOG_DENYLIST: %w", err)
+		}
+		cfg.LogDenylist = globs
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_EXCLUDE_FOLDERS"); ok {
+		globs, err := parseLogGlobsCSV(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_EXCLUDE_FOLDERS: %w", err)
+		}
+		cfg.ArchiveExcludeFolders = globs
+	}
+
+	if v, ok := lookup("CT_RETIRED_LOGS"); ok {
+		globs, err := parseLogGlobsCSV(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_RETIRED_LOGS: %w", err)
+		}
+		cfg.RetiredLogs = globs
+	}
+
+	prefix, suffix, err := parseArchiveFolderPattern(cfg.ArchiveFolderPattern)
 	if err != nil {
 		return Config{}, fmt.Errorf("CT_ARCHIVE_FOLDER_PATTERN: %w", err)
 	}
 	cfg.ArchiveFolderPrefix = prefix
+	cfg.ArchiveFolderSuffix = suffix
+
+	if v, ok := lookup("CT_CHECKPOINT_ENTRY_NAME"); ok {
+		if v == "" {
+			return Config{}, errors.New("CT_CHECKPOINT_ENTRY_NAME: empty value is invalid")
+		}
+		if strings.ContainsAny(v, `/\`) {
+			return Config{}, errors.New("CT_CHECKPOINT_ENTRY_NAME: must not contain slashes")
+		}
+		cfg.CheckpointEntryName = v
+	}
+
+	if v, ok := lookup("CT_ZIP_ENTRY_PREFIX"); ok && v != "" {
+		prefix, err := parseZipEntryPrefix(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ZIP_ENTRY_PREFIX: %w", err)
+		}
+		cfg.ZipEntryPrefix = prefix
+	}
+
+	if v, ok := lookup("CT_VALIDATE_TILE_SIZE"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_VALIDATE_TILE_SIZE: %w", err)
+		}
+		cfg.ValidateTileSize = b
+	}
+
+	if v, ok := lookup("CT_VALIDATE_DATA_TILES"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_VALIDATE_DATA_TILES: %w", err)
+		}
+		cfg.ValidateDataTiles = b
+	}
+
+	if v, ok := lookup("CT_TILE_ALLOW_UPPERCASE_X"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_TILE_ALLOW_UPPERCASE_X: %w", err)
+		}
+		cfg.TileAllowUppercaseX = b
+	}
+
+	if v, ok := lookup("CT_ALLOW_PERCENT_ENCODING"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ALLOW_PERCENT_ENCODING: %w", err)
+		}
+		cfg.AllowPercentEncoding = b
+	}
+
+	if v, ok := lookup("CT_ENTRY_METADATA_HEADERS"); ok && v != "" {
+		for _, raw := range strings.Split(v, ",") {
+			name := http.CanonicalHeaderKey(strings.TrimSpace(raw))
+			switch name {
+			case "":
+				continue
+			case headerLastModified, headerArchiveEntryComment:
+				if !slices.Contains(cfg.EntryMetadataHeaders, name) {
+					cfg.EntryMetadataHeaders = append(cfg.EntryMetadataHeaders, name)
+				}
+			default:
+				return Config{}, fmt.Errorf("CT_ENTRY_METADATA_HEADERS: unsupported header %q (want %s or %s)", name, headerLastModified, headerArchiveEntryComment)
+			}
+		}
+	}
+
+	if v, ok := lookup("CT_EMIT_LINK_HEADERS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_EMIT_LINK_HEADERS: %w", err)
+		}
+		cfg.EmitLinkHeaders = b
+	}
+
+	if v, ok := lookup("CT_EMIT_REFRESH_HEADERS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_EMIT_REFRESH_HEADERS: %w", err)
+		}
+		cfg.EmitRefreshHeaders = b
+	}
+
+	if v, ok := lookup("CT_PARTIAL_FROM_FULL"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_PARTIAL_FROM_FULL: %w", err)
+		}
+		cfg.PartialFromFull = b
+	}
+
+	if v, ok := lookup("CT_TILE_HASH_BYTES"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_TILE_HASH_BYTES: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_TILE_HASH_BYTES: must be > 0")
+		}
+		cfg.TileHashBytes = n
+	}
+
+	if v, ok := lookup("CT_CHECKPOINT_LONGPOLL_MAX_WAIT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_CHECKPOINT_LONGPOLL_MAX_WAIT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_CHECKPOINT_LONGPOLL_MAX_WAIT: must be >= 0")
+		}
+		cfg.CheckpointLongPollMaxWait = d
+	}
 
+	if v, ok := lookup("CT_CHECKPOINT_LONGPOLL_MAX_WAITERS"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_CHECKPOINT_LONGPOLL_MAX_WAITERS: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_CHECKPOINT_LONGPOLL_MAX_WAITERS: must be > 0")
+		}
+		cfg.CheckpointLongPollMaxWaiters = n
+	}
+
+	if v, ok := lookup("CT_CHECKPOINT_HEADERS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_CHECKPOINT_HEADERS: %w", err)
+		}
+		cfg.CheckpointHeaders = b
+	}
+
+	logListV3JSONIntervalSet := false
 	if v, ok := lookup("CT_LOGLISTV3_JSON_REFRESH_INTERVAL"); ok && v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil {
@@ -110,6 +658,63 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 			return Config{}, errors.New("CT_LOGLISTV3_JSON_REFRESH_INTERVAL: must be > 0")
 		}
 		cfg.LogListV3JSONRefreshInterval = d
+		logListV3JSONIntervalSet = true
+	}
+
+	if v, ok := lookup("CT_MONITOR_JSON_ALIAS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_MONITOR_JSON_ALIAS: %w", err)
+		}
+		cfg.MonitorJSONAlias = b
+	}
+
+	// Legacy name from when the endpoint was /monitor.json, only read with the alias
+	// enabled. Both endpoints are served from one snapshot, so if both variables are set
+	// the more frequent interval wins.
+	if v, ok := lookup("CT_MONITOR_JSON_REFRESH_INTERVAL"); ok && v != "" && cfg.MonitorJSONAlias {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_MONITOR_JSON_REFRESH_INTERVAL: %w", err)
+		}
+		if d <= 0 {
+			return Config{}, errors.New("CT_MONITOR_JSON_REFRESH_INTERVAL: must be > 0")
+		}
+		if !logListV3JSONIntervalSet || d < cfg.LogListV3JSONRefreshInterval {
+			cfg.LogListV3JSONRefreshInterval = d
+		}
+	}
+
+	if v, ok := lookup("CT_ENABLE_LOGLISTV3_JSON"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ENABLE_LOGLISTV3_JSON: %w", err)
+		}
+		cfg.DisableLogListV3JSON = !b
+	}
+
+	if v, ok := lookup("CT_OPERATOR_MAP"); ok {
+		cfg.OperatorMapFile = strings.TrimSpace(v)
+	}
+
+	if v, ok := lookup("CT_LOGLISTV3_JSON_STATIC_GZ"); ok {
+		cfg.LogListV3JSONStaticGz = strings.TrimSpace(v)
+	}
+
+	if v, ok := lookup("CT_LOGLISTV3_JSON_ETAG"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_LOGLISTV3_JSON_ETAG: %w", err)
+		}
+		cfg.LogListV3JSONETag = b
+	}
+
+	if v, ok := lookup("CT_HTTP_BROTLI"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_BROTLI: %w", err)
+		}
+		cfg.HTTPBrotli = b
 	}
 
 	if v, ok := lookup("CT_ARCHIVE_REFRESH_INTERVAL"); ok && v != "" {
@@ -123,6 +728,47 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.ArchiveRefreshInterval = d
 	}
 
+	if v, ok := lookup("CT_ARCHIVE_REFRESH_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_REFRESH_TIMEOUT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_ARCHIVE_REFRESH_TIMEOUT: must be >= 0 (0 disables)")
+		}
+		cfg.ArchiveRefreshTimeout = d
+	}
+
+	if v, ok := lookup("CT_NEW_LOG_GRACE_PERIOD"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_NEW_LOG_GRACE_PERIOD: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_NEW_LOG_GRACE_PERIOD: must be >= 0 (0 disables)")
+		}
+		cfg.NewLogGracePeriod = d
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_SNAPSHOT_EXPORT_PATH"); ok && v != "" {
+		cfg.ArchiveSnapshotExportPath = v
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_SNAPSHOT_EXPORT_INTERVAL"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_SNAPSHOT_EXPORT_INTERVAL: %w", err)
+		}
+		if d <= 0 {
+			return Config{}, errors.New("CT_ARCHIVE_SNAPSHOT_EXPORT_INTERVAL: must be > 0")
+		}
+		cfg.ArchiveSnapshotExportInterval = d
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_SNAPSHOT_SEED_PATH"); ok && v != "" {
+		cfg.ArchiveSnapshotSeedPath = v
+	}
+
 	if v, ok := lookup("CT_ZIP_CACHE_MAX_OPEN"); ok && v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil {
@@ -145,6 +791,36 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.ZipCacheMaxConcurrentOpens = n
 	}
 
+	if v, ok := lookup("CT_ZIP_ENTRY_FAILURE_THRESHOLD"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ZIP_ENTRY_FAILURE_THRESHOLD: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_ZIP_ENTRY_FAILURE_THRESHOLD: must be > 0")
+		}
+		cfg.ZipEntryFailureThreshold = n
+	}
+
+	if v, ok := lookup("CT_ZSTD_ZIP_PART_MAX_BYTES"); ok && v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ZSTD_ZIP_PART_MAX_BYTES: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_ZSTD_ZIP_PART_MAX_BYTES: must be > 0")
+		}
+		cfg.ZstdZipPartMaxBytes = n
+	}
+
+	if v, ok := lookup("CT_PREOPEN_NEW_PARTS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_PREOPEN_NEW_PARTS: %w", err)
+		}
+		cfg.PreopenNewParts = b
+	}
+
 	if v, ok := lookup("CT_ENTRY_CACHE_MAX_BYTES"); ok && v != "" {
 		n, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
@@ -156,6 +832,44 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.EntryContentCacheMaxBytes = n
 	}
 
+	if v, ok := lookup("CT_ENTRY_CACHE_FILL_CONCURRENCY"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ENTRY_CACHE_FILL_CONCURRENCY: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_ENTRY_CACHE_FILL_CONCURRENCY: must be >= 0 (0 means no limit)")
+		}
+		cfg.EntryCacheFillConcurrency = n
+	}
+
+	if v, ok := lookup("CT_EMIT_CONTENT_HASH"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_EMIT_CONTENT_HASH: %w", err)
+		}
+		cfg.EmitContentHash = b
+	}
+
+	if v, ok := lookup("CT_ENTRY_CACHE_COMPRESS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ENTRY_CACHE_COMPRESS: %w", err)
+		}
+		cfg.EntryCacheCompress = b
+	}
+
+	if v, ok := lookup("CT_CACHE_STATS_INTERVAL"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_CACHE_STATS_INTERVAL: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_CACHE_STATS_INTERVAL: must be >= 0 (0 disables)")
+		}
+		cfg.CacheStatsInterval = d
+	}
+
 	if v, ok := lookup("CT_ZIP_INTEGRITY_FAIL_TTL"); ok && v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil {
@@ -167,6 +881,73 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.ZipIntegrityFailTTL = d
 	}
 
+	if v, ok := lookup("CT_ZIP_INTEGRITY_PASS_TTL"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ZIP_INTEGRITY_PASS_TTL: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_ZIP_INTEGRITY_PASS_TTL: must be >= 0 (0 means never)")
+		}
+		cfg.ZipIntegrityPassTTL = d
+	}
+
+	if v, ok := lookup("CT_ZIP_INTEGRITY_VERIFY_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ZIP_INTEGRITY_VERIFY_TIMEOUT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_ZIP_INTEGRITY_VERIFY_TIMEOUT: must be >= 0 (0 disables)")
+		}
+		cfg.ZipIntegrityVerifyTimeout = d
+	}
+
+	if v, ok := lookup("CT_ZIP_COMPLETE_MARKER"); ok {
+		cfg.ZipCompleteMarker = v
+	}
+
+	if v, ok := lookup("CT_ZIP_GROWTH_CHECK"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ZIP_GROWTH_CHECK: %w", err)
+		}
+		cfg.ZipGrowthCheck = b
+	}
+
+	if v, ok := lookup("CT_LOG_CIRCUIT_FAILURES"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_LOG_CIRCUIT_FAILURES: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_LOG_CIRCUIT_FAILURES: must be >= 0")
+		}
+		cfg.LogCircuitFailures = n
+	}
+
+	if v, ok := lookup("CT_LOG_CIRCUIT_WINDOW"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_LOG_CIRCUIT_WINDOW: %w", err)
+		}
+		if d <= 0 {
+			return Config{}, errors.New("CT_LOG_CIRCUIT_WINDOW: must be > 0")
+		}
+		cfg.LogCircuitWindow = d
+	}
+
+	if v, ok := lookup("CT_LOG_CIRCUIT_COOLDOWN"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_LOG_CIRCUIT_COOLDOWN: %w", err)
+		}
+		if d <= 0 {
+			return Config{}, errors.New("CT_LOG_CIRCUIT_COOLDOWN: must be > 0")
+		}
+		cfg.LogCircuitCooldown = d
+	}
+
 	if v, ok := lookup("CT_HTTP_READ_HEADER_TIMEOUT"); ok && v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil {
@@ -200,6 +981,17 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.HTTPMaxHeaderBytes = n
 	}
 
+	if v, ok := lookup("CT_HTTP_MAX_HEADER_COUNT"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_MAX_HEADER_COUNT: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_HTTP_MAX_HEADER_COUNT: must be >= 0 (0 means unlimited)")
+		}
+		cfg.HTTPMaxHeaderCount = n
+	}
+
 	if v, ok := lookup("CT_HTTP_WRITE_TIMEOUT"); ok && v != "" {
 		d, err := time.ParseDuration(v)
 		if err != nil {
@@ -222,6 +1014,110 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.HTTPReadTimeout = d
 	}
 
+	if v, ok := lookup("CT_HTTP_STREAM_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_STREAM_TIMEOUT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_HTTP_STREAM_TIMEOUT: must be >= 0")
+		}
+		cfg.HTTPStreamTimeout = d
+	}
+
+	if v, ok := lookup("CT_HTTP_CONTENT_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_CONTENT_TIMEOUT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_HTTP_CONTENT_TIMEOUT: must be >= 0")
+		}
+		cfg.HTTPContentTimeout = d
+	}
+
+	if v, ok := lookup("CT_JSON_RESPONSE_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_JSON_RESPONSE_TIMEOUT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_JSON_RESPONSE_TIMEOUT: must be >= 0 (0 disables)")
+		}
+		cfg.JSONResponseTimeout = d
+	}
+
+	if v, ok := lookup("CT_HTTP_MAX_CLIENT_WAIT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_MAX_CLIENT_WAIT: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_HTTP_MAX_CLIENT_WAIT: must be >= 0")
+		}
+		cfg.HTTPMaxClientWait = d
+	}
+
+	if v, ok := lookup("CT_HTTP_STREAM_FLUSH_INTERVAL"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_STREAM_FLUSH_INTERVAL: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_HTTP_STREAM_FLUSH_INTERVAL: must be >= 0 (0 disables)")
+		}
+		cfg.HTTPStreamFlushInterval = n
+	}
+
+	if v, ok := lookup("CT_HTTP_DEFAULT_CACHE_CONTROL"); ok {
+		if strings.ContainsAny(v, "\r\n") {
+			return Config{}, errors.New("CT_HTTP_DEFAULT_CACHE_CONTROL: must not contain line breaks")
+		}
+		cfg.HTTPDefaultCacheControl = strings.TrimSpace(v)
+	}
+
+	if v, ok := lookup("CT_HTTP_TLS_CERT_FILE"); ok {
+		cfg.HTTPTLSCertFile = v
+	}
+	if v, ok := lookup("CT_HTTP_TLS_KEY_FILE"); ok {
+		cfg.HTTPTLSKeyFile = v
+	}
+	if (cfg.HTTPTLSCertFile == "") != (cfg.HTTPTLSKeyFile == "") {
+		return Config{}, errors.New("CT_HTTP_TLS_CERT_FILE and CT_HTTP_TLS_KEY_FILE must be set together")
+	}
+
+	if v, ok := lookup("CT_HTTP_TLS_MIN_VERSION"); ok && v != "" {
+		ver, err := parseTLSVersion(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_TLS_MIN_VERSION: %w", err)
+		}
+		cfg.HTTPTLSMinVersion = ver
+	}
+
+	if v, ok := lookup("CT_HTTP_TLS_CLIENT_CA"); ok && v != "" {
+		if cfg.HTTPTLSCertFile == "" {
+			return Config{}, errors.New("CT_HTTP_TLS_CLIENT_CA: requires CT_HTTP_TLS_CERT_FILE and CT_HTTP_TLS_KEY_FILE")
+		}
+		cfg.HTTPTLSClientCAFile = v
+	}
+
+	if v, ok := lookup("CT_HTTP2_H2C"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP2_H2C: %w", err)
+		}
+		cfg.HTTP2H2C = b
+	}
+
+	if v, ok := lookup("CT_HTTP_NETWORK"); ok && v != "" {
+		switch v {
+		case HTTPNetworkTCP, HTTPNetworkTCP4, HTTPNetworkTCP6:
+			cfg.HTTPNetwork = v
+		default:
+			return Config{}, fmt.Errorf("CT_HTTP_NETWORK: unsupported network %q (want %s, %s or %s)", v, HTTPNetworkTCP, HTTPNetworkTCP4, HTTPNetworkTCP6)
+		}
+	}
+
 	if v, ok := lookup("CT_HTTP_TRUSTED_SOURCES"); ok {
 		ps, err := parseTrustedSourcesCSV(v)
 		if err != nil {
@@ -230,17 +1126,282 @@ func parseConfigFromLookup(lookup envLookup) (Config, error) {
 		cfg.HTTPTrustedSources = ps
 	}
 
+	if v, ok := lookup("CT_REQUIRE_TLS_OR_TRUSTED_PROXY"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_REQUIRE_TLS_OR_TRUSTED_PROXY: %w", err)
+		}
+		cfg.RequireTLSOrTrustedProxy = b
+	}
+	// A public deployment behind neither TLS nor a known proxy would hand untrusted clients
+	// plain-HTTP URLs; fail fast rather than serve them.
+	if cfg.RequireTLSOrTrustedProxy && cfg.HTTPTLSCertFile == "" && len(cfg.HTTPTrustedSources) == 0 {
+		return Config{}, errors.New("CT_REQUIRE_TLS_OR_TRUSTED_PROXY: neither TLS nor a trusted proxy is configured; " +
+			"set CT_HTTP_TLS_CERT_FILE and CT_HTTP_TLS_KEY_FILE, or CT_HTTP_TRUSTED_SOURCES to the reverse proxy's addresses")
+	}
+
+	if v, ok := lookup("CT_HTTP_BLOCKED_USER_AGENTS"); ok {
+		res, err := parseUserAgentPatternsCSV(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HTTP_BLOCKED_USER_AGENTS: %w", err)
+		}
+		cfg.HTTPBlockedUserAgents = res
+	}
+
+	if v, ok := lookup("CT_HTTP_NOT_FOUND_BODY"); ok {
+		cfg.HTTPNotFoundBody = v
+	}
+
+	if v, ok := lookup("CT_HTTP_NOT_FOUND_CONTENT_TYPE"); ok {
+		if strings.ContainsAny(v, "\r\n") {
+			return Config{}, errors.New("CT_HTTP_NOT_FOUND_CONTENT_TYPE: must not contain line breaks")
+		}
+		cfg.HTTPNotFoundContentType = strings.TrimSpace(v)
+	}
+
+	if v, ok := lookup("CT_SERVE_WELLKNOWN"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_SERVE_WELLKNOWN: %w", err)
+		}
+		cfg.ServeWellKnown = b
+	}
+
+	if v, ok := lookup("CT_ROBOTS_TXT"); ok {
+		// Environment variables rarely carry real newlines; accept "\n" escapes.
+		cfg.RobotsTXT = strings.ReplaceAll(v, `\n`, "\n")
+	}
+
+	if v, ok := lookup("CT_ROOT_REDIRECT"); ok && v != "" {
+		target, err := parseRootRedirect(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ROOT_REDIRECT: %w", err)
+		}
+		cfg.RootRedirect = target
+	}
+
+	if v, ok := lookup("CT_MAX_LOG_NAME_LENGTH"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_MAX_LOG_NAME_LENGTH: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_MAX_LOG_NAME_LENGTH: must be > 0")
+		}
+		cfg.MaxLogNameLength = n
+	}
+
+	if v, ok := lookup("CT_ENABLE_ISSUER_LISTING"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ENABLE_ISSUER_LISTING: %w", err)
+		}
+		cfg.EnableIssuerListing = b
+	}
+
+	if v, ok := lookup("CT_ISSUER_READ_CONCURRENCY"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ISSUER_READ_CONCURRENCY: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_ISSUER_READ_CONCURRENCY: must be >= 0 (0 means unlimited)")
+		}
+		cfg.IssuerReadConcurrency = n
+	}
+
+	if v, ok := lookup("CT_ENABLE_BULK_DOWNLOAD"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ENABLE_BULK_DOWNLOAD: %w", err)
+		}
+		cfg.EnableBulkDownload = b
+	}
+
+	if v, ok := lookup("CT_BULK_DOWNLOAD_CONCURRENCY"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_BULK_DOWNLOAD_CONCURRENCY: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_BULK_DOWNLOAD_CONCURRENCY: must be > 0")
+		}
+		cfg.BulkDownloadConcurrency = n
+	}
+
+	if v, ok := lookup("CT_HEAVY_OP_CONCURRENCY"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_HEAVY_OP_CONCURRENCY: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_HEAVY_OP_CONCURRENCY: must be >= 0 (0 means unlimited)")
+		}
+		cfg.HeavyOpConcurrency = n
+	}
+
+	if v, ok := lookup("CT_UPSTREAM_BASE_URL"); ok && v != "" {
+		base, err := parseUpstreamBaseURL(strings.TrimSpace(v))
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_UPSTREAM_BASE_URL: %w", err)
+		}
+		cfg.UpstreamBaseURL = base
+	}
+
+	if v, ok := lookup("CT_UPSTREAM_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_UPSTREAM_TIMEOUT: %w", err)
+		}
+		if d <= 0 {
+			return Config{}, errors.New("CT_UPSTREAM_TIMEOUT: must be > 0")
+		}
+		cfg.UpstreamTimeout = d
+	}
+
+	if v, ok := lookup("CT_READY_MIN_LOGS"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_READY_MIN_LOGS: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_READY_MIN_LOGS: must be >= 0")
+		}
+		cfg.ReadyMinLogs = n
+	}
+
+	if v, ok := lookup("CT_MIN_FREE_DISK_BYTES"); ok && v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_MIN_FREE_DISK_BYTES: %w", err)
+		}
+		cfg.MinFreeDiskBytes = n
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_PROBE_INTERVAL"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_PROBE_INTERVAL: %w", err)
+		}
+		if d < 0 {
+			return Config{}, errors.New("CT_ARCHIVE_PROBE_INTERVAL: must be >= 0 (0 disables)")
+		}
+		cfg.ArchiveProbeInterval = d
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_PROBE_TIMEOUT"); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_ARCHIVE_PROBE_TIMEOUT: %w", err)
+		}
+		if d <= 0 {
+			return Config{}, errors.New("CT_ARCHIVE_PROBE_TIMEOUT: must be > 0")
+		}
+		cfg.ArchiveProbeTimeout = d
+	}
+
+	if v, ok := lookup("CT_ARCHIVE_PROBE_FILE"); ok && v != "" {
+		if !filepath.IsLocal(v) {
+			return Config{}, errors.New("CT_ARCHIVE_PROBE_FILE: must be a relative path inside CT_ARCHIVE_PATH")
+		}
+		cfg.ArchiveProbeFile = v
+	}
+
+	if v, ok := lookup("CT_MAX_ZIP_PARTS_PER_LOG"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_MAX_ZIP_PARTS_PER_LOG: %w", err)
+		}
+		if n <= 0 {
+			return Config{}, errors.New("CT_MAX_ZIP_PARTS_PER_LOG: must be > 0")
+		}
+		cfg.MaxZipPartsPerLog = n
+	}
+
+	if v, ok := lookup("CT_ADMIN_TOKEN"); ok {
+		cfg.AdminToken = strings.TrimSpace(v)
+	}
+
+	if v, ok := lookup("CT_METRICS_SUMMARIES"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_METRICS_SUMMARIES: %w", err)
+		}
+		cfg.MetricsSummaries = b
+	}
+
+	if v, ok := lookup("CT_METRICS_MAX_LOG_SERIES"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_METRICS_MAX_LOG_SERIES: %w", err)
+		}
+		if n < 0 {
+			return Config{}, errors.New("CT_METRICS_MAX_LOG_SERIES: must be >= 0 (0 means no cap)")
+		}
+		cfg.MetricsMaxLogSeries = n
+	}
+
+	if v, ok := lookup("CT_METRICS_EXEMPLARS"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_METRICS_EXEMPLARS: %w", err)
+		}
+		cfg.MetricsExemplars = b
+	}
+
+	if v, ok := lookup("CT_METRICS_RUNTIME"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_METRICS_RUNTIME: %w", err)
+		}
+		cfg.MetricsRuntime = b
+	}
+
+	if v, ok := lookup("CT_STARTUP_SELFTEST"); ok && v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("CT_STARTUP_SELFTEST: %w", err)
+		}
+		cfg.StartupSelfTest = b
+	}
+
 	return cfg, nil
 }
 
-func parseArchiveFolderPrefix(pattern string) (string, error) {
-	if !strings.HasSuffix(pattern, "*") {
-		return "", errors.New("pattern must be of the form <prefix>* (missing trailing '*')")
-	}
+// parseArchiveFolderPattern splits a <prefix>*<suffix> pattern around its single '*'.
+// Either side may be empty, e.g. "ct_*", "*_ct" or "ct_*_v3".
+func parseArchiveFolderPattern(pattern string) (prefix, suffix string, err error) {
 	if strings.Count(pattern, "*") != 1 {
-		return "", errors.New("pattern must contain exactly one '*' and it must be the final character")
+		return "", "", errors.New("pattern must be of the form <prefix>*<suffix> with exactly one '*'")
+	}
+	prefix, suffix, _ = strings.Cut(pattern, "*")
+	return prefix, suffix, nil
+}
+
+// archiveFolderLogName returns the log name matched by the '*' of the folder pattern, or
+// false when folderName does not match it.
+func archiveFolderLogName(folderName, prefix, suffix string) (string, bool) {
+	if len(folderName) < len(prefix)+len(suffix) ||
+		!strings.HasPrefix(folderName, prefix) || !strings.HasSuffix(folderName, suffix) {
+		return "", false
+	}
+	return folderName[len(prefix) : len(folderName)-len(suffix)], true
+}
+
+// parseLogGlobsCSV parses a CSV of log names or path.Match patterns, skipping empty items.
+func parseLogGlobsCSV(csv string) ([]string, error) {
+	var out []string
+	for _, raw := range strings.Split(csv, ",") {
+		s := strings.TrimSpace(raw)
+		if s == "" {
+			continue
+		}
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+		}
+		out = append(out, s)
 	}
-	return strings.TrimSuffix(pattern, "*"), nil
+	return out, nil
 }
 
 func parseTrustedSourcesCSV(csv string) ([]netip.Prefix, error) {
@@ -276,3 +1437,29 @@ func parseTrustedSourcesCSV(csv string) ([]netip.Prefix, error) {
 	return out, nil
 }
 
+
+// parseZipEntryPrefix validates CT_ZIP_ENTRY_PREFIX and normalizes it to end in "/", so
+// "logdata" and "logdata/" both select entries such as "logdata/tile/0/000".
+func parseZipEntryPrefix(v string) (string, error) {
+	if strings.HasPrefix(v, "/") {
+		return "", errors.New("must not start with a slash")
+	}
+	if strings.Contains(v, `\`) {
+		return "", errors.New("must use forward slashes")
+	}
+	v = strings.TrimSuffix(v, "/")
+	for seg := range strings.SplitSeq(v, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid path segment %q", seg)
+		}
+	}
+	return v + "/", nil
+}
+
+// Redacted returns a copy of cfg with secrets zeroed, safe to log or expose on
+// /admin/config.json. File paths (e.g. CT_HTTP_TLS_KEY_FILE) are kept; only the values
+// that grant access are removed.
+func (cfg Config) Redacted() Config {
+	cfg.AdminToken = ""
+	return cfg
+}
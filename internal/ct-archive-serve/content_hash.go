@@ -0,0 +1,60 @@
+package ctarchiveserve
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+)
+
+// contentSHA256Header carries the base64 SHA-256 of a tile body (CT_EMIT_CONTENT_HASH).
+const contentSHA256Header = "X-Content-SHA256"
+
+// SetContentHash makes Put store a SHA-256 of each entry alongside its bytes, so it is
+// computed once per cache fill rather than per request. Must be called before use.
+func (c *EntryContentCache) SetContentHash(v bool) {
+	c.hashContent = v
+}
+
+// ContentSHA256 returns the SHA-256 stored for a cached entry. It reports false when the
+// entry is not cached or was cached without a hash. Unlike Get it does not count as a
+// hit or move the entry in the LRU order.
+func (c *EntryContentCache) ContentSHA256(zipPath, entryName string) ([sha256.Size]byte, bool) {
+	if c == nil || c.maxBytes() <= 0 {
+		return [sha256.Size]byte{}, false
+	}
+	key := compositeKey(zipPath, entryName)
+	shard := c.shardFor(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	elem, ok := shard.items[key]
+	if !ok {
+		return [sha256.Size]byte{}, false
+	}
+	item, _ := elem.Value.(*entryCacheItem) //nolint:errcheck // internal invariant: LRU list only contains *entryCacheItem
+	if item.sum == nil {
+		return [sha256.Size]byte{}, false
+	}
+	return *item.sum, true
+}
+
+// CachedContentSHA256 returns the SHA-256 of entryName of zipPath stored by the entry
+// content cache. It reports false when there is no entry cache or the entry is not in it.
+func (zr *ZipReader) CachedContentSHA256(zipPath, entryName string) ([sha256.Size]byte, bool) {
+	if zr == nil || zr.entryCache == nil {
+		return [sha256.Size]byte{}, false
+	}
+	return zr.entryCache.ContentSHA256(zipPath, entryName)
+}
+
+// setContentHash sets X-Content-SHA256 to the hash of the tile entry, when the entry
+// content cache holds one (CT_EMIT_CONTENT_HASH). Tiles streamed without being cached
+// (too large, or beyond CT_ENTRY_CACHE_FILL_CONCURRENCY) get no header rather than a
+// per-request hash. Like the ETag, it describes the whole tile, also for Range requests.
+func (s *Server) setContentHash(w http.ResponseWriter, zipPath, entryName string) {
+	if !s.cfg.EmitContentHash {
+		return
+	}
+	if sum, ok := s.zipReader.CachedContentSHA256(zipPath, entryName); ok {
+		w.Header().Set(contentSHA256Header, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+}
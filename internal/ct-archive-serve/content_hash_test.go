@@ -0,0 +1,76 @@
+package ctarchiveserve
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_ContentHash(t *testing.T) {
+	t.Parallel()
+
+	hashTile := make([]byte, fullTileWidth*tileHashSize)
+	for i := range hashTile {
+		hashTile[i] = byte(i)
+	}
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/0/000":    hashTile,
+		"tile/data/000": x509TileLeaf(1),
+	})
+
+	newServer := func(emit bool, entryCacheBytes int64) *Server {
+		cfg := Config{
+			ArchivePath:          root,
+			ArchiveFolderPattern: "ct_*",
+			ArchiveFolderPrefix:  "ct_",
+			EmitContentHash:      emit,
+		}
+		archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		zipPartCache := NewZipPartCache(16, nil, 4)
+		t.Cleanup(func() { _ = zipPartCache.Close() })
+		zr.SetZipPartCache(zipPartCache)
+		entryCache := NewEntryContentCache(entryCacheBytes, nil)
+		entryCache.SetContentHash(emit)
+		zr.SetEntryContentCache(entryCache)
+		return NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+	}
+
+	server := newServer(true, 1<<20)
+	for _, path := range []string{"/test_log/tile/0/000", "/test_log/tile/data/000"} {
+		// The first request fills the cache, the second is served from it.
+		for range 2 {
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+			}
+			sum := sha256.Sum256(w.Body.Bytes())
+			if got, want := w.Header().Get(contentSHA256Header), base64.StdEncoding.EncodeToString(sum[:]); got != want {
+				t.Errorf("GET %s %s = %q, want %q", path, contentSHA256Header, got, want)
+			}
+		}
+	}
+
+	// Without the option, or without an entry cache, there is no header.
+	for _, server := range []*Server{newServer(false, 1<<20), newServer(true, 0)} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/tile/0/000", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get(contentSHA256Header); got != "" {
+			t.Errorf("%s = %q, want unset", contentSHA256Header, got)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// hasContentTimeout reports whether CT_HTTP_CONTENT_TIMEOUT and Prefer: wait apply to the
+// request: archive content routes, except checkpoint long-polls, which wait on purpose.
+func (s *Server) hasContentTimeout(r *http.Request, route Route) bool {
+	switch route.Kind {
+	case RouteHashTile, RouteDataTile, RouteIssuer, RouteIssuerList, RouteLogV3JSON, RouteCheckpointWitnessed:
+		return true
+	case RouteCheckpoint:
+		return !s.isCheckpointLongPoll(r, route)
+	default:
+		return false
+	}
+}
+
+// contentTimeout returns the deadline for serving the request, or 0 for none: the shorter
+// of CT_HTTP_CONTENT_TIMEOUT and the client's Prefer: wait (see clientWait), or
+// CT_JSON_RESPONSE_TIMEOUT for the log list.
+func (s *Server) contentTimeout(r *http.Request, route Route) time.Duration {
+	if route.Kind == RouteLogListV3JSON {
+		return s.cfg.JSONResponseTimeout
+	}
+	if !s.hasContentTimeout(r, route) {
+		return 0
+	}
+	timeout := s.cfg.HTTPContentTimeout
+	if wait, ok := s.clientWait(r); ok && (timeout == 0 || wait < timeout) {
+		timeout = wait
+	}
+	return timeout
+}
+
+// serveWithContentTimeout dispatches route under timeout (see contentTimeout). The handler
+// runs in its own goroutine with a deadline on its context, so a read blocked in a
+// syscall (e.g. a failing disk) cannot hold the connection past the budget; the goroutine
+// itself finishes whenever the read returns.
+//
+// On timeout, a response with nothing written yet becomes a 503. It returns false if the
+// handler had already started the response, which the caller must then abort so the
+// client does not mistake a truncated body for a complete one.
+func (s *Server) serveWithContentTimeout(w http.ResponseWriter, r *http.Request, route Route, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	tw := newContentTimeoutWriter(w)
+	done := make(chan struct{})
+	panicChan := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			}
+		}()
+		s.dispatch(tw, r.WithContext(ctx), route)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		panic(p)
+	case <-done:
+		tw.finish()
+		return true
+	case <-ctx.Done():
+	}
+
+	// Prefer a handler that finished just as the deadline fired.
+	select {
+	case <-done:
+		tw.finish()
+		return true
+	default:
+	}
+
+	started := tw.stop()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// The client went away; there is no one left to answer.
+		if !started {
+			w.WriteHeader(statusClientClosedRequest)
+		}
+		return true
+	}
+	if s.logger != nil {
+		s.requestLogger(r).Warn("Content response exceeded its deadline",
+			"log", route.Log, "path", r.URL.Path, "timeout", timeout, "started", started)
+	}
+	if started {
+		return false
+	}
+	http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+	return true
+}
+
+// contentTimeoutWriter is the handler's side of serveWithContentTimeout. The handler gets
+// its own header map, copied to the real writer when the response starts, and writes are
+// serialized with stop so none reach the real writer after the timeout fired.
+type contentTimeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	stopped     bool
+}
+
+func newContentTimeoutWriter(w http.ResponseWriter) *contentTimeoutWriter {
+	return &contentTimeoutWriter{w: w, h: w.Header().Clone()}
+}
+
+func (tw *contentTimeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *contentTimeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.stopped || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *contentTimeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.stopped {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b) //nolint:wrapcheck // pass-through writer
+}
+
+func (tw *contentTimeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.copyHeaderLocked()
+	tw.w.WriteHeader(code)
+}
+
+func (tw *contentTimeoutWriter) copyHeaderLocked() {
+	dst := tw.w.Header()
+	clear(dst)
+	maps.Copy(dst, tw.h)
+}
+
+// finish is called once the handler returned. A handler that wrote nothing (e.g. HEAD)
+// still set headers for net/http's implicit 200.
+func (tw *contentTimeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.wroteHeader {
+		tw.copyHeaderLocked()
+	}
+}
+
+// stop rejects further handler writes and reports whether the response had started.
+func (tw *contentTimeoutWriter) stop() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.stopped = true
+	return tw.wroteHeader
+}
@@ -0,0 +1,163 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_ContentTimeout(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint": checkpointBody(1),
+		"tile/0/000": []byte("tile"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		HTTPContentTimeout:   50 * time.Millisecond,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	// The integrity check stands in for a read stuck on a bad disk: it ignores the
+	// request context and blocks until the test ends.
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	verify := func(string) error {
+		<-release
+		return nil
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, verify, nil))
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/tile/0/000", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("request took %v, want it bounded by the content timeout", elapsed)
+	}
+	if got := w.Header().Get(requestIDHeader); got == "" {
+		t.Errorf("%s header missing on timeout response", requestIDHeader)
+	}
+
+	// Routes outside the content budget are unaffected.
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /metrics status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestServer_ContentTimeoutNotReached(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint": checkpointBody(1),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		HTTPContentTimeout:   time.Minute,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Body.String(), string(checkpointBody(1)); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Cache-Control"); got != immutableCacheControl {
+		t.Errorf("Cache-Control = %q, want %q", got, immutableCacheControl)
+	}
+}
+
+func TestServer_JSONResponseTimeout(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint":  checkpointBody(1),
+		"log.v3.json": []byte(`{"description":"Test log","log_id":"aWQ=","key":"a2V5","mmd":86400}`),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		JSONResponseTimeout:  50 * time.Millisecond,
+		MonitorJSONAlias:     true,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+	builder.refreshOnce("http://placeholder")
+
+	// The encoder stands in for rendering a huge list under memory pressure; it blocks
+	// until released.
+	release := make(chan struct{})
+	released := false
+	t.Cleanup(func() {
+		if !released {
+			close(release)
+		}
+	})
+	encode := builder.encode
+	builder.encode = func(snap *LogListV3JSONSnapshot) ([]byte, error) {
+		<-release
+		return encode(snap)
+	}
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, builder)
+
+	for _, path := range []string{"/logs.v3.json", "/monitor.json"} {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusServiceUnavailable)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Fatalf("GET %s took %v, want it bounded by the JSON response timeout", path, elapsed)
+		}
+	}
+
+	// Once rendering is fast again the list is served.
+	close(release)
+	released = true
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /logs.v3.json after release status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package ctarchiveserve
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeDiskBytes returns the bytes available to unprivileged users on the filesystem
+// holding path.
+func freeDiskBytes(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	return st.Bavail * uint64(st.Bsize), nil //nolint:gosec // G115: block size is positive
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package ctarchiveserve
+
+import "errors"
+
+// freeDiskBytes is only implemented on Linux; elsewhere CT_MIN_FREE_DISK_BYTES has no
+// effect on readiness.
+func freeDiskBytes(string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
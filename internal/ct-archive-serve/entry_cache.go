@@ -2,6 +2,7 @@ package ctarchiveserve
 
 import (
 	"container/list"
+	"crypto/sha256"
 	"hash/fnv"
 	"strings"
 	"sync"
@@ -25,6 +26,11 @@ type EntryContentCache struct {
 	metrics   *Metrics
 	shards    []entryContentShard
 	numShards uint64
+
+	// hashContent stores a SHA-256 of each entry as it is cached (CT_EMIT_CONTENT_HASH).
+	hashContent bool
+	// compress stores entries compressed when worthwhile (CT_ENTRY_CACHE_COMPRESS).
+	compress bool
 }
 
 // entryContentShard is a single shard of the EntryContentCache.
@@ -38,8 +44,12 @@ type entryContentShard struct {
 
 // entryCacheItem is stored in the LRU list.
 type entryCacheItem struct {
-	key  string // composite key: zipPath + "\x00" + entryName
-	data []byte
+	key  string             // composite key: zipPath + "\x00" + entryName
+	data []byte             // as stored, counted against the budget; compressed when rawLen >= 0
+	sum  *[sha256.Size]byte // SHA-256 of the raw content; nil unless hashContent
+
+	// rawLen is the decompressed length of a compressed entry, or -1 if data is raw.
+	rawLen int
 }
 
 // NewEntryContentCache constructs a new sharded EntryContentCache.
@@ -117,15 +127,25 @@ func (c *EntryContentCache) Get(zipPath, entryName string) ([]byte, bool) {
 	// Promote to front under shard write lock.
 	shard.mu.Lock()
 	shard.lru.MoveToFront(elem)
+	item, _ := elem.Value.(*entryCacheItem) //nolint:errcheck // internal invariant: LRU list only contains *entryCacheItem
+	data, rawLen := item.data, item.rawLen
 	shard.mu.Unlock()
 
-	item, _ := elem.Value.(*entryCacheItem) //nolint:errcheck // internal invariant: LRU list only contains *entryCacheItem
+	if rawLen >= 0 {
+		var err error
+		if data, err = decompressEntry(data, rawLen); err != nil {
+			if c.metrics != nil {
+				c.metrics.IncEntryCacheMisses()
+			}
+			return nil, false
+		}
+	}
 
 	if c.metrics != nil {
 		c.metrics.IncEntryCacheHits()
 	}
 
-	return item.data, true
+	return data, true
 }
 
 // Put stores the decompressed content for the given zip entry.
@@ -140,6 +160,19 @@ func (c *EntryContentCache) Put(zipPath, entryName string, data []byte) {
 
 	key := compositeKey(zipPath, entryName)
 	shard := c.shardFor(key)
+
+	var sum *[sha256.Size]byte
+	if c.hashContent {
+		h := sha256.Sum256(data)
+		sum = &h
+	}
+
+	rawLen := -1
+	if c.compress {
+		if compressed, ok := compressEntry(data); ok {
+			data, rawLen = compressed, len(data)
+		}
+	}
 	size := int64(len(data))
 
 	if size > shard.maxBytes {
@@ -155,6 +188,8 @@ func (c *EntryContentCache) Put(zipPath, entryName string, data []byte) {
 		old, _ := elem.Value.(*entryCacheItem) //nolint:errcheck // internal invariant: LRU list only contains *entryCacheItem
 		shard.curBytes -= int64(len(old.data))
 		old.data = data
+		old.sum = sum
+		old.rawLen = rawLen
 		shard.curBytes += size
 		shard.lru.MoveToFront(elem)
 		evictShardUntilBudget(c, shard)
@@ -166,7 +201,7 @@ func (c *EntryContentCache) Put(zipPath, entryName string, data []byte) {
 		evictShardBack(c, shard)
 	}
 
-	item := &entryCacheItem{key: key, data: data}
+	item := &entryCacheItem{key: key, data: data, sum: sum, rawLen: rawLen}
 	elem := shard.lru.PushFront(item)
 	shard.items[key] = elem
 	shard.curBytes += size
@@ -248,6 +283,20 @@ func evictShardUntilBudget(c *EntryContentCache, shard *entryContentShard) {
 	}
 }
 
+// Stats returns the cached bytes and item count, taking each shard lock.
+func (c *EntryContentCache) Stats() (totalBytes int64, totalItems int) {
+	if c == nil {
+		return 0, 0
+	}
+	for i := range c.shards {
+		c.shards[i].mu.RLock()
+		totalBytes += c.shards[i].curBytes
+		totalItems += c.shards[i].lru.Len()
+		c.shards[i].mu.RUnlock()
+	}
+	return totalBytes, totalItems
+}
+
 // totals returns aggregate byte and item counts across all shards.
 // The values are approximate when called without holding all shard locks.
 func (c *EntryContentCache) totals() (totalBytes int64, totalItems int) {
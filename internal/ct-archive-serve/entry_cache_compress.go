@@ -0,0 +1,70 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"sync"
+)
+
+// entryCacheCompressMaxRatio is the largest compressed/raw size ratio at which an entry
+// is stored compressed (CT_ENTRY_CACHE_COMPRESS). Entries that shrink less, such as
+// hash tiles, are stored raw so Get does not pay to decompress them for little gain.
+const entryCacheCompressMaxRatio = 0.875
+
+var (
+	entryCacheFlateWriters = sync.Pool{New: func() any {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed) //nolint:errcheck // BestSpeed is a valid level
+		return w
+	}}
+	entryCacheFlateReaders sync.Pool
+)
+
+// SetCompress makes Put store entries deflate-compressed (at the fastest level) when
+// that clears entryCacheCompressMaxRatio, and Get decompress them, fitting more entries
+// in the budget at the cost of CPU. The budget counts the compressed size. Must be
+// called before use.
+func (c *EntryContentCache) SetCompress(v bool) {
+	c.compress = v
+}
+
+// compressEntry returns data compressed, or false when it does not compress well enough
+// to be worth storing that way.
+func compressEntry(data []byte) ([]byte, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	var buf bytes.Buffer
+	w, _ := entryCacheFlateWriters.Get().(*flate.Writer) //nolint:errcheck // pool only holds *flate.Writer
+	defer entryCacheFlateWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, false
+	}
+	if err := w.Close(); err != nil {
+		return nil, false
+	}
+	if float64(buf.Len()) > float64(len(data))*entryCacheCompressMaxRatio {
+		return nil, false
+	}
+	return bytes.Clone(buf.Bytes()), true
+}
+
+// decompressEntry inflates an entry stored by compressEntry into rawLen bytes.
+func decompressEntry(data []byte, rawLen int) ([]byte, error) {
+	src := bytes.NewReader(data)
+	r, ok := entryCacheFlateReaders.Get().(io.ReadCloser)
+	if rs, canReset := r.(flate.Resetter); ok && canReset {
+		if err := rs.Reset(src, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		r = flate.NewReader(src)
+	}
+	defer entryCacheFlateReaders.Put(r)
+	out := make([]byte, rawLen)
+	if _, err := io.ReadFull(r, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
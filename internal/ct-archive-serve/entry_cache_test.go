@@ -2,6 +2,7 @@ package ctarchiveserve
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"sync"
 	"testing"
@@ -131,6 +132,52 @@ func TestEntryContentCache_UpdateInPlace(t *testing.T) {
 	}
 }
 
+func TestEntryContentCache_Compress(t *testing.T) {
+	t.Parallel()
+
+	cache := NewEntryContentCache(1024*1024, nil)
+	cache.SetCompress(true)
+	cache.SetContentHash(true)
+
+	// A data tile of similar leaves compresses well.
+	compressible := bytes.Repeat([]byte("leaf with a mostly repeated body "), 128)
+	cache.Put("/archive/000.zip", "tile/data/x000/000", compressible)
+	stored, items := cache.Stats()
+	if items != 1 || stored <= 0 || float64(stored) > float64(len(compressible))*entryCacheCompressMaxRatio {
+		t.Fatalf("Stats() = (%d bytes, %d items), want 1 item compressed below %d bytes", stored, items, len(compressible))
+	}
+	got, ok := cache.Get("/archive/000.zip", "tile/data/x000/000")
+	if !ok || !bytes.Equal(got, compressible) {
+		t.Fatalf("Get() = (%d bytes, %v), want the original %d bytes", len(got), ok, len(compressible))
+	}
+	if sum, ok := cache.ContentSHA256("/archive/000.zip", "tile/data/x000/000"); !ok || sum != sha256.Sum256(compressible) {
+		t.Errorf("ContentSHA256() = (%x, %v), want the hash of the raw content", sum, ok)
+	}
+
+	// A hash tile is effectively random and is stored raw, at its full size.
+	var incompressible []byte
+	h := sha256.Sum256([]byte("seed"))
+	for range 64 {
+		incompressible = append(incompressible, h[:]...)
+		h = sha256.Sum256(h[:])
+	}
+	cache.Put("/archive/000.zip", "tile/0/000", incompressible)
+	after, _ := cache.Stats()
+	if got := after - stored; got != int64(len(incompressible)) {
+		t.Errorf("raw entry accounted as %d bytes, want %d", got, len(incompressible))
+	}
+	got, ok = cache.Get("/archive/000.zip", "tile/0/000")
+	if !ok || !bytes.Equal(got, incompressible) {
+		t.Fatalf("Get() raw entry = (%d bytes, %v), want the original %d bytes", len(got), ok, len(incompressible))
+	}
+
+	// Replacing a compressed entry with a raw one keeps the accounting exact.
+	cache.Put("/archive/000.zip", "tile/data/x000/000", incompressible)
+	if total, _ := cache.Stats(); total != 2*int64(len(incompressible)) {
+		t.Errorf("Stats() bytes after replace = %d, want %d", total, 2*len(incompressible))
+	}
+}
+
 func TestEntryContentCache_ConcurrentAccess(t *testing.T) {
 	t.Parallel()
 
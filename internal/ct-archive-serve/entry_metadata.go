@@ -0,0 +1,107 @@
+package ctarchiveserve
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Response headers CT_ENTRY_METADATA_HEADERS can forward from a zip entry's metadata.
+const (
+	headerLastModified        = "Last-Modified"           // the entry's modification time
+	headerArchiveEntryComment = "X-Archive-Entry-Comment" // the entry's comment, if any
+)
+
+// maxEntryCommentHeaderBytes bounds the X-Archive-Entry-Comment value; longer comments
+// are truncated.
+const maxEntryCommentHeaderBytes = 1024
+
+// ZipEntryInfo is the zip metadata of one entry.
+type ZipEntryInfo struct {
+	Modified time.Time
+	Comment  string
+}
+
+// EntryInfo returns the metadata of entryName in the zip part at zipPath. It is meant to
+// follow a successful OpenEntry of the same entry: it skips the integrity check and only
+// reads the central directory, from the zip part cache when available.
+func (zr *ZipReader) EntryInfo(ctx context.Context, zipPath, entryName string) (ZipEntryInfo, error) {
+	if zr == nil {
+		return ZipEntryInfo{}, errors.New("zip reader is nil")
+	}
+
+	var f *zip.File
+	if zr.cache != nil {
+		cacheEntry, err := zr.cache.Get(ctx, zipPath)
+		if err != nil {
+			return ZipEntryInfo{}, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+		}
+		f = cacheEntry.index.Lookup(entryName)
+	} else {
+		zrdr, err := openZipPart(zipPath)
+		if err != nil {
+			return ZipEntryInfo{}, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+		}
+		defer func() { _ = zrdr.Close() }()
+		f = findZipEntry(zrdr.File, entryName)
+	}
+	if f == nil {
+		return ZipEntryInfo{}, fmt.Errorf("%w: zip entry missing", ErrNotFound)
+	}
+	return ZipEntryInfo{Modified: f.Modified, Comment: f.Comment}, nil
+}
+
+// entryMetadata sets the CT_ENTRY_METADATA_HEADERS response headers for entryName and
+// returns the modification time to pass to http.ServeContent, which writes Last-Modified
+// and evaluates If-Modified-Since itself. It returns the zero time when Last-Modified is
+// not forwarded. Metadata is best effort: a lookup failure only drops the headers.
+func (s *Server) entryMetadata(w http.ResponseWriter, r *http.Request, zipPath, entryName string) time.Time {
+	if len(s.cfg.EntryMetadataHeaders) == 0 {
+		return time.Time{}
+	}
+	info, err := s.zipReader.EntryInfo(r.Context(), zipPath, entryName)
+	if err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Debug("Failed to read zip entry metadata", "zip_path", zipPath, "entry", entryName, "error", err)
+		}
+		return time.Time{}
+	}
+	if slices.Contains(s.cfg.EntryMetadataHeaders, headerArchiveEntryComment) {
+		if comment := sanitizeHeaderValue(info.Comment, maxEntryCommentHeaderBytes); comment != "" {
+			w.Header().Set(headerArchiveEntryComment, comment)
+		}
+	}
+	if slices.Contains(s.cfg.EntryMetadataHeaders, headerLastModified) {
+		return info.Modified
+	}
+	return time.Time{}
+}
+
+// sanitizeHeaderValue drops control characters from v, which are not allowed in header
+// values, trims it and truncates it to at most maxBytes bytes on a rune boundary.
+func sanitizeHeaderValue(v string, maxBytes int) string {
+	v = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, v))
+	if len(v) <= maxBytes {
+		return v
+	}
+	cut := 0
+	for i := range v {
+		if i > maxBytes {
+			break
+		}
+		cut = i
+	}
+	return v[:cut]
+}
@@ -0,0 +1,106 @@
+package ctarchiveserve
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// mustCreateZipWithEntryComment writes a zip holding one entry with the given
+// modification time and comment.
+func mustCreateZipWithEntryComment(t *testing.T, path, name string, data []byte, modified time.Time, comment string) {
+	t.Helper()
+
+	//nolint:gosec // G304: path is validated and comes from test helpers, not user input
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create(%q) error = %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	w := zip.NewWriter(f)
+	fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified, Comment: comment})
+	if err != nil {
+		t.Fatalf("zip.CreateHeader(%q) error = %v", name, err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		t.Fatalf("zip write %q error = %v", name, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("zip.Close() error = %v", err)
+	}
+}
+
+func TestServer_EntryMetadataHeaders(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC)
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZipWithEntryComment(t, filepath.Join(logFolder, "000.zip"), "tile/0/000", make([]byte, 32),
+		modified, "archived by mirror-7\r\n")
+
+	newServer := func(headers []string) *Server {
+		cfg := Config{
+			ArchivePath:          root,
+			ArchiveFolderPattern: "ct_*",
+			ArchiveFolderPrefix:  "ct_",
+			EntryMetadataHeaders: headers,
+		}
+		metrics := NewMetrics(prometheus.NewRegistry())
+		archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+		return NewServer(cfg, NewLogger(LoggerOptions{}), metrics, archiveIndex, NewZipReader(zic), nil)
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Parallel()
+		server := newServer([]string{headerLastModified, headerArchiveEntryComment})
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/tile/0/000", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if got, want := w.Header().Get("Last-Modified"), modified.Format(http.TimeFormat); got != want {
+			t.Errorf("Last-Modified = %q, want %q", got, want)
+		}
+		if got, want := w.Header().Get("X-Archive-Entry-Comment"), "archived by mirror-7"; got != want {
+			t.Errorf("X-Archive-Entry-Comment = %q, want %q (control characters dropped)", got, want)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/test_log/tile/0/000", nil)
+		req.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("If-Modified-Since status = %d, want %d", w.Code, http.StatusNotModified)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		server := newServer(nil)
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/tile/0/000", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		for _, name := range []string{"Last-Modified", "X-Archive-Entry-Comment"} {
+			if got := w.Header().Get(name); got != "" {
+				t.Errorf("%s = %q, want unset", name, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package ctarchiveserve
+
+import "net/http"
+
+// heavyOpRetryAfterSeconds is the Retry-After sent when CT_HEAVY_OP_CONCURRENCY is reached.
+const heavyOpRetryAfterSeconds = "30"
+
+// acquireHeavyOp takes a slot from the shared heavy-operation budget
+// (CT_HEAVY_OP_CONCURRENCY) used by bulk downloads, issuer listings and zip part
+// manifests, so that a few of them cannot crowd out tile reads. When the budget is
+// exhausted it writes 503 with Retry-After and returns ok false. The returned release
+// must be called once the operation is done.
+func (s *Server) acquireHeavyOp(w http.ResponseWriter) (release func(), ok bool) {
+	if s.heavySem == nil {
+		return func() {}, true
+	}
+	if !s.heavySem.TryAcquire(1) {
+		w.Header().Set("Retry-After", heavyOpRetryAfterSeconds)
+		http.Error(w, "Too many heavy requests", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return func() { s.heavySem.Release(1) }, true
+}
@@ -0,0 +1,86 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_HeavyOpConcurrency(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint":    checkpointBody(2),
+		"log.v3.json":   []byte(`{"description":"test"}`),
+		"issuer/0a1b":   []byte("issuer"),
+		"tile/data/000": []byte("data tile 0"),
+	})
+
+	cfg := Config{
+		ArchivePath:             root,
+		ArchiveFolderPattern:    "ct_*",
+		ArchiveFolderPrefix:     "ct_",
+		AdminToken:              "secret",
+		EnableBulkDownload:      true,
+		BulkDownloadConcurrency: 2,
+		EnableIssuerListing:     true,
+		HeavyOpConcurrency:      1,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	cache := NewZipPartCache(16, nil, 4)
+	t.Cleanup(func() { _ = cache.Close() })
+	zr.SetZipPartCache(cache)
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	heavy := []string{
+		"/test_log/download.tar",
+		"/test_log/issuers.json",
+		"/test_log/parts/000/manifest.json",
+	}
+	for _, path := range heavy {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, adminRequest(http.MethodGet, path, "secret"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+	}
+
+	// The only heavy slot is taken, e.g. by a long bulk download.
+	if !server.heavySem.TryAcquire(1) {
+		t.Fatal("heavySem.TryAcquire() = false, want true")
+	}
+	for _, path := range heavy {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, adminRequest(http.MethodGet, path, "secret"))
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != heavyOpRetryAfterSeconds {
+			t.Errorf("busy GET %s: status = %d, Retry-After = %q; want %d, %q", path, w.Code, w.Header().Get("Retry-After"), http.StatusServiceUnavailable, heavyOpRetryAfterSeconds)
+		}
+	}
+
+	// Tile reads do not share the budget.
+	start := time.Now()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/tile/data/000", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("tile: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("tile read took %v while heavy ops were saturated", d)
+	}
+	server.heavySem.Release(1)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/issuers.json", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("released: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
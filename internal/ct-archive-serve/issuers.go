@@ -0,0 +1,73 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// issuerList is the JSON body of GET /<log>/issuers.json.
+type issuerList struct {
+	Log     string   `json:"log"`
+	Issuers []string `json:"issuers"`
+}
+
+// handleIssuerList serves GET /<log>/issuers.json (CT_ENABLE_ISSUER_LISTING), listing the
+// fingerprints of the issuer/<fp> entries in 000.zip, sorted. Logs without issuers
+// (has_issuers false) get an empty list. The entry list comes from the zip part cache,
+// so the part is not re-read per request.
+func (s *Server) handleIssuerList(w http.ResponseWriter, r *http.Request, route Route) {
+	if !s.cfg.EnableIssuerListing {
+		s.notFound(w, r)
+		return
+	}
+	if s.zipReader == nil || s.archiveIndex == nil {
+		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
+		return
+	}
+
+	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
+	if !ok {
+		s.notFound(w, r)
+		return
+	}
+
+	release, ok := s.acquireHeavyOp(w)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Issuers are in 000.zip
+	entries, err := s.zipReader.ListEntries(r.Context(), archiveLog.ZipPartPath(0))
+	if err != nil {
+		s.writeOpenEntryError(w, r, err)
+		return
+	}
+	s.zipReader.PinPart(archiveLog.ZipPartPath(0))
+	issuers := make([]string, 0)
+	for _, e := range entries {
+		fp, ok := strings.CutPrefix(normalizeZipEntryName(e.Name), s.zipEntryName("issuer/"))
+		if ok && isLowerHex(fp) {
+			issuers = append(issuers, fp)
+		}
+	}
+	sort.Strings(issuers)
+	// An exact and a backslash-separated entry may name the same issuer.
+	issuers = slices.Compact(issuers)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", immutableCacheControl)
+	s.setRefreshHeaders(w)
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+
+	if err := json.NewEncoder(w).Encode(issuerList{Log: route.Log, Issuers: issuers}); err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode issuer list", "log", route.Log, "error", err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newIssuerListTestServer(t *testing.T, enabled bool, files map[string][]byte) *Server {
+	t.Helper()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), files)
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		EnableIssuerListing:  enabled,
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	zr := NewZipReader(zic)
+	zr.SetZipPartCache(NewZipPartCache(16, metrics, 1))
+	return NewServer(cfg, logger, metrics, archiveIndex, zr, nil)
+}
+
+func getIssuerList(t *testing.T, server *Server) (int, issuerList) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/test_log/issuers.json", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	var got issuerList
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, got
+}
+
+func TestServer_HandleIssuerList(t *testing.T) {
+	t.Parallel()
+
+	server := newIssuerListTestServer(t, true, map[string][]byte{
+		"checkpoint":  []byte("checkpoint"),
+		"issuer/bb02": []byte("issuer b"),
+		"issuer/aa01": []byte("issuer a"),
+		"tile/0/x000": []byte("tile"),
+	})
+
+	code, got := getIssuerList(t, server)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	if want := []string{"aa01", "bb02"}; got.Log != "test_log" || !slices.Equal(got.Issuers, want) {
+		t.Fatalf("issuer list = %+v, want log test_log with %v", got, want)
+	}
+}
+
+func TestServer_HandleIssuerList_NoIssuers(t *testing.T) {
+	t.Parallel()
+
+	server := newIssuerListTestServer(t, true, map[string][]byte{"checkpoint": []byte("checkpoint")})
+
+	code, got := getIssuerList(t, server)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	if got.Issuers == nil || len(got.Issuers) != 0 {
+		t.Fatalf("issuers = %#v, want empty list", got.Issuers)
+	}
+}
+
+func TestServer_HandleIssuerList_Disabled(t *testing.T) {
+	t.Parallel()
+
+	server := newIssuerListTestServer(t, false, map[string][]byte{"issuer/aa01": []byte("issuer a")})
+
+	if code, _ := getIssuerList(t, server); code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestServer_IssuerReadConcurrency_SaturatedStillServesTiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"issuer/aa01": []byte("issuer a"),
+		"tile/0/000":  []byte("hash tile data"),
+	})
+
+	cfg := Config{
+		ArchivePath:           root,
+		ArchiveFolderPattern:  "ct_*",
+		ArchiveFolderPrefix:   "ct_",
+		IssuerReadConcurrency: 2,
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	zr.SetZipPartCache(NewZipPartCache(16, metrics, 1))
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Occupy every issuer slot, as a burst of in-flight issuer reads would.
+	if !server.issuerSem.TryAcquire(2) {
+		t.Fatalf("TryAcquire(2) = false, want true")
+	}
+
+	w := get("/test_log/issuer/aa01")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("saturated issuer status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != issuerRetryAfterSeconds {
+		t.Errorf("Retry-After = %q, want %q", got, issuerRetryAfterSeconds)
+	}
+	if w := get("/test_log/tile/0/000"); w.Code != http.StatusOK || w.Body.String() != "hash tile data" {
+		t.Fatalf("tile while issuers saturated: status = %d, body = %q; want 200 with tile data", w.Code, w.Body.String())
+	}
+
+	server.issuerSem.Release(2)
+	if w := get("/test_log/issuer/aa01"); w.Code != http.StatusOK || w.Body.String() != "issuer a" {
+		t.Errorf("issuer after release: status = %d, body = %q; want 200 with issuer data", w.Code, w.Body.String())
+	}
+}
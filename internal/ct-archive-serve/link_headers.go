@@ -0,0 +1,36 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"strings"
+)
+
+// setLinkHeaders adds RFC 8288 Link headers (CT_EMIT_LINK_HEADERS) pointing from a tile
+// to its log's checkpoint and log.v3.json, and from a checkpoint to /logs.v3.json when
+// that endpoint is enabled. URLs are absolute, built from derivePublicBaseURL like the
+// URLs in /logs.v3.json, so they respect CT_HTTP_TRUSTED_SOURCES.
+func (s *Server) setLinkHeaders(w http.ResponseWriter, r *http.Request, route Route) {
+	if !s.cfg.EmitLinkHeaders {
+		return
+	}
+
+	var paths []string
+	switch route.Kind {
+	case RouteHashTile, RouteDataTile:
+		paths = []string{"/" + route.Log + "/checkpoint", "/" + route.Log + "/" + logV3JSONFileName}
+	case RouteCheckpoint:
+		if !s.cfg.DisableLogListV3JSON {
+			paths = []string{"/logs.v3.json"}
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	base := s.derivePublicBaseURL(r)
+	links := make([]string, 0, len(paths))
+	for _, p := range paths {
+		links = append(links, "<"+base+p+`>; rel="related"`)
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,79 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_LinkHeaders(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint": checkpointBody(1),
+		"tile/0/000": make([]byte, 32),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		EmitLinkHeaders:      true,
+		HTTPTrustedSources:   []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), metrics, archiveIndex, NewZipReader(zic), nil)
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		want       string
+	}{
+		{
+			name:       "tile",
+			path:       "/test_log/tile/0/000",
+			remoteAddr: "192.0.2.1:1234",
+			want:       `<http://example.com/test_log/checkpoint>; rel="related", <http://example.com/test_log/log.v3.json>; rel="related"`,
+		},
+		{
+			name:       "tile via trusted proxy",
+			path:       "/test_log/tile/0/000",
+			remoteAddr: "10.0.0.1:1234",
+			want:       `<https://ct.example.org/test_log/checkpoint>; rel="related", <https://ct.example.org/test_log/log.v3.json>; rel="related"`,
+		},
+		{
+			name:       "checkpoint",
+			path:       "/test_log/checkpoint",
+			remoteAddr: "192.0.2.1:1234",
+			want:       `<http://example.com/logs.v3.json>; rel="related"`,
+		},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.RemoteAddr = tc.remoteAddr
+		req.Header.Set("X-Forwarded-Host", "ct.example.org")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", tc.name, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("Link"); got != tc.want {
+			t.Errorf("%s: Link = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
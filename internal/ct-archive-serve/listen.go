@@ -0,0 +1,28 @@
+package ctarchiveserve
+
+import (
+	"fmt"
+	"net"
+)
+
+// Listener networks accepted by CT_HTTP_NETWORK.
+const (
+	HTTPNetworkTCP  = "tcp"  // dual-stack where the system supports it
+	HTTPNetworkTCP4 = "tcp4" // IPv4 only
+	HTTPNetworkTCP6 = "tcp6" // IPv6 only
+)
+
+// Listen opens the main listener on addr with the CT_HTTP_NETWORK network, so operators
+// can force IPv4-only or IPv6-only binding instead of the implicit "tcp" of
+// http.Server.ListenAndServe.
+func Listen(cfg Config, addr string) (net.Listener, error) {
+	network := cfg.HTTPNetwork
+	if network == "" {
+		network = HTTPNetworkTCP
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s %s: %w", network, addr, err)
+	}
+	return ln, nil
+}
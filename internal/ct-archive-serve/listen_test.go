@@ -0,0 +1,30 @@
+package ctarchiveserve
+
+import (
+	"net"
+	"testing"
+)
+
+func TestListen_TCP4(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(Config{HTTPNetwork: HTTPNetworkTCP4}, ":0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	addr, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		t.Fatalf("Addr() = %T, want *net.TCPAddr", ln.Addr())
+	}
+	if addr.IP.To4() == nil {
+		t.Fatalf("Addr().IP = %v, want an IPv4 address for %s", addr.IP, HTTPNetworkTCP4)
+	}
+
+	// An IPv4-only listener cannot take an IPv6 address.
+	if ln6, err := Listen(Config{HTTPNetwork: HTTPNetworkTCP4}, "[::1]:0"); err == nil {
+		_ = ln6.Close()
+		t.Fatalf("Listen(%s, [::1]:0) error = nil, want an address family error", HTTPNetworkTCP4)
+	}
+}
@@ -0,0 +1,173 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// errLogCircuitOpen is wrapped in ErrZipTemporarilyUnavailable while a log's circuit
+// breaker is open.
+var errLogCircuitOpen = errors.New("log circuit breaker open")
+
+// logCircuit is a per-log circuit breaker over zip integrity checks
+// (CT_LOG_CIRCUIT_FAILURES). A log whose parts fail failures verifications in a row within
+// window is opened: its reads fail fast for cooldown without touching disk. After the
+// cooldown the log is half-open: reads go through again. A failed verification reopens
+// the breaker; a passed verification or the first entry read from one of the log's parts
+// closes it. Reads alone close it because they often need no verification at all, e.g.
+// of parts whose pass or failure is still cached.
+//
+// Logs are keyed by folder path, the directory of their zip parts. Only fresh
+// verifications count; cached pass or fail results are not re-counted.
+type logCircuit struct {
+	failures int
+	window   time.Duration
+	cooldown time.Duration
+	now      func() time.Time
+	metrics  *Metrics
+
+	mu       sync.RWMutex
+	logs     map[string]*logCircuitState
+	open     int
+	halfOpen int
+}
+
+type logCircuitState struct {
+	failures     int       // consecutive failures since firstFailure
+	firstFailure time.Time // start of the current failure window
+	openUntil    time.Time // non-zero while open
+	halfOpen     bool
+}
+
+func newLogCircuit(failures int, window, cooldown time.Duration, now func() time.Time, metrics *Metrics) *logCircuit {
+	return &logCircuit{
+		failures: failures,
+		window:   window,
+		cooldown: cooldown,
+		now:      now,
+		metrics:  metrics,
+		logs:     make(map[string]*logCircuitState),
+	}
+}
+
+// allow reports whether zip part zipPath may be read, i.e. its log's breaker is not open.
+func (c *logCircuit) allow(zipPath string) bool {
+	if c == nil {
+		return true
+	}
+	folder := filepath.Dir(zipPath)
+
+	c.mu.RLock()
+	st, ok := c.logs[folder]
+	open := ok && !st.openUntil.IsZero()
+	c.mu.RUnlock()
+	if !open {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if st.openUntil.IsZero() {
+		return true // moved to half-open meanwhile
+	}
+	if c.now().Before(st.openUntil) {
+		return false
+	}
+	st.openUntil = time.Time{}
+	st.halfOpen = true
+	c.open--
+	c.halfOpen++
+	c.updateMetricsLocked()
+	return true
+}
+
+// failure records a failed verification of zipPath.
+func (c *logCircuit) failure(zipPath string) {
+	if c == nil {
+		return
+	}
+	folder := filepath.Dir(zipPath)
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.logs[folder]
+	if !ok {
+		st = &logCircuitState{}
+		c.logs[folder] = st
+	}
+	trip := false
+	switch {
+	case !st.openUntil.IsZero():
+		return // already open, e.g. a verification that started before it tripped
+	case st.halfOpen:
+		st.halfOpen = false
+		c.halfOpen--
+		trip = true
+	case st.failures == 0 || now.Sub(st.firstFailure) > c.window:
+		st.failures = 1
+		st.firstFailure = now
+	default:
+		st.failures++
+	}
+	if !trip && st.failures < c.failures {
+		return
+	}
+	st.failures = 0
+	st.openUntil = now.Add(c.cooldown)
+	c.open++
+	c.updateMetricsLocked()
+}
+
+// success records a passed verification of zipPath, closing its log's breaker.
+func (c *logCircuit) success(zipPath string) {
+	if c == nil {
+		return
+	}
+	folder := filepath.Dir(zipPath)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.logs[folder]
+	if !ok || !st.openUntil.IsZero() {
+		return
+	}
+	if st.halfOpen {
+		c.halfOpen--
+		c.updateMetricsLocked()
+	}
+	delete(c.logs, folder)
+}
+
+// readSucceeded records that an entry of zipPath was read, closing its log's breaker if
+// it is half-open.
+func (c *logCircuit) readSucceeded(zipPath string) {
+	if c == nil {
+		return
+	}
+	folder := filepath.Dir(zipPath)
+
+	c.mu.RLock()
+	st, ok := c.logs[folder]
+	halfOpen := ok && st.halfOpen
+	c.mu.RUnlock()
+	if !halfOpen {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !st.halfOpen || c.logs[folder] != st {
+		return // closed or reopened meanwhile
+	}
+	st.halfOpen = false
+	c.halfOpen--
+	c.updateMetricsLocked()
+	delete(c.logs, folder)
+}
+
+func (c *logCircuit) updateMetricsLocked() {
+	c.metrics.SetLogCircuits(c.open, c.halfOpen)
+}
@@ -0,0 +1,259 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLogCircuit_TripAndReset(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	c := newLogCircuit(3, time.Minute, 30*time.Second, func() time.Time { return now }, nil)
+	part := func(i int) string { return fmt.Sprintf("/archive/ct_log/%03d.zip", i) }
+
+	// Failures spread wider than the window do not trip.
+	c.failure(part(0))
+	c.failure(part(1))
+	now = now.Add(2 * time.Minute)
+	c.failure(part(2))
+	if !c.allow(part(0)) {
+		t.Fatalf("allow() = false after failures outside the window")
+	}
+
+	// Three in a row across parts do.
+	c.failure(part(3))
+	c.failure(part(4))
+	if c.allow(part(7)) {
+		t.Fatalf("allow() = true after 3 consecutive failures")
+	}
+	if !c.allow("/archive/ct_other/000.zip") {
+		t.Fatalf("allow() = false for another log")
+	}
+
+	// Half-open after the cooldown; a failure reopens at once.
+	now = now.Add(31 * time.Second)
+	if !c.allow(part(0)) {
+		t.Fatalf("allow() = false after the cooldown")
+	}
+	c.failure(part(0))
+	if c.allow(part(0)) {
+		t.Fatalf("allow() = true after a half-open failure")
+	}
+
+	// A half-open success closes it.
+	now = now.Add(31 * time.Second)
+	if !c.allow(part(0)) {
+		t.Fatalf("allow() = false after the second cooldown")
+	}
+	c.success(part(5))
+	c.failure(part(5))
+	if !c.allow(part(5)) {
+		t.Fatalf("allow() = false after one failure once closed")
+	}
+}
+
+// logCircuitGauge returns ct_archive_serve_log_circuit_open{state=state} from reg.
+func logCircuitGauge(t *testing.T, reg *prometheus.Registry, state string) float64 {
+	t.Helper()
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != "ct_archive_serve_log_circuit_open" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if m.GetLabel()[0].GetValue() == state {
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+	t.Fatalf("ct_archive_serve_log_circuit_open{state=%q} not found", state)
+	return 0
+}
+
+func TestZipReader_LogCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	for i := 0; i < 4; i++ {
+		mustCreateZip(t, filepath.Join(logFolder, fmt.Sprintf("%03d.zip", i)), map[string][]byte{
+			"tile/0/000": []byte("tile"),
+		})
+	}
+
+	var mu sync.Mutex
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	verifyErr := errors.New("corrupt")
+	verifies := 0
+	verify := func(string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		verifies++
+		return verifyErr
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	zic := NewZipIntegrityCache(time.Hour, clock, verify, metrics)
+	zic.SetLogCircuitBreaker(3, time.Minute, time.Minute)
+	zr := NewZipReader(zic)
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		LogCircuitCooldown:   time.Minute,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	circuits := func(state string) float64 {
+		t.Helper()
+		return logCircuitGauge(t, reg, state)
+	}
+
+	// Three parts fail verification in a row: the breaker trips.
+	if w := get("/test_log/tile/0/000"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	for _, part := range []string{"001.zip", "002.zip"} {
+		_, err := zr.OpenEntry(t.Context(), filepath.Join(logFolder, part), "tile/0/000")
+		if !errors.Is(err, ErrZipTemporarilyUnavailable) || errors.Is(err, errLogCircuitOpen) {
+			t.Fatalf("OpenEntry(%s) error = %v, want an integrity failure", part, err)
+		}
+	}
+	if got := circuits("open"); got != 1 {
+		t.Fatalf("log_circuit_open{state=open} = %v, want 1", got)
+	}
+
+	// Open: no disk access, 503 with Retry-After.
+	mu.Lock()
+	before := verifies
+	mu.Unlock()
+	w := get("/test_log/tile/0/000")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status while open = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if _, err := zr.OpenEntry(t.Context(), filepath.Join(logFolder, "003.zip"), "tile/0/000"); !errors.Is(err, errLogCircuitOpen) {
+		t.Fatalf("OpenEntry() while open error = %v, want errLogCircuitOpen", err)
+	}
+	mu.Lock()
+	if verifies != before {
+		t.Errorf("verify called %d times while open, want 0", verifies-before)
+	}
+
+	// After the cooldown and with the parts fixed, the next verification closes it.
+	now = now.Add(2 * time.Hour)
+	verifyErr = nil
+	mu.Unlock()
+	if w := get("/test_log/tile/0/000"); w.Code != http.StatusOK {
+		t.Fatalf("status after cooldown = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := circuits("open") + circuits("half_open"); got != 0 {
+		t.Errorf("log circuits open or half-open = %v, want 0", got)
+	}
+}
+
+func TestZipReader_LogCircuitClosesOnSuccessfulRead(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	part := func(i int) string { return filepath.Join(logFolder, fmt.Sprintf("%03d.zip", i)) }
+	for i := 0; i < 4; i++ {
+		mustCreateZip(t, part(i), map[string][]byte{"tile/0/000": []byte("tile")})
+	}
+
+	var mu sync.Mutex
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	verifies := 0
+	verify := func(path string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		verifies++
+		if path == part(0) {
+			return nil
+		}
+		return errors.New("corrupt")
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	// Results are cached far longer than the cooldown, so nothing is re-verified after it.
+	zic := NewZipIntegrityCache(time.Hour, clock, verify, metrics)
+	zic.SetLogCircuitBreaker(3, time.Minute, time.Minute)
+	zr := NewZipReader(zic)
+
+	if _, err := zr.OpenEntry(t.Context(), part(0), "tile/0/000"); err != nil {
+		t.Fatalf("OpenEntry(000.zip) error = %v", err)
+	}
+	for i := 1; i < 4; i++ {
+		if _, err := zr.OpenEntry(t.Context(), part(i), "tile/0/000"); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+			t.Fatalf("OpenEntry(%03d.zip) error = %v, want an integrity failure", i, err)
+		}
+	}
+	if _, err := zr.OpenEntry(t.Context(), part(0), "tile/0/000"); !errors.Is(err, errLogCircuitOpen) {
+		t.Fatalf("OpenEntry() while open error = %v, want errLogCircuitOpen", err)
+	}
+
+	// Half-open after the cooldown: a read of the good part closes the breaker without
+	// any verification, and a failing part's cached failure does not reopen it.
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	before := verifies
+	mu.Unlock()
+	if _, err := zr.OpenEntry(t.Context(), part(0), "tile/0/000"); err != nil {
+		t.Fatalf("OpenEntry() after the cooldown error = %v", err)
+	}
+	mu.Lock()
+	if verifies != before {
+		t.Errorf("verify called %d times after the cooldown, want 0", verifies-before)
+	}
+	mu.Unlock()
+	if got := logCircuitGauge(t, reg, "half_open"); got != 0 {
+		t.Errorf("log_circuit_open{state=half_open} = %v, want 0", got)
+	}
+	if got := logCircuitGauge(t, reg, "open"); got != 0 {
+		t.Errorf("log_circuit_open{state=open} = %v, want 0", got)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := zr.OpenEntry(t.Context(), part(0), "tile/0/000"); err != nil {
+			t.Fatalf("OpenEntry() once closed error = %v", err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ctarchiveserve
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,11 +10,17 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/sync/singleflight"
 )
 
 // LogV3Entry represents a log entry from log.v3.json.
@@ -33,9 +40,12 @@ type LogListV3JSONSnapshot struct {
 	LogListTimestamp string                 `json:"log_list_timestamp"`
 	Operators        []LogListV3JSONOperator  `json:"operators"`
 	LastError        error                  `json:"-"` // Internal: tracks refresh failure state (not in JSON)
+	BuiltAt          time.Time              `json:"-"` // Internal: when this snapshot was built (for staleness)
+	ETag             string                 `json:"-"` // Internal: content hash, set at build time when CT_LOGLISTV3_JSON_ETAG is enabled
 }
 
-// LogListV3JSONOperator represents the single operator in loglist v3 JSON.
+// LogListV3JSONOperator represents an operator in loglist v3 JSON: the default
+// "ct-archive-serve" operator, or one from CT_OPERATOR_MAP.
 type LogListV3JSONOperator struct {
 	Name      string            `json:"name"`
 	Email     []string          `json:"email"`
@@ -60,6 +70,7 @@ type LogListV3JSONTiledLog struct {
 // zipFileCacheEntry stores cached data for a zip file along with its modification time.
 type zipFileCacheEntry struct {
 	mtime      time.Time
+	metaMtime  time.Time // mtime of the folder-level log.v3.json it was read from; zero for the zip entry
 	logV3Entry *LogV3Entry
 	hasIssuers bool
 }
@@ -70,18 +81,48 @@ type LogListV3JSONBuilder struct {
 	archiveIndex *ArchiveIndex
 	logger       *slog.Logger
 	cfg          Config
+	now          func() time.Time
 
 	snap atomic.Value // stores *LogListV3JSONSnapshot
 
+	// metadata stores the *LogMetadataDump of the last BuildSnapshot (/admin/metadata.json).
+	metadata atomic.Value
+
+	// refreshGroup coalesces overlapping refresh triggers into a single build whose
+	// result is shared by all callers.
+	refreshGroup singleflight.Group
+
 	// refreshMu serializes refresh operations to prevent concurrent refreshes
 	// (e.g., if a refresh takes longer than the refresh interval)
 	refreshMu sync.Mutex
 
+	// build produces a snapshot; it is BuildSnapshot except in tests.
+	build func(publicBaseURL string) (*LogListV3JSONSnapshot, error)
+
 	// zipCache stores cached log.v3.json data keyed by zip file path.
 	// Protected by refreshMu (only accessed during refresh operations).
 	zipCache map[string]zipFileCacheEntry
+
+	// encode renders a per-request snapshot; it is encodeLogListV3JSON except in tests.
+	encode func(snap *LogListV3JSONSnapshot) ([]byte, error)
+
+	// bodyMu guards the rendered bodies of bodySnap, keyed by public base URL, so a HEAD
+	// and the GET that follows (or any repeat request) reuse the same bytes. A refresh
+	// stores a new snapshot, which empties the cache on next use.
+	bodyMu   sync.Mutex
+	bodySnap *LogListV3JSONSnapshot
+	bodies   map[string][]byte
+
+	// operators assigns logs to operators by log name (CT_OPERATOR_MAP); logs not in it
+	// go to the default operator.
+	operators map[string]LogOperator
 }
 
+// maxCachedLogListBodies bounds the rendered bodies kept per snapshot. Each distinct
+// public base URL (Host / X-Forwarded-* combination) gets its own body; beyond this many
+// they are rendered per request instead of cached.
+const maxCachedLogListBodies = 16
+
 // NewLogListV3JSONBuilder constructs a new LogListV3JSONBuilder.
 func NewLogListV3JSONBuilder(
 	cfg Config,
@@ -89,13 +130,98 @@ func NewLogListV3JSONBuilder(
 	archiveIndex *ArchiveIndex,
 	logger *slog.Logger,
 ) *LogListV3JSONBuilder {
-	return &LogListV3JSONBuilder{
+	b := &LogListV3JSONBuilder{
 		zipReader:    zipReader,
 		archiveIndex: archiveIndex,
 		logger:       logger,
 		cfg:          cfg,
+		now:          time.Now,
 		zipCache:     make(map[string]zipFileCacheEntry),
 	}
+	b.build = b.BuildSnapshot
+	b.encode = encodeLogListV3JSON
+	return b
+}
+
+// encodeLogListV3JSON renders snap as served, with json.Encoder's trailing newline.
+func encodeLogListV3JSON(snap *LogListV3JSONSnapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(snap); err != nil {
+		return nil, fmt.Errorf("encode logs.v3.json: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderForRequest returns the current snapshot and its body rendered for publicBaseURL.
+// Bodies are cached per base URL until the next refresh. A nil body means there is no
+// usable snapshot (nil or LastError set); the snapshot is returned for the caller's
+// error handling.
+func (b *LogListV3JSONBuilder) RenderForRequest(publicBaseURL string) (*LogListV3JSONSnapshot, []byte, error) {
+	return b.RenderFilteredForRequest(publicBaseURL, nil)
+}
+
+// RenderFilteredForRequest is RenderForRequest with the tiled logs restricted to those
+// whose has_issuers equals *hasIssuers; a nil hasIssuers keeps all logs. Filtered bodies
+// are cached alongside unfiltered ones.
+func (b *LogListV3JSONBuilder) RenderFilteredForRequest(publicBaseURL string, hasIssuers *bool) (*LogListV3JSONSnapshot, []byte, error) {
+	if b == nil {
+		return nil, nil, nil
+	}
+	snap := b.GetSnapshot()
+	if snap == nil || snap.LastError != nil {
+		return snap, nil, nil
+	}
+
+	b.bodyMu.Lock()
+	defer b.bodyMu.Unlock()
+	if b.bodySnap != snap {
+		b.bodySnap = snap
+		b.bodies = make(map[string][]byte)
+	}
+	key := logListBodyKey(publicBaseURL, hasIssuers)
+	if body, ok := b.bodies[key]; ok {
+		return snap, body, nil
+	}
+
+	rendered := b.withBaseURL(snap, publicBaseURL)
+	if hasIssuers != nil {
+		rendered = filterHasIssuers(rendered, *hasIssuers)
+	}
+	body, err := b.encode(rendered)
+	if err != nil {
+		return snap, nil, err
+	}
+	if len(b.bodies) < maxCachedLogListBodies {
+		b.bodies[key] = body
+	}
+	return snap, body, nil
+}
+
+// logListBodyKey identifies one rendering of a snapshot: the base URL plus any filter.
+// It keys the body cache and, via ETagForRequest, distinguishes the ETags of filtered
+// and unfiltered bodies.
+func logListBodyKey(publicBaseURL string, hasIssuers *bool) string {
+	if hasIssuers == nil {
+		return publicBaseURL
+	}
+	return publicBaseURL + "?has_issuers=" + strconv.FormatBool(*hasIssuers)
+}
+
+// filterHasIssuers returns a copy of snap keeping only tiled logs whose HasIssuers equals
+// want. snap's slices are shared with the cached snapshot, so new ones are built.
+func filterHasIssuers(snap *LogListV3JSONSnapshot, want bool) *LogListV3JSONSnapshot {
+	clone := *snap
+	clone.Operators = make([]LogListV3JSONOperator, len(snap.Operators))
+	for i, op := range snap.Operators {
+		op.TiledLogs = slices.DeleteFunc(slices.Clone(op.TiledLogs), func(tlog LogListV3JSONTiledLog) bool {
+			return tlog.HasIssuers != want
+		})
+		if op.TiledLogs == nil {
+			op.TiledLogs = []LogListV3JSONTiledLog{}
+		}
+		clone.Operators[i] = op
+	}
+	return &clone
 }
 
 // GetSnapshot returns the current loglist v3 JSON snapshot.
@@ -119,16 +245,27 @@ func (b *LogListV3JSONBuilder) GetSnapshot() *LogListV3JSONSnapshot {
 // extracts/parses log.v3.json and checks for issuer/ entries. This avoids opening
 // the same ZIP file twice, which is expensive for large ZIPs with many entries.
 // It uses mtime-based caching to avoid re-reading unchanged zip files.
+//
+// As CT_METADATA_SOURCE selects, log.v3.json comes from the file next to the zip part
+// (the log folder) instead; the zip is still scanned for issuer/ entries.
 func (b *LogListV3JSONBuilder) extractLogV3JSONAndCheckIssuers(zipPath string) (*LogV3Entry, bool, error) {
 	// Check mtime to see if we can use cached data
 	stat, err := os.Stat(zipPath)
 	if err != nil {
 		return nil, false, fmt.Errorf("stat zip: %w", err)
 	}
+	metaPath, metaStat, err := metadataFile(b.cfg.MetadataSource, filepath.Dir(zipPath))
+	if err != nil {
+		return nil, false, fmt.Errorf("log.v3.json file: %w", err)
+	}
+	var metaMtime time.Time
+	if metaStat != nil {
+		metaMtime = metaStat.ModTime()
+	}
 
 	// Check cache (protected by refreshMu, which is held by caller)
 	if cached, ok := b.zipCache[zipPath]; ok {
-		if cached.mtime.Equal(stat.ModTime()) {
+		if cached.mtime.Equal(stat.ModTime()) && cached.metaMtime.Equal(metaMtime) {
 			// mtime matches, use cached data
 			if b.logger != nil {
 				b.logger.Debug("Using cached log.v3.json data (mtime unchanged)", "zip_path", zipPath)
@@ -139,7 +276,7 @@ func (b *LogListV3JSONBuilder) extractLogV3JSONAndCheckIssuers(zipPath string) (
 		}
 		// mtime changed, remove from cache and re-read
 		if b.logger != nil {
-			b.logger.Debug("Zip file mtime changed, re-reading", "zip_path", zipPath, "old_mtime", cached.mtime, "new_mtime", stat.ModTime())
+			b.logger.Debug("Zip file or log.v3.json mtime changed, re-reading", "zip_path", zipPath, "old_mtime", cached.mtime, "new_mtime", stat.ModTime())
 		}
 		delete(b.zipCache, zipPath)
 	}
@@ -148,7 +285,7 @@ func (b *LogListV3JSONBuilder) extractLogV3JSONAndCheckIssuers(zipPath string) (
 	if b.logger != nil {
 		b.logger.Debug("Opening zip file for log.v3.json extraction and issuer check", "zip_path", zipPath)
 	}
-	r, err := zip.OpenReader(zipPath)
+	r, err := openZipPart(zipPath)
 	if err != nil {
 		return nil, false, fmt.Errorf("open zip: %w", err)
 	}
@@ -163,9 +300,9 @@ func (b *LogListV3JSONBuilder) extractLogV3JSONAndCheckIssuers(zipPath string) (
 	issuerLogged := false
 
 	for _, f := range r.File {
-		if f.Name == "log.v3.json" {
+		if f.Name == b.cfg.ZipEntryPrefix+logV3JSONFileName {
 			logV3File = f
-		} else if strings.HasPrefix(f.Name, "issuer/") {
+		} else if strings.HasPrefix(normalizeZipEntryName(f.Name), b.cfg.ZipEntryPrefix+"issuer/") {
 			hasIssuers = true
 			// Only log the first issuer entry found to reduce verbosity
 			if b.logger != nil && !issuerLogged {
@@ -175,22 +312,33 @@ func (b *LogListV3JSONBuilder) extractLogV3JSONAndCheckIssuers(zipPath string) (
 		}
 	}
 
-	if logV3File == nil {
-		return nil, hasIssuers, errors.New("log.v3.json not found in zip")
-	}
+	var data []byte
+	if metaPath != "" {
+		if b.logger != nil {
+			b.logger.Debug("Reading log.v3.json from file", "path", metaPath)
+		}
+		data, err = os.ReadFile(metaPath)
+		if err != nil {
+			return nil, hasIssuers, fmt.Errorf("read log.v3.json: %w", err)
+		}
+	} else {
+		if logV3File == nil {
+			return nil, hasIssuers, errors.New("log.v3.json not found in zip")
+		}
 
-	if b.logger != nil {
-		b.logger.Debug("Reading log.v3.json from zip", "zip_path", zipPath)
-	}
-	rc, err := logV3File.Open()
-	if err != nil {
-		return nil, hasIssuers, fmt.Errorf("open log.v3.json: %w", err)
-	}
-	defer func() { _ = rc.Close() }()
+		if b.logger != nil {
+			b.logger.Debug("Reading log.v3.json from zip", "zip_path", zipPath)
+		}
+		rc, err := logV3File.Open()
+		if err != nil {
+			return nil, hasIssuers, fmt.Errorf("open log.v3.json: %w", err)
+		}
+		defer func() { _ = rc.Close() }()
 
-	data, err := io.ReadAll(rc)
-	if err != nil {
-		return nil, hasIssuers, fmt.Errorf("read log.v3.json: %w", err)
+		data, err = io.ReadAll(rc)
+		if err != nil {
+			return nil, hasIssuers, fmt.Errorf("read log.v3.json: %w", err)
+		}
 	}
 
 	var entry LogV3Entry
@@ -201,6 +349,7 @@ func (b *LogListV3JSONBuilder) extractLogV3JSONAndCheckIssuers(zipPath string) (
 	// Cache the result
 	b.zipCache[zipPath] = zipFileCacheEntry{
 		mtime:      stat.ModTime(),
+		metaMtime:  metaMtime,
 		logV3Entry: &entry,
 		hasIssuers: hasIssuers,
 	}
@@ -230,6 +379,12 @@ func (b *LogListV3JSONBuilder) checkHasIssuers(zipPath string) (bool, error) {
 	return hasIssuers, err
 }
 
+// SetOperatorMap groups logs under the operators of m (see LoadOperatorMap) instead of
+// the single default operator. Must be called before Start.
+func (b *LogListV3JSONBuilder) SetOperatorMap(m map[string]LogOperator) {
+	b.operators = m
+}
+
 // BuildSnapshot builds a new logs.v3.json snapshot from the current archive index state.
 // The publicBaseURL is used to set submission_url and monitoring_url per spec.md FR-006.
 func (b *LogListV3JSONBuilder) BuildSnapshot(publicBaseURL string) (*LogListV3JSONSnapshot, error) {
@@ -244,6 +399,7 @@ func (b *LogListV3JSONBuilder) BuildSnapshot(publicBaseURL string) (*LogListV3JS
 	}
 
 	tiledLogs := make([]LogListV3JSONTiledLog, 0)
+	results := make([]LogMetadataResult, 0, len(snap.Logs))
 	logNames := make([]string, 0, len(snap.Logs))
 	for logName := range snap.Logs {
 		logNames = append(logNames, logName)
@@ -252,7 +408,7 @@ func (b *LogListV3JSONBuilder) BuildSnapshot(publicBaseURL string) (*LogListV3JS
 
 	for i, logName := range logNames {
 		log := snap.Logs[logName]
-		zipPath := log.FolderPath + "/000.zip"
+		zipPath := log.ZipPartPath(0)
 
 		if b.logger != nil {
 			b.logger.Debug("Processing log for logs.v3.json", "log", logName, "progress", fmt.Sprintf("%d/%d", i+1, len(logNames)), "zip_path", zipPath)
@@ -267,8 +423,11 @@ func (b *LogListV3JSONBuilder) BuildSnapshot(publicBaseURL string) (*LogListV3JS
 			if b.logger != nil {
 				b.logger.Warn("Failed to extract log.v3.json or check issuers", "log", logName, "error", err)
 			}
+			results = append(results, LogMetadataResult{Log: logName, HasIssuers: hasIssuers, Error: err.Error()})
 			continue // Skip this log
 		}
+		raw := *logV3
+		results = append(results, LogMetadataResult{Log: logName, Entry: &raw, HasIssuers: hasIssuers})
 		if b.logger != nil {
 			b.logger.Debug("Extracted log.v3.json and checked issuers", "log", logName, "description", logV3.Description, "has_issuers", hasIssuers)
 		}
@@ -311,7 +470,7 @@ func (b *LogListV3JSONBuilder) BuildSnapshot(publicBaseURL string) (*LogListV3JS
 	// Build a set of current zip paths
 	currentZipPaths := make(map[string]bool, len(snap.Logs))
 	for _, log := range snap.Logs {
-		currentZipPaths[log.FolderPath+"/000.zip"] = true
+		currentZipPaths[log.ZipPartPath(0)] = true
 	}
 
 	// Remove cache entries for zip files that no longer exist in the archive
@@ -328,26 +487,42 @@ func (b *LogListV3JSONBuilder) BuildSnapshot(publicBaseURL string) (*LogListV3JS
 		b.logger.Debug("Logs.v3.json snapshot build complete", "tiled_log_count", len(tiledLogs))
 	}
 
-	return &LogListV3JSONSnapshot{
+	builtAt := b.now()
+	b.metadata.Store(&LogMetadataDump{BuiltAt: builtAt, Logs: results})
+	out := &LogListV3JSONSnapshot{
 		Version:          "3.0",
-		LogListTimestamp: time.Now().UTC().Format(time.RFC3339),
-		Operators: []LogListV3JSONOperator{
-			{
-				Name:      "ct-archive-serve",
-				Email:     []string{},
-				Logs:      []interface{}{},
-				TiledLogs: tiledLogs,
-			},
-		},
-		LastError: nil,
-	}, nil
+		LogListTimestamp: builtAt.UTC().Format(time.RFC3339),
+		Operators:        groupByOperator(tiledLogs, b.operators),
+		LastError:        nil,
+		BuiltAt:          builtAt,
+	}
+
+	// Hash the content once here rather than per request. Submission/monitoring URLs
+	// depend on the request, so handlers combine this with the request's base URL.
+	if b.cfg.LogListV3JSONETag {
+		body, err := encodeLogListV3JSON(out)
+		if err != nil {
+			return nil, err
+		}
+		out.ETag = strconv.FormatUint(xxhash.Sum64(body), 16)
+	}
+	return out, nil
+}
+
+// ETagForRequest returns the quoted ETag of snap as rendered for publicBaseURL, or "" when
+// snap carries no precomputed ETag.
+func (snap *LogListV3JSONSnapshot) ETagForRequest(publicBaseURL string) string {
+	if snap == nil || snap.ETag == "" {
+		return ""
+	}
+	return `"` + snap.ETag + "-" + strconv.FormatUint(xxhash.Sum64String(publicBaseURL), 16) + `"`
 }
 
 // Start begins the periodic refresh loop for logs.v3.json.
 // It performs an initial refresh at startup, then refreshes on CT_LOGLISTV3_JSON_REFRESH_INTERVAL.
 // Note: publicBaseURL is a placeholder for the refresh loop; actual URLs are set per-request.
 func (b *LogListV3JSONBuilder) Start(ctx context.Context) {
-	if b == nil {
+	if b == nil || b.cfg.DisableLogListV3JSON {
 		return
 	}
 
@@ -360,6 +535,15 @@ func (b *LogListV3JSONBuilder) Start(ctx context.Context) {
 		b.logger.Debug("Initial loglist v3 JSON refresh completed")
 	}
 
+	// Immutable archives only need one successful build. If the initial build failed,
+	// keep retrying on the normal interval until one succeeds.
+	if b.cfg.ArchiveImmutable && b.hasGoodSnapshot() {
+		if b.logger != nil {
+			b.logger.Debug("Archive is immutable, periodic logs.v3.json refresh disabled")
+		}
+		return
+	}
+
 	// Periodic refresh loop
 	t := time.NewTicker(b.cfg.LogListV3JSONRefreshInterval)
 	go func() {
@@ -371,19 +555,39 @@ func (b *LogListV3JSONBuilder) Start(ctx context.Context) {
 			case <-t.C:
 				// Refresh with placeholder; actual URLs set per-request
 				b.refreshOnce("http://placeholder")
+				if b.cfg.ArchiveImmutable && b.hasGoodSnapshot() {
+					return
+				}
 			}
 		}
 	}()
 }
 
-// refreshOnce attempts to build a new snapshot and update the atomic value.
-// On success, LastError is nil. On failure, LastError is set and the snapshot may be nil.
-// This method is protected by refreshMu to prevent concurrent refreshes.
-func (b *LogListV3JSONBuilder) refreshOnce(publicBaseURL string) {
+// hasGoodSnapshot reports whether a snapshot has been built without error.
+func (b *LogListV3JSONBuilder) hasGoodSnapshot() bool {
+	snap := b.GetSnapshot()
+	return snap != nil && snap.LastError == nil
+}
+
+// refreshOnce attempts to build a new snapshot and update the atomic value, returning the
+// stored snapshot. On success, LastError is nil. On failure, LastError is set.
+//
+// Overlapping calls (e.g. ticker and a manual trigger firing together) are coalesced via
+// refreshGroup: only one build runs and every caller receives its result.
+func (b *LogListV3JSONBuilder) refreshOnce(publicBaseURL string) *LogListV3JSONSnapshot {
+	v, _, _ := b.refreshGroup.Do("refresh", func() (interface{}, error) {
+		return b.refreshLocked(publicBaseURL), nil
+	})
+	snap, _ := v.(*LogListV3JSONSnapshot) //nolint:errcheck // refreshLocked always returns *LogListV3JSONSnapshot
+	return snap
+}
+
+// refreshLocked performs one refresh under refreshMu and stores the result.
+func (b *LogListV3JSONBuilder) refreshLocked(publicBaseURL string) *LogListV3JSONSnapshot {
 	b.refreshMu.Lock()
 	defer b.refreshMu.Unlock()
 
-	snap, err := b.BuildSnapshot(publicBaseURL)
+	snap, err := b.build(publicBaseURL)
 	if err != nil {
 		if b.logger != nil {
 			b.logger.Error("Logs.v3.json refresh failed", "error", err)
@@ -394,7 +598,7 @@ func (b *LogListV3JSONBuilder) refreshOnce(publicBaseURL string) {
 			snap = &LogListV3JSONSnapshot{
 				Version:          "3.0",
 				LogListTimestamp: time.Now().UTC().Format(time.RFC3339),
-				Operators:        []LogListV3JSONOperator{{Name: "ct-archive-serve", Email: []string{}, Logs: []interface{}{}, TiledLogs: []LogListV3JSONTiledLog{}}},
+				Operators:        []LogListV3JSONOperator{{Name: defaultOperatorName, Email: []string{}, Logs: []interface{}{}, TiledLogs: []LogListV3JSONTiledLog{}}},
 				LastError:        err,
 			}
 		} else {
@@ -402,6 +606,25 @@ func (b *LogListV3JSONBuilder) refreshOnce(publicBaseURL string) {
 		}
 	}
 	b.snap.Store(snap)
+	return snap
+}
+
+// Staleness reports how old snap is and whether it is stale.
+//
+// A snapshot is considered stale once it is older than twice
+// CT_LOGLISTV3_JSON_REFRESH_INTERVAL, i.e. at least one scheduled refresh has
+// not produced a newer snapshot (for example because a refresh is still
+// running against a very large or slow archive). Snapshots of an immutable
+// archive (CT_ARCHIVE_IMMUTABLE) are never stale.
+func (b *LogListV3JSONBuilder) Staleness(snap *LogListV3JSONSnapshot) (time.Duration, bool) {
+	if b == nil || snap == nil || snap.BuiltAt.IsZero() || b.cfg.LogListV3JSONRefreshInterval <= 0 {
+		return 0, false
+	}
+	age := b.now().Sub(snap.BuiltAt)
+	if b.cfg.ArchiveImmutable {
+		return age, false
+	}
+	return age, age > 2*b.cfg.LogListV3JSONRefreshInterval
 }
 
 // GetSnapshotForRequest returns a snapshot with URLs set from the request's publicBaseURL.
@@ -412,9 +635,15 @@ func (b *LogListV3JSONBuilder) GetSnapshotForRequest(publicBaseURL string) *LogL
 	snap := b.GetSnapshot()
 	if snap == nil || snap.LastError != nil {
 		return snap // Return as-is (will result in 503)
-	}	// Clone snapshot and update URLs per request
+	}
+	return b.withBaseURL(snap, publicBaseURL)
+}
+
+// withBaseURL returns a copy of snap with submission/monitoring URLs under publicBaseURL.
+func (b *LogListV3JSONBuilder) withBaseURL(snap *LogListV3JSONSnapshot, publicBaseURL string) *LogListV3JSONSnapshot {
+	// Clone snapshot and update URLs per request
 	clone := *snap
-	if len(clone.Operators) > 0 && len(clone.Operators[0].TiledLogs) > 0 {
+	if len(clone.Operators) > 0 {
 		clone.Operators = make([]LogListV3JSONOperator, len(snap.Operators))
 		for i, op := range snap.Operators {
 			clone.Operators[i] = LogListV3JSONOperator{
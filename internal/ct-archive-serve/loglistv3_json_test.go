@@ -2,10 +2,12 @@ package ctarchiveserve
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -268,6 +270,92 @@ func TestLogListV3JSONBuilder_LogListV3Validation(t *testing.T) {
 	}
 }
 
+func TestLogListV3JSONBuilder_Immutable_NoRefreshAfterFirstBuild(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	mustCreateZipForLogListV3(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"log.v3.json": []byte(`{"description":"Test Log","log_id":"abc123","key":"def456","mmd":86400,"log_type":"prod","state":{}}`),
+	})
+
+	cfg := Config{
+		ArchivePath:                  root,
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: 5 * time.Millisecond,
+		ArchiveImmutable:             true,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	builder := NewLogListV3JSONBuilder(cfg, NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)), archiveIndex, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	builder.Start(ctx)
+
+	first := builder.GetSnapshot()
+	if first == nil || first.LastError != nil {
+		t.Fatalf("initial snapshot = %+v, want successful build", first)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if got := builder.GetSnapshot(); got != first {
+		t.Fatalf("snapshot was rebuilt after the first build in immutable mode")
+	}
+	if _, stale := builder.Staleness(first); stale {
+		t.Fatalf("Staleness() stale = true, want false in immutable mode")
+	}
+}
+
+func TestLogListV3JSONBuilder_ConcurrentRefreshesCoalesce(t *testing.T) {
+	t.Parallel()
+
+	builder := NewLogListV3JSONBuilder(Config{}, nil, nil, nil)
+
+	// Block the build until all callers have piled up behind it.
+	release := make(chan struct{})
+	var builds atomic.Int64
+	builder.build = func(string) (*LogListV3JSONSnapshot, error) {
+		builds.Add(1)
+		<-release
+		return &LogListV3JSONSnapshot{Version: "3.0"}, nil
+	}
+
+	const callers = 20
+	snaps := make(chan *LogListV3JSONSnapshot, callers)
+	for i := 0; i < callers; i++ {
+		go func() { snaps <- builder.refreshOnce("http://placeholder") }()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	var first *LogListV3JSONSnapshot
+	for i := 0; i < callers; i++ {
+		snap := <-snaps
+		if snap == nil {
+			t.Fatalf("refreshOnce() returned nil snapshot")
+		}
+		if first == nil {
+			first = snap
+		} else if snap != first {
+			t.Fatalf("refreshOnce() callers received different snapshots, want one shared result")
+		}
+	}
+	if got, want := builds.Load(), int64(1); got != want {
+		t.Fatalf("BuildSnapshot runs = %d, want %d", got, want)
+	}
+	if builder.GetSnapshot() != first {
+		t.Fatalf("GetSnapshot() is not the shared refresh result")
+	}
+}
+
 // mustCreateZipForLogListV3 is a helper to create zip files for logs.v3.json tests.
 func mustCreateZipForLogListV3(t *testing.T, path string, files map[string][]byte) {
 	t.Helper()
@@ -0,0 +1,30 @@
+package ctarchiveserve
+
+import (
+	"time"
+)
+
+// LogMetadataResult is what the last logs.v3.json build extracted for one log: the
+// parsed log.v3.json before URL rewriting, or the error that left the log out.
+type LogMetadataResult struct {
+	Log        string      `json:"log"`
+	Entry      *LogV3Entry `json:"entry,omitempty"`
+	HasIssuers bool        `json:"has_issuers"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// LogMetadataDump holds the per-log extraction results of one logs.v3.json build.
+type LogMetadataDump struct {
+	BuiltAt time.Time           `json:"built_at"`
+	Logs    []LogMetadataResult `json:"logs"`
+}
+
+// MetadataDump returns the per-log extraction results of the last BuildSnapshot, sorted
+// by log name, or nil before the first build.
+func (b *LogListV3JSONBuilder) MetadataDump() *LogMetadataDump {
+	if b == nil {
+		return nil
+	}
+	dump, _ := b.metadata.Load().(*LogMetadataDump) //nolint:errcheck // only *LogMetadataDump is stored
+	return dump
+}
@@ -0,0 +1,96 @@
+package ctarchiveserve
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidateLogListV3JSONStaticGz checks that a CT_LOGLISTV3_JSON_STATIC_GZ file is a gzip
+// stream holding a JSON document. It is called once at startup; the file is read from
+// disk on every request, so it may be replaced (atomically) while the server runs.
+func ValidateLogListV3JSONStaticGz(path string) error {
+	//nolint:gosec // G304: path comes from operator configuration, not user input
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open static logs.v3.json: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("static logs.v3.json is not gzip: %w", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("decompress static logs.v3.json: %w", err)
+	}
+	if !json.Valid(data) {
+		return errors.New("static logs.v3.json does not contain valid JSON")
+	}
+	return nil
+}
+
+// acceptsGzip reports whether an Accept-Encoding header value allows a gzip response:
+// gzip (or x-gzip) is listed with a non-zero weight, or "*" is and gzip is not excluded.
+func acceptsGzip(header string) bool {
+	return acceptsEncoding(header, "gzip", "x-gzip")
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header value allows a response in the
+// content coding known by names: one of them is listed with a non-zero weight, or "*" is
+// and none of them is listed.
+func acceptsEncoding(header string, names ...string) bool {
+	codingQ, anyQ := -1.0, -1.0
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if !ok || !strings.EqualFold(k, "q") {
+				continue
+			}
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch name = strings.ToLower(strings.TrimSpace(name)); {
+		case slices.Contains(names, name):
+			codingQ = q
+		case name == "*":
+			anyQ = q
+		}
+	}
+	if codingQ >= 0 {
+		return codingQ > 0
+	}
+	return anyQ > 0
+}
+
+// serveLogListV3JSONStaticGz serves the CT_LOGLISTV3_JSON_STATIC_GZ file as-is with
+// Content-Encoding: gzip. It returns false, having written nothing, when the file cannot
+// be opened, so the caller can fall back to the builder.
+func (s *Server) serveLogListV3JSONStaticGz(w http.ResponseWriter, r *http.Request) bool {
+	f, err := os.Open(s.cfg.LogListV3JSONStaticGz)
+	if err == nil {
+		var fi os.FileInfo
+		if fi, err = f.Stat(); err == nil {
+			defer func() { _ = f.Close() }()
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			http.ServeContent(w, r, "", fi.ModTime(), f)
+			return true
+		}
+		_ = f.Close()
+	}
+	if s.logger != nil {
+		s.requestLogger(r).Error("Failed to open static logs.v3.json, serving it dynamically", "path", s.cfg.LogListV3JSONStaticGz, "error", err)
+	}
+	return false
+}
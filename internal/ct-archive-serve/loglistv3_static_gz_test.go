@@ -0,0 +1,159 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_LogListV3JSONStaticGz(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint":  checkpointBody(1),
+		"log.v3.json": []byte(`{"description":"Test log","log_id":"aWQ=","key":"a2V5","mmd":86400}`),
+	})
+
+	const static = `{"version":"static","operators":[]}`
+	staticPath := filepath.Join(t.TempDir(), "logs.v3.json.gz")
+	mustWriteFile(t, staticPath, gzipBytes(t, []byte(static)))
+	if err := ValidateLogListV3JSONStaticGz(staticPath); err != nil {
+		t.Fatalf("ValidateLogListV3JSONStaticGz() error = %v", err)
+	}
+
+	cfg := Config{
+		ArchivePath:           root,
+		ArchiveFolderPattern:  "ct_*",
+		ArchiveFolderPrefix:   "ct_",
+		LogListV3JSONStaticGz: staticPath,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+	builder.refreshOnce("http://placeholder")
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, builder)
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s (Accept-Encoding %q) status = %d, want 200", path, acceptEncoding, w.Code)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("GET %s Vary = %q, want Accept-Encoding", path, got)
+		}
+		return w
+	}
+
+	// A gzip client gets the file as-is.
+	w := get("/logs.v3.json", "br, gzip;q=0.8")
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	zr2, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	body, err := io.ReadAll(zr2)
+	if err != nil || string(body) != static {
+		t.Fatalf("decompressed body = %q (error %v), want %q", body, err, static)
+	}
+
+	// Clients without gzip, and filtered requests, get the built list.
+	for _, tc := range []struct{ path, acceptEncoding string }{
+		{"/logs.v3.json", ""},
+		{"/logs.v3.json", "gzip;q=0, *"},
+		{"/logs.v3.json", "identity"},
+		{"/logs.v3.json?has_issuers=false", "gzip"},
+	} {
+		w := get(tc.path, tc.acceptEncoding)
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("GET %s (Accept-Encoding %q) Content-Encoding = %q, want none", tc.path, tc.acceptEncoding, got)
+		}
+		if !strings.Contains(w.Body.String(), "test_log") {
+			t.Errorf("GET %s (Accept-Encoding %q) body = %q, want the built list", tc.path, tc.acceptEncoding, w.Body.String())
+		}
+	}
+
+	// A file that disappears later falls back to the built list.
+	if err := os.Remove(staticPath); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if w := get("/logs.v3.json", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("GET with the static file gone: Content-Encoding = %q, want none", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestValidateLogListV3JSONStaticGz_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"plain.json":   []byte(`{"operators":[]}`),
+		"notjson.gz":   gzipBytes(t, []byte("not json")),
+		"truncated.gz": gzipBytes(t, []byte(`{"operators":[]}`))[:12],
+	} {
+		path := filepath.Join(dir, name)
+		mustWriteFile(t, path, content)
+		if err := ValidateLogListV3JSONStaticGz(path); err == nil {
+			t.Errorf("ValidateLogListV3JSONStaticGz(%s) = nil, want error", name)
+		}
+	}
+	if err := ValidateLogListV3JSONStaticGz(filepath.Join(dir, "missing.gz")); err == nil {
+		t.Error("ValidateLogListV3JSONStaticGz(missing) = nil, want error")
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	t.Parallel()
+
+	for header, want := range map[string]bool{
+		"":                    false,
+		"gzip":                true,
+		"GZIP":                true,
+		"x-gzip":              true,
+		"deflate, gzip;q=1.0": true,
+		"br;q=1, gzip;q=0.5":  true,
+		"gzip;q=0":            false,
+		"identity":            false,
+		"*":                   true,
+		"*;q=0":               false,
+		"gzip;q=0, *":         false,
+	} {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip Write() error = %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
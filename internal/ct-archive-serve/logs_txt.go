@@ -0,0 +1,41 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// handleLogsTXT serves GET /logs.txt: the discovered log names, sorted, one per line.
+// It is read straight from the archive index, so unlike /logs.v3.json it does not wait on
+// (or fail with) a log list snapshot build.
+func (s *Server) handleLogsTXT(w http.ResponseWriter, r *http.Request) {
+	if s.archiveIndex == nil {
+		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
+		return
+	}
+
+	snap := s.archiveIndex.GetAllLogs()
+	names := make([]string, 0, len(snap.Logs))
+	for name := range snap.Logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('\n')
+	}
+	body := b.String()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+	if _, err := w.Write([]byte(body)); err != nil {
+		s.logCopyError(r, "Failed to write logs.txt response", "error", err)
+	}
+}
@@ -0,0 +1,65 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_HandleLogsTXT(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, folder := range []string{"ct_xenon2025", "ct_argon2025", "ct_nessie2025"} {
+		mustMkdir(t, filepath.Join(root, folder))
+		mustWriteFile(t, filepath.Join(root, folder, "000.zip"), []byte("x"))
+	}
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	server := NewServer(cfg, nil, metrics, archiveIndex, nil, nil)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logs.txt", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	if got, want := w.Body.String(), "argon2025\nnessie2025\nxenon2025\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/logs.txt", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("HEAD status = %d, body length = %d; want 200 without body", w.Code, w.Body.Len())
+	}
+	if got, want := w.Header().Get("Content-Length"), "31"; got != want {
+		t.Errorf("HEAD Content-Length = %q, want %q", got, want)
+	}
+
+	// Not attributed to any log.
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() == "ct_archive_serve_log_requests_total" && len(mf.GetMetric()) != 0 {
+			t.Errorf("%s has %d series, want none", mf.GetName(), len(mf.GetMetric()))
+		}
+	}
+}
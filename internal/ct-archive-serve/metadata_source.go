@@ -0,0 +1,40 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CT_METADATA_SOURCE values: where log.v3.json is read from.
+const (
+	MetadataSourceAuto = "auto" // the log folder's log.v3.json file if present, else the 000.zip entry
+	MetadataSourceFile = "file" // only the log folder's log.v3.json file
+	MetadataSourceZip  = "zip"  // only the 000.zip entry
+)
+
+// logV3JSONFileName is the metadata file name, both in the log folder and in 000.zip.
+const logV3JSONFileName = "log.v3.json"
+
+// metadataFile returns the folder-level log.v3.json to read for the log in folder, or ""
+// when the 000.zip entry should be used instead. An empty source behaves like
+// MetadataSourceAuto. With MetadataSourceFile a missing file is ErrNotFound.
+func metadataFile(source, folder string) (string, fs.FileInfo, error) {
+	if source == MetadataSourceZip {
+		return "", nil, nil
+	}
+	path := filepath.Join(folder, logV3JSONFileName)
+	fi, err := os.Stat(path)
+	switch {
+	case err == nil:
+		return path, fi, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", nil, fmt.Errorf("stat %s: %w", path, err)
+	case source == MetadataSourceFile:
+		return "", nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	default:
+		return "", nil, nil
+	}
+}
@@ -0,0 +1,125 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMetadataSource(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, log := range []string{"file_log", "zip_log"} {
+		folder := filepath.Join(root, "ct_"+log)
+		mustMkdir(t, folder)
+		mustCreateZip(t, filepath.Join(folder, "000.zip"), map[string][]byte{
+			"log.v3.json":  []byte(`{"description":"Zip Log"}`),
+			"issuer/aa":    []byte("issuer"),
+			"tile/0/000.p": []byte("tile"),
+		})
+	}
+	fileLogMeta := filepath.Join(root, "ct_file_log", "log.v3.json")
+	mustWriteFile(t, fileLogMeta, []byte(`{"description":"File Log"}`))
+
+	tests := []struct {
+		source   string
+		log      string
+		wantDesc string // "" when the log has no metadata in this mode
+	}{
+		{source: MetadataSourceAuto, log: "file_log", wantDesc: "File Log"},
+		{source: MetadataSourceAuto, log: "zip_log", wantDesc: "Zip Log"},
+		{source: MetadataSourceFile, log: "file_log", wantDesc: "File Log"},
+		{source: MetadataSourceFile, log: "zip_log", wantDesc: ""},
+		{source: MetadataSourceZip, log: "file_log", wantDesc: "Zip Log"},
+		{source: MetadataSourceZip, log: "zip_log", wantDesc: "Zip Log"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.source+"/"+tc.log, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := Config{
+				ArchivePath:          root,
+				ArchiveFolderPattern: "ct_*",
+				ArchiveFolderPrefix:  "ct_",
+				MetadataSource:       tc.source,
+			}
+			archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+			if err != nil {
+				t.Fatalf("NewArchiveIndex() error = %v", err)
+			}
+			zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+			server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+tc.log+"/log.v3.json", nil))
+			if tc.wantDesc == "" {
+				if w.Code != http.StatusNotFound {
+					t.Fatalf("GET log.v3.json status = %d, want %d", w.Code, http.StatusNotFound)
+				}
+			} else {
+				if w.Code != http.StatusOK {
+					t.Fatalf("GET log.v3.json status = %d, want %d", w.Code, http.StatusOK)
+				}
+				var got LogV3Entry
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Unmarshal(%q) error = %v", w.Body.String(), err)
+				}
+				if got.Description != tc.wantDesc {
+					t.Errorf("served description = %q, want %q", got.Description, tc.wantDesc)
+				}
+			}
+
+			builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+			zipPath := filepath.Join(root, "ct_"+tc.log, "000.zip")
+			entry, hasIssuers, err := builder.extractLogV3JSONAndCheckIssuers(zipPath)
+			if tc.wantDesc == "" {
+				if err == nil {
+					t.Fatalf("extractLogV3JSONAndCheckIssuers() error = nil, want missing file error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractLogV3JSONAndCheckIssuers() error = %v", err)
+			}
+			if entry.Description != tc.wantDesc {
+				t.Errorf("built description = %q, want %q", entry.Description, tc.wantDesc)
+			}
+			if !hasIssuers {
+				t.Errorf("hasIssuers = false, want true (issuers are still read from 000.zip)")
+			}
+		})
+	}
+}
+
+func TestMetadataSource_FileChangeInvalidatesCache(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	folder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, folder)
+	zipPath := filepath.Join(folder, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{"checkpoint": checkpointBody(1)})
+	metaPath := filepath.Join(folder, "log.v3.json")
+	mustWriteFile(t, metaPath, []byte(`{"description":"Before"}`))
+
+	builder := NewLogListV3JSONBuilder(Config{ArchivePath: root}, nil, nil, nil)
+	entry, _, err := builder.extractLogV3JSONAndCheckIssuers(zipPath)
+	if err != nil || entry.Description != "Before" {
+		t.Fatalf("extractLogV3JSONAndCheckIssuers() = %v, %v; want Before", entry, err)
+	}
+
+	mustWriteFile(t, metaPath, []byte(`{"description":"After"}`))
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(metaPath, later, later); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	entry, _, err = builder.extractLogV3JSONAndCheckIssuers(zipPath)
+	if err != nil || entry.Description != "After" {
+		t.Fatalf("extractLogV3JSONAndCheckIssuers() after file change = %v, %v; want After", entry, err)
+	}
+}
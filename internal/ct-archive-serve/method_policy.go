@@ -0,0 +1,106 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// readOnlyMethods are the methods of every route that serves content (spec.md FR-002a).
+var readOnlyMethods = []string{http.MethodGet, http.MethodHead}
+
+// submissionMethods are the methods of the RFC 6962 submission endpoints: add-chain and
+// add-pre-chain are POSTs, get-roots is a GET.
+var submissionMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// allowedMethods returns the methods a route accepts, which the 405 Allow header
+// advertises. Routes accepting more than GET and HEAD are listed here.
+func allowedMethods(kind RouteKind) []string {
+	switch kind {
+	case RouteSubmission:
+		return submissionMethods
+	default:
+		return readOnlyMethods
+	}
+}
+
+// methodAllowed reports whether route accepts method.
+func methodAllowed(route Route, method string) bool {
+	for _, m := range allowedMethods(route.Kind) {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// methodNotAllowed writes a 405 with the route's Allow header. The body is JSON when the
+// client prefers application/json over text/plain, and plain text otherwise.
+func (s *Server) methodNotAllowed(w http.ResponseWriter, r *http.Request, route Route) {
+	methods := allowedMethods(route.Kind)
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	if !prefersJSON(r) {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := json.Marshal(struct {
+		Error string   `json:"error"`
+		Allow []string `json:"allow"`
+	}{Error: "method not allowed", Allow: methods})
+	if err != nil {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// prefersJSON reports whether the request's Accept header ranks application/json above
+// text/plain. Without an Accept header, or on a tie, plain text wins.
+func prefersJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+	return acceptQuality(accept, "application/json") > acceptQuality(accept, "text/plain")
+}
+
+// acceptQuality returns the q-value the Accept header gives mediaType, taken from the
+// most specific matching media range, or 0 if no range matches.
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, item := range strings.Split(accept, ",") {
+		rng, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		var spec int
+		switch rng {
+		case mediaType:
+			spec = 2
+		case typ + "/*":
+			spec = 1
+		case "*/*":
+			spec = 0
+		default:
+			continue
+		}
+		if spec <= specificity {
+			continue
+		}
+		itemQ := 1.0
+		if v, ok := params["q"]; ok {
+			if itemQ, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		q, specificity = itemQ, spec
+	}
+	return q
+}
@@ -0,0 +1,85 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_MethodNotAllowed_RouteAllow(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{RetiredLogs: []string{"old_log"}}
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), NewMetrics(prometheus.NewRegistry()), nil, nil, nil)
+
+	tests := []struct {
+		path      string
+		wantAllow string
+	}{
+		{path: "/metrics", wantAllow: "GET, HEAD"},
+		{path: "/old_log/tile/0/000", wantAllow: "GET, HEAD"},
+		{path: "/old_log/ct/v1/add-chain", wantAllow: "GET, HEAD, POST"},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodPut, tc.path, nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("PUT %s status = %d, want %d", tc.path, w.Code, http.StatusMethodNotAllowed)
+		}
+		if got := w.Header().Get("Allow"); got != tc.wantAllow {
+			t.Errorf("PUT %s Allow = %q, want %q", tc.path, got, tc.wantAllow)
+		}
+	}
+}
+
+func TestServer_MethodNotAllowed_Negotiation(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(Config{}, NewLogger(LoggerOptions{}), NewMetrics(prometheus.NewRegistry()), nil, nil, nil)
+
+	tests := []struct {
+		accept   string
+		wantJSON bool
+	}{
+		{accept: "", wantJSON: false},
+		{accept: "application/json", wantJSON: true},
+		{accept: "text/plain, application/json", wantJSON: false},
+		{accept: "text/plain;q=0.5, application/json", wantJSON: true},
+		{accept: "application/*;q=0.9, */*;q=0.1", wantJSON: true},
+		{accept: "text/*, application/json;q=0.2", wantJSON: false},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/metrics", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("Accept %q: status = %d, want %d", tc.accept, w.Code, http.StatusMethodNotAllowed)
+		}
+		isJSON := strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+		if isJSON != tc.wantJSON {
+			t.Errorf("Accept %q: Content-Type = %q, want JSON %v", tc.accept, w.Header().Get("Content-Type"), tc.wantJSON)
+			continue
+		}
+		if !isJSON {
+			continue
+		}
+		var body struct {
+			Error string   `json:"error"`
+			Allow []string `json:"allow"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Accept %q: body %q: %v", tc.accept, w.Body.String(), err)
+		}
+		if !slices.Equal(body.Allow, []string{http.MethodGet, http.MethodHead}) {
+			t.Errorf("Accept %q: allow = %v, want [GET HEAD]", tc.accept, body.Allow)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package ctarchiveserve
 
 import (
+	"container/list"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // Metrics provides low-cardinality Prometheus metrics for ct-archive-serve.
@@ -11,37 +16,93 @@ import (
 // Per specs/001-ct-archive-serve/spec.md (NFR-009), metrics are limited to:
 // - `/logs.v3.json` aggregate
 // - per-`<log>` aggregates for all `/<log>/...` requests combined
+// - a request count by method, bounded to GET, HEAD and "other"
 //
 // Metrics MUST NOT be labeled by status code, endpoint name, or full request path.
 type Metrics struct {
+	// gatherer is the registry the metrics are registered with, served on /metrics.
+	gatherer prometheus.Gatherer
+
 	logListV3JSONRequestsTotal   prometheus.Counter
 	logListV3JSONRequestDuration prometheus.Histogram
 
 	logRequestsTotal   *prometheus.CounterVec
 	logRequestDuration *prometheus.HistogramVec
 
+	// logRequestDurationSummary is optional (nil unless MetricsOptions.Summaries is set).
+	logRequestDurationSummary *prometheus.SummaryVec
+
+	// maxLogSeries caps the logs with per-log series; 0 means no cap. logSeries and
+	// logSeriesLRU (front = most recently observed) track them under logSeriesMu.
+	maxLogSeries       int
+	logSeriesMu        sync.Mutex
+	logSeries          map[string]*list.Element
+	logSeriesLRU       *list.List
+	logSeriesEvictions prometheus.Counter
+
+	// requestsByMethod counts all requests by methodLabel (GET, HEAD or other).
+	requestsByMethod *prometheus.CounterVec
+
 	archiveLogsDiscovered     prometheus.Gauge
 	archiveZipPartsDiscovered prometheus.Gauge
+	archiveRefreshRetries     prometheus.Counter
+	archiveRefreshTimeouts    prometheus.Counter
+	archiveLogsAdded          prometheus.Counter
+	archiveLogsRemoved        prometheus.Counter
+	archiveLogCollisions      prometheus.Counter
 
 	zipCacheOpen       prometheus.Gauge
 	zipCacheEvictions  prometheus.Counter
 	zipIntegrityPassed prometheus.Counter
 	zipIntegrityFailed prometheus.Counter
+	tileSizeMismatch   prometheus.Counter
+
+	// logCircuits counts logs by circuit breaker state ("open" or "half_open").
+	logCircuits *prometheus.GaugeVec
+
+	archiveMountHealthy prometheus.Gauge
 
 	entryCacheHits      prometheus.Counter
 	entryCacheMisses    prometheus.Counter
 	entryCacheEvictions prometheus.Counter
 	entryCacheBytes     prometheus.Gauge
 	entryCacheItems     prometheus.Gauge
+
+	// contentSource counts zip entries opened for responses by ContentSource.
+	contentSource *prometheus.CounterVec
 }
 
-// NewMetrics constructs and registers the service's metrics.
+// MetricsOptions controls optional (more expensive) metrics.
+type MetricsOptions struct {
+	// Summaries enables a per-log request duration SummaryVec with p50/p90/p99
+	// objectives in addition to the default histogram (CT_METRICS_SUMMARIES).
+	Summaries bool
+
+	// Runtime registers the standard Go runtime (go_*) and process (process_*)
+	// collectors (CT_METRICS_RUNTIME). It is ignored for prometheus.DefaultRegisterer,
+	// which already includes them.
+	Runtime bool
+
+	// MaxLogSeries caps how many logs have per-log request series at once; observing a
+	// new log beyond it drops the series of the least recently observed one
+	// (CT_METRICS_MAX_LOG_SERIES). 0 means no cap.
+	MaxLogSeries int
+}
+
+// NewMetrics constructs and registers the service's metrics with default options.
 func NewMetrics(reg prometheus.Registerer) *Metrics {
+	return NewMetricsWithOptions(reg, MetricsOptions{})
+}
+
+// NewMetricsWithOptions constructs and registers the service's metrics, including
+// any optional metrics enabled in opts.
+func NewMetricsWithOptions(reg prometheus.Registerer, opts MetricsOptions) *Metrics {
 	if reg == nil {
 		reg = prometheus.DefaultRegisterer
 	}
 
 	m := &Metrics{
+		gatherer: prometheus.DefaultGatherer,
 		logListV3JSONRequestsTotal: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: "ct_archive_serve",
 			Subsystem: "http",
@@ -68,6 +129,18 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Help:      "Duration of requests under /<log>/... in seconds aggregated by log.",
 			Buckets:   prometheus.DefBuckets,
 		}, []string{"log"}),
+		logSeriesEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Subsystem: "http",
+			Name:      "log_series_evictions_total",
+			Help:      "Total number of logs whose per-log request series were dropped to stay within CT_METRICS_MAX_LOG_SERIES.",
+		}),
+		requestsByMethod: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Subsystem: "http",
+			Name:      "requests_by_method_total",
+			Help:      "Total number of HTTP requests by method (GET, HEAD, or other for all disallowed methods).",
+		}, []string{"method"}),
 
 		archiveLogsDiscovered: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "ct_archive_serve",
@@ -79,6 +152,31 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Name:      "archive_zip_parts_discovered",
 			Help:      "Number of zip parts currently discovered across all logs by the archive index.",
 		}),
+		archiveRefreshRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "archive_refresh_retries_total",
+			Help:      "Total number of directory reads retried after a transient error during archive discovery.",
+		}),
+		archiveRefreshTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "archive_refresh_timeouts_total",
+			Help:      "Total number of archive refreshes abandoned after CT_ARCHIVE_REFRESH_TIMEOUT.",
+		}),
+		archiveLogsAdded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "logs_added_total",
+			Help:      "Total number of logs that appeared in the archive between refreshes.",
+		}),
+		archiveLogsRemoved: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "logs_removed_total",
+			Help:      "Total number of logs that disappeared from the archive between refreshes.",
+		}),
+		archiveLogCollisions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "log_collisions_total",
+			Help:      "Total number of logs left out of the archive index because several folders map to their name (CT_ARCHIVE_COLLISION_POLICY=skip).",
+		}),
 
 		zipCacheOpen: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "ct_archive_serve",
@@ -100,6 +198,23 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Name:      "zip_integrity_failed_total",
 			Help:      "Total number of zip parts that failed structural integrity checks.",
 		}),
+		tileSizeMismatch: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "tile_size_mismatch_total",
+			Help:      "Total number of served tiles whose size did not match the tile geometry (CT_VALIDATE_TILE_SIZE, CT_VALIDATE_DATA_TILES).",
+		}),
+
+		logCircuits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "log_circuit_open",
+			Help:      "Current number of logs whose circuit breaker is open or half-open, by state (CT_LOG_CIRCUIT_FAILURES).",
+		}, []string{"state"}),
+
+		archiveMountHealthy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "archive_mount_healthy",
+			Help:      "1 if the last archive mount probe answered within CT_ARCHIVE_PROBE_TIMEOUT, else 0 (always 1 without CT_ARCHIVE_PROBE_INTERVAL).",
+		}),
 
 		entryCacheHits: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: "ct_archive_serve",
@@ -126,6 +241,11 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Name:      "entry_cache_items",
 			Help:      "Current number of items in the entry content cache.",
 		}),
+		contentSource: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ct_archive_serve",
+			Name:      "content_source_total",
+			Help:      "Total number of zip entries opened for responses by source (entry_cache, part_cache, cold or upstream).",
+		}, []string{"source"}),
 	}
 
 	reg.MustRegister(
@@ -133,36 +253,151 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		m.logListV3JSONRequestDuration,
 		m.logRequestsTotal,
 		m.logRequestDuration,
+		m.logSeriesEvictions,
+		m.requestsByMethod,
 		m.archiveLogsDiscovered,
 		m.archiveZipPartsDiscovered,
+		m.archiveRefreshRetries,
+		m.archiveRefreshTimeouts,
+		m.archiveLogsAdded,
+		m.archiveLogsRemoved,
+		m.archiveLogCollisions,
 		m.zipCacheOpen,
 		m.zipCacheEvictions,
 		m.zipIntegrityPassed,
 		m.zipIntegrityFailed,
+		m.tileSizeMismatch,
+		m.logCircuits,
+		m.archiveMountHealthy,
 		m.entryCacheHits,
 		m.entryCacheMisses,
 		m.entryCacheEvictions,
 		m.entryCacheBytes,
 		m.entryCacheItems,
+		m.contentSource,
 	)
+	m.SetLogCircuits(0, 0)
+	m.SetArchiveMountHealthy(true)
+	// Export all three method series from the start, so the GET/HEAD split is visible
+	// (as zero) before the first request of each kind.
+	for _, method := range []string{http.MethodGet, http.MethodHead, methodLabelOther} {
+		m.requestsByMethod.WithLabelValues(method)
+	}
+	for _, src := range []ContentSource{ContentSourceEntryCache, ContentSourcePartCache, ContentSourceCold, ContentSourceUpstream} {
+		m.contentSource.WithLabelValues(src.String())
+	}
+
+	if opts.MaxLogSeries > 0 {
+		m.maxLogSeries = opts.MaxLogSeries
+		m.logSeries = make(map[string]*list.Element)
+		m.logSeriesLRU = list.New()
+	}
+
+	if opts.Summaries {
+		m.logRequestDurationSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  "ct_archive_serve",
+			Subsystem:  "http",
+			Name:       "log_request_duration_summary",
+			Help:       "Duration quantiles of requests under /<log>/... in seconds aggregated by log.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, []string{"log"})
+		reg.MustRegister(m.logRequestDurationSummary)
+	}
+
+	// A custom registry (as main uses) is served instead of the default one.
+	if g, ok := reg.(prometheus.Gatherer); ok {
+		m.gatherer = g
+	}
+
+	if opts.Runtime && reg != prometheus.DefaultRegisterer {
+		reg.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
 
 	return m
 }
 
-func (m *Metrics) ObserveLogListV3JSONRequest(d time.Duration) {
+// Gatherer returns the registry the metrics are registered with, or the default one for
+// a nil Metrics.
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	if m == nil {
+		return prometheus.DefaultGatherer
+	}
+	return m.gatherer
+}
+
+// ObserveLogListV3JSONRequest records a /logs.v3.json request. A non-empty traceID is
+// attached to the duration observation as an exemplar.
+func (m *Metrics) ObserveLogListV3JSONRequest(d time.Duration, traceID string) {
 	if m == nil {
 		return
 	}
 	m.logListV3JSONRequestsTotal.Inc()
-	m.logListV3JSONRequestDuration.Observe(d.Seconds())
+	observeWithTrace(m.logListV3JSONRequestDuration, d.Seconds(), traceID)
 }
 
-func (m *Metrics) ObserveLogRequest(log string, d time.Duration) {
+// ObserveLogRequest records a request under /<log>/. A non-empty traceID is attached to
+// the duration histogram observation as an exemplar. Callers should only pass logs in the
+// archive index, since every log name gets its own series.
+func (m *Metrics) ObserveLogRequest(log string, d time.Duration, traceID string) {
 	if m == nil {
 		return
 	}
+	if m.maxLogSeries > 0 {
+		// Held while observing, so an eviction cannot race a new series for the same log.
+		m.logSeriesMu.Lock()
+		defer m.logSeriesMu.Unlock()
+		m.trackLogSeriesLocked(log)
+	}
 	m.logRequestsTotal.WithLabelValues(log).Inc()
-	m.logRequestDuration.WithLabelValues(log).Observe(d.Seconds())
+	observeWithTrace(m.logRequestDuration.WithLabelValues(log), d.Seconds(), traceID)
+	if m.logRequestDurationSummary != nil {
+		m.logRequestDurationSummary.WithLabelValues(log).Observe(d.Seconds())
+	}
+}
+
+// trackLogSeriesLocked marks log as the most recently observed, first dropping the series
+// of the least recently observed logs while log would exceed maxLogSeries. Caller must
+// hold logSeriesMu.
+func (m *Metrics) trackLogSeriesLocked(log string) {
+	if elem, ok := m.logSeries[log]; ok {
+		m.logSeriesLRU.MoveToFront(elem)
+		return
+	}
+	for m.logSeriesLRU.Len() >= m.maxLogSeries {
+		idle, _ := m.logSeriesLRU.Remove(m.logSeriesLRU.Back()).(string) //nolint:errcheck // internal invariant: LRU list only contains log names
+		delete(m.logSeries, idle)
+		m.logRequestsTotal.DeleteLabelValues(idle)
+		m.logRequestDuration.DeleteLabelValues(idle)
+		if m.logRequestDurationSummary != nil {
+			m.logRequestDurationSummary.DeleteLabelValues(idle)
+		}
+		m.logSeriesEvictions.Inc()
+	}
+	m.logSeries[log] = m.logSeriesLRU.PushFront(log)
+}
+
+// methodLabelOther aggregates every method other than GET and HEAD, keeping the method
+// label bounded regardless of what clients send.
+const methodLabelOther = "other"
+
+// methodLabel maps a request method to its requests_by_method_total label value.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return method
+	default:
+		return methodLabelOther
+	}
+}
+
+func (m *Metrics) IncRequestMethod(method string) {
+	if m == nil {
+		return
+	}
+	m.requestsByMethod.WithLabelValues(methodLabel(method)).Inc()
 }
 
 func (m *Metrics) SetArchiveDiscovered(logCount, zipPartCount int) {
@@ -173,6 +408,42 @@ func (m *Metrics) SetArchiveDiscovered(logCount, zipPartCount int) {
 	m.archiveZipPartsDiscovered.Set(float64(zipPartCount))
 }
 
+func (m *Metrics) IncArchiveRefreshRetries() {
+	if m == nil {
+		return
+	}
+	m.archiveRefreshRetries.Inc()
+}
+
+func (m *Metrics) IncArchiveLogCollisions() {
+	if m == nil {
+		return
+	}
+	m.archiveLogCollisions.Inc()
+}
+
+func (m *Metrics) IncArchiveRefreshTimeouts() {
+	if m == nil {
+		return
+	}
+	m.archiveRefreshTimeouts.Inc()
+}
+
+func (m *Metrics) IncTileSizeMismatch() {
+	if m == nil {
+		return
+	}
+	m.tileSizeMismatch.Inc()
+}
+
+func (m *Metrics) AddArchiveLogsChanged(added, removed int) {
+	if m == nil {
+		return
+	}
+	m.archiveLogsAdded.Add(float64(added))
+	m.archiveLogsRemoved.Add(float64(removed))
+}
+
 func (m *Metrics) SetZipCacheOpen(n int) {
 	if m == nil {
 		return
@@ -194,6 +465,27 @@ func (m *Metrics) IncZipIntegrityPassed() {
 	m.zipIntegrityPassed.Inc()
 }
 
+// SetLogCircuits sets the number of logs whose circuit breaker is open and half-open.
+func (m *Metrics) SetLogCircuits(open, halfOpen int) {
+	if m == nil {
+		return
+	}
+	m.logCircuits.WithLabelValues("open").Set(float64(open))
+	m.logCircuits.WithLabelValues("half_open").Set(float64(halfOpen))
+}
+
+// SetArchiveMountHealthy records the result of the last archive mount probe.
+func (m *Metrics) SetArchiveMountHealthy(healthy bool) {
+	if m == nil {
+		return
+	}
+	if healthy {
+		m.archiveMountHealthy.Set(1)
+	} else {
+		m.archiveMountHealthy.Set(0)
+	}
+}
+
 func (m *Metrics) IncZipIntegrityFailed() {
 	if m == nil {
 		return
@@ -201,6 +493,23 @@ func (m *Metrics) IncZipIntegrityFailed() {
 	m.zipIntegrityFailed.Inc()
 }
 
+// zipCacheCounters returns the current zip cache eviction and integrity pass/fail totals,
+// for periodic cache statistics logging.
+func (m *Metrics) zipCacheCounters() (evictions, integrityPassed, integrityFailed uint64) {
+	if m == nil {
+		return 0, 0, 0
+	}
+	return counterTotal(m.zipCacheEvictions), counterTotal(m.zipIntegrityPassed), counterTotal(m.zipIntegrityFailed)
+}
+
+func counterTotal(c prometheus.Counter) uint64 {
+	var pb dto.Metric
+	if err := c.Write(&pb); err != nil {
+		return 0
+	}
+	return uint64(pb.GetCounter().GetValue())
+}
+
 // Entry content cache metrics.
 
 func (m *Metrics) IncEntryCacheHits() {
@@ -236,4 +545,11 @@ func (m *Metrics) SetEntryCacheItems(n int) {
 		return
 	}
 	m.entryCacheItems.Set(float64(n))
+}
+
+func (m *Metrics) IncContentSource(src ContentSource) {
+	if m == nil {
+		return
+	}
+	m.contentSource.WithLabelValues(src.String()).Inc()
 }
\ No newline at end of file
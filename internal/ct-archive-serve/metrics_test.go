@@ -1,6 +1,12 @@
 package ctarchiveserve
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,8 +20,8 @@ func TestMetrics_LowCardinality(t *testing.T) {
 	reg := prometheus.NewRegistry()
 	m := NewMetrics(reg)
 
-	m.ObserveLogListV3JSONRequest(120 * time.Millisecond)
-	m.ObserveLogRequest("example_log", 50*time.Millisecond)
+	m.ObserveLogListV3JSONRequest(120*time.Millisecond, "")
+	m.ObserveLogRequest("example_log", 50*time.Millisecond, "")
 
 	mfs, err := reg.Gather()
 	if err != nil {
@@ -31,6 +37,126 @@ func TestMetrics_LowCardinality(t *testing.T) {
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_http_loglistv3_json_request_duration_seconds", nil)
 }
 
+func TestMetrics_RequestsByMethod_BoundedLabels(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	server := NewServer(Config{}, nil, NewMetrics(reg), nil, nil, nil)
+	for _, method := range []string{http.MethodGet, http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete, "PROPFIND"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/logs.v3.json", nil))
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_http_requests_by_method_total", []string{"method"})
+
+	want := map[string]float64{"GET": 2, "HEAD": 1, "other": 3}
+	got := map[string]float64{}
+	for _, mf := range mfs {
+		if mf.GetName() != "ct_archive_serve_http_requests_by_method_total" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			got[m.Label[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("method label values = %v, want exactly %v", got, want)
+	}
+	for method, n := range want {
+		if got[method] != n {
+			t.Errorf("requests_by_method_total{method=%q} = %v, want %v", method, got[method], n)
+		}
+	}
+}
+
+func TestMetrics_Summaries(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	m := NewMetricsWithOptions(reg, MetricsOptions{Summaries: true})
+	m.ObserveLogRequest("example_log", 50*time.Millisecond, "")
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_http_log_request_duration_summary", []string{"log"})
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_http_log_request_duration_seconds", []string{"log"})
+
+	// Summaries are opt-in.
+	reg = prometheus.NewRegistry()
+	NewMetrics(reg).ObserveLogRequest("example_log", 50*time.Millisecond, "")
+	mfs, err = reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() == "ct_archive_serve_http_log_request_duration_summary" {
+			t.Fatalf("summary registered without MetricsOptions.Summaries")
+		}
+	}
+}
+
+func TestMetrics_Runtime(t *testing.T) {
+	t.Parallel()
+
+	hasGoroutines := func(reg *prometheus.Registry) bool {
+		t.Helper()
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		for _, mf := range mfs {
+			if mf.GetName() == "go_goroutines" {
+				return true
+			}
+		}
+		return false
+	}
+
+	reg := prometheus.NewRegistry()
+	NewMetricsWithOptions(reg, MetricsOptions{Runtime: true})
+	if !hasGoroutines(reg) {
+		t.Fatalf("go_goroutines not gathered with MetricsOptions.Runtime")
+	}
+
+	reg = prometheus.NewRegistry()
+	NewMetrics(reg)
+	if hasGoroutines(reg) {
+		t.Fatalf("go_goroutines gathered without MetricsOptions.Runtime")
+	}
+}
+
+func TestServer_MetricsEndpointServesRegistry(t *testing.T) {
+	t.Parallel()
+
+	// main registers everything on its own registry, not the default one; /metrics must
+	// serve that registry.
+	scrape := func(opts MetricsOptions) string {
+		t.Helper()
+		server := NewServer(Config{}, nil, NewMetricsWithOptions(prometheus.NewRegistry(), opts), nil, nil, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /metrics status = %d, want %d", w.Code, http.StatusOK)
+		}
+		return w.Body.String()
+	}
+
+	body := scrape(MetricsOptions{Runtime: true})
+	for _, name := range []string{"go_goroutines", "ct_archive_serve_http_requests_by_method_total"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("GET /metrics body lacks %s", name)
+		}
+	}
+	if body := scrape(MetricsOptions{}); strings.Contains(body, "go_goroutines") {
+		t.Errorf("GET /metrics body has go_goroutines without MetricsOptions.Runtime")
+	}
+}
+
 func TestMetrics_ResourceObservability_NoLabels(t *testing.T) {
 	t.Parallel()
 
@@ -44,10 +170,175 @@ func TestMetrics_ResourceObservability_NoLabels(t *testing.T) {
 
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_archive_logs_discovered", nil)
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_archive_zip_parts_discovered", nil)
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_archive_refresh_retries_total", nil)
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_archive_refresh_timeouts_total", nil)
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_logs_added_total", nil)
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_logs_removed_total", nil)
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_zip_cache_open", nil)
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_zip_cache_evictions_total", nil)
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_zip_integrity_passed_total", nil)
 	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_zip_integrity_failed_total", nil)
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_tile_size_mismatch_total", nil)
+}
+
+func TestMetrics_ObservedFromRequestPath(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint": []byte("test checkpoint data"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /test_log/checkpoint status = %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil))
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_http_log_requests_total", "test_log"); got != 2 {
+		t.Errorf("log_requests_total{log=test_log} = %v, want 2", got)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_http_loglistv3_json_requests_total", ""); got != 1 {
+		t.Errorf("loglistv3_json_requests_total = %v, want 1", got)
+	}
+}
+
+func TestMetrics_NotObservedForUnknownLog(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_test_log"))
+	mustCreateZip(t, filepath.Join(root, "ct_test_log", "000.zip"), map[string][]byte{
+		"checkpoint": []byte("test checkpoint data"),
+	})
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+
+	for _, path := range []string{"/no_such_log/checkpoint", "/no_such_log/tile/0/000", "/test_log/checkpoint"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, name := range []string{"ct_archive_serve_http_log_requests_total", "ct_archive_serve_http_log_request_duration_seconds"} {
+		if got := metricLogLabels(mfs, name); !slices.Equal(got, []string{"test_log"}) {
+			t.Errorf("%s logs = %v, want [test_log]", name, got)
+		}
+	}
+}
+
+func TestMetrics_MaxLogSeries(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	m := NewMetricsWithOptions(reg, MetricsOptions{MaxLogSeries: 2, Summaries: true})
+	for _, log := range []string{"a_log", "b_log", "a_log", "c_log", "a_log"} {
+		m.ObserveLogRequest(log, time.Millisecond, "")
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	// b_log was the least recently observed when c_log arrived.
+	for _, name := range []string{
+		"ct_archive_serve_http_log_requests_total",
+		"ct_archive_serve_http_log_request_duration_seconds",
+		"ct_archive_serve_http_log_request_duration_summary",
+	} {
+		if got, want := metricLogLabels(mfs, name), []string{"a_log", "c_log"}; !slices.Equal(got, want) {
+			t.Errorf("%s logs = %v, want %v", name, got, want)
+		}
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_http_log_requests_total", "a_log"); got != 3 {
+		t.Errorf("log_requests_total{log=a_log} = %v, want 3", got)
+	}
+	if got := counterValue(t, mfs, "ct_archive_serve_http_log_series_evictions_total", ""); got != 1 {
+		t.Errorf("log_series_evictions_total = %v, want 1", got)
+	}
+}
+
+// metricLogLabels returns the sorted `log` label values of the series in family name.
+func metricLogLabels(mfs []*dto.MetricFamily, name string) []string {
+	var logs []string
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.Metric {
+			for _, lp := range m.Label {
+				if lp.GetName() == "log" {
+					logs = append(logs, lp.GetValue())
+				}
+			}
+		}
+	}
+	slices.Sort(logs)
+	return logs
+}
+
+// counterValue returns the value of the counter series in family name whose `log`
+// label equals log (or the unlabeled series when log is empty).
+func counterValue(t *testing.T, mfs []*dto.MetricFamily, name, log string) float64 {
+	t.Helper()
+
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.Metric {
+			got := ""
+			for _, lp := range m.Label {
+				if lp.GetName() == "log" {
+					got = lp.GetValue()
+				}
+			}
+			if got == log {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	t.Fatalf("metric %q{log=%q} not found", name, log)
+	return 0
 }
 
 func assertMetricFamilyLabelNames(t *testing.T, mfs []*dto.MetricFamily, name string, want []string) {
@@ -105,3 +396,67 @@ func stringSlicesEqualUnordered(a, b []string) bool {
 	return true
 }
 
+func TestMetrics_ContentSource(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/0/000":    make([]byte, fullTileWidth*tileHashSize),
+		"tile/data/000": x509TileLeaf(1),
+	})
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	zipPartCache := NewZipPartCache(16, metrics, 4)
+	t.Cleanup(func() { _ = zipPartCache.Close() })
+	zr.SetZipPartCache(zipPartCache)
+	zr.SetEntryContentCache(NewEntryContentCache(1<<20, metrics))
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+
+	// The first tile opens the zip part from disk and caches its content, the repeat is
+	// served from the entry cache, and the other tile reuses the open zip part.
+	for _, path := range []string{"/test_log/tile/0/000", "/test_log/tile/0/000", "/test_log/tile/data/000"} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	assertMetricFamilyLabelNames(t, mfs, "ct_archive_serve_content_source_total", []string{"source"})
+
+	want := map[string]float64{"entry_cache": 1, "part_cache": 1, "cold": 1, "upstream": 0}
+	got := map[string]float64{}
+	for _, mf := range mfs {
+		if mf.GetName() != "ct_archive_serve_content_source_total" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			got[m.Label[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("source label values = %v, want exactly %v", got, want)
+	}
+	for source, n := range want {
+		if got[source] != n {
+			t.Errorf("content_source_total{source=%q} = %v, want %v", source, got[source], n)
+		}
+	}
+}
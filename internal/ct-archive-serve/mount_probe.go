@@ -0,0 +1,139 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// errMountProbeStuck is reported while the previous probe is still blocked.
+var errMountProbeStuck = errors.New("previous probe still blocked")
+
+// MountProbe periodically checks that the archive mount answers (CT_ARCHIVE_PROBE_INTERVAL),
+// independently of the archive refresh loop. On a wedged NFS mount a refresh can block in
+// readDir indefinitely and the index just goes stale; the probe bounds each check with
+// CT_ARCHIVE_PROBE_TIMEOUT, so /readyz and ct_archive_serve_archive_mount_healthy turn
+// within one interval plus the timeout.
+//
+// Each probe stats CT_ARCHIVE_PATH, or reads CT_ARCHIVE_PROBE_FILE when set. A probe
+// blocked in the kernel cannot be interrupted: it is abandoned on timeout, and until it
+// returns later probes fail without starting another, so a hung mount does not pile up
+// goroutines.
+type MountProbe struct {
+	path     string
+	interval time.Duration
+	timeout  time.Duration
+	logger   *slog.Logger
+	metrics  *Metrics
+
+	// check runs one probe against path; replaced in tests.
+	check func(path string) error
+
+	healthy  atomic.Bool
+	inflight atomic.Bool // a probe, possibly abandoned, is still running
+}
+
+// NewMountProbe constructs a MountProbe from cfg. The mount counts as healthy until the
+// first probe fails.
+func NewMountProbe(cfg Config, logger *slog.Logger, metrics *Metrics) *MountProbe {
+	p := &MountProbe{
+		path:     cfg.ArchivePath,
+		interval: cfg.ArchiveProbeInterval,
+		timeout:  cfg.ArchiveProbeTimeout,
+		logger:   logger,
+		metrics:  metrics,
+		check:    statPath,
+	}
+	if cfg.ArchiveProbeFile != "" {
+		p.path = filepath.Join(cfg.ArchivePath, cfg.ArchiveProbeFile)
+		p.check = readCanary
+	}
+	p.healthy.Store(true)
+	return p
+}
+
+// Start probes every interval until ctx is done. It is a no-op when the interval is <= 0.
+func (p *MountProbe) Start(ctx context.Context) {
+	if p == nil || p.interval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(p.interval)
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				p.probeOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Healthy reports the result of the last probe. A nil MountProbe is always healthy.
+func (p *MountProbe) Healthy() bool {
+	return p == nil || p.healthy.Load()
+}
+
+// probeOnce runs one timeout-bounded probe and records the result.
+func (p *MountProbe) probeOnce(ctx context.Context) bool {
+	err := p.run(ctx)
+	healthy := err == nil
+	p.metrics.SetArchiveMountHealthy(healthy)
+	if p.healthy.Swap(healthy) != healthy && p.logger != nil {
+		if healthy {
+			p.logger.Info("Archive mount probe recovered, reporting ready", "path", p.path)
+		} else {
+			p.logger.Warn("Archive mount probe failed, reporting not ready", "path", p.path, "timeout", p.timeout, "error", err)
+		}
+	}
+	return healthy
+}
+
+func (p *MountProbe) run(ctx context.Context) error {
+	if !p.inflight.CompareAndSwap(false, true) {
+		return errMountProbeStuck
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer p.inflight.Store(false)
+		done <- p.check(p.path)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // logged with the probe path
+	}
+}
+
+func statPath(path string) error {
+	_, err := os.Stat(path)
+	return err //nolint:wrapcheck // logged with the probe path
+}
+
+// readCanary opens the canary file and reads its first byte, which unlike a stat cannot
+// be answered from cached attributes alone.
+func readCanary(path string) error {
+	//nolint:gosec // G304: path comes from operator configuration, not user input
+	f, err := os.Open(path)
+	if err != nil {
+		return err //nolint:wrapcheck // logged with the probe path
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return err //nolint:wrapcheck // logged with the probe path
+	}
+	return nil
+}
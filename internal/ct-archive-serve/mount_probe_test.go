@@ -0,0 +1,119 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMountProbe_HangingStat(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	cfg := Config{ArchivePath: t.TempDir(), ArchiveProbeInterval: time.Hour, ArchiveProbeTimeout: 20 * time.Millisecond}
+	probe := NewMountProbe(cfg, nil, metrics)
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	probe.check = func(string) error {
+		calls.Add(1)
+		<-release // a stat on a wedged mount
+		return nil
+	}
+
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	server := NewServer(cfg, nil, metrics, archiveIndex, nil, nil)
+	server.SetMountProbe(probe)
+	readyz := func() int {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return w.Code
+	}
+	healthyGauge := func() float64 {
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		for _, mf := range mfs {
+			if mf.GetName() == "ct_archive_serve_archive_mount_healthy" {
+				return mf.GetMetric()[0].GetGauge().GetValue()
+			}
+		}
+		t.Fatalf("ct_archive_serve_archive_mount_healthy not found")
+		return 0
+	}
+
+	if code := readyz(); code != http.StatusOK {
+		t.Fatalf("/readyz before any probe = %d, want %d", code, http.StatusOK)
+	}
+
+	start := time.Now()
+	if probe.probeOnce(context.Background()) {
+		t.Fatalf("probeOnce() = true with a hanging stat, want false")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probeOnce() took %v, want it bounded by the timeout", elapsed)
+	}
+	if code := readyz(); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz with a hung mount = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if got := healthyGauge(); got != 0 {
+		t.Errorf("archive_mount_healthy = %v, want 0", got)
+	}
+
+	// While the stat is still blocked, probes fail without starting another one.
+	if probe.probeOnce(context.Background()) {
+		t.Fatalf("probeOnce() = true while the previous stat hangs, want false")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("stat calls = %d, want 1 (no pile-up behind a hung stat)", n)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for !probe.probeOnce(context.Background()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("probeOnce() still failing after the stat returned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := readyz(); code != http.StatusOK {
+		t.Errorf("/readyz after recovery = %d, want %d", code, http.StatusOK)
+	}
+	if got := healthyGauge(); got != 1 {
+		t.Errorf("archive_mount_healthy = %v, want 1", got)
+	}
+}
+
+func TestMountProbe_CanaryFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	cfg := Config{ArchivePath: root, ArchiveProbeTimeout: time.Second, ArchiveProbeFile: "canary"}
+	probe := NewMountProbe(cfg, nil, nil)
+
+	if probe.probeOnce(context.Background()) {
+		t.Fatalf("probeOnce() = true without the canary file, want false")
+	}
+	mustWriteFile(t, filepath.Join(root, "canary"), []byte("ok"))
+	if !probe.probeOnce(context.Background()) {
+		t.Fatalf("probeOnce() = false with the canary file, want true")
+	}
+	if err := os.Remove(filepath.Join(root, "canary")); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if probe.probeOnce(context.Background()) {
+		t.Fatalf("probeOnce() = true after the canary file was removed, want false")
+	}
+}
@@ -0,0 +1,89 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// defaultOperatorName is the synthetic operator of logs.v3.json. It holds every log
+// unless CT_OPERATOR_MAP assigns them to real operators.
+const defaultOperatorName = "ct-archive-serve"
+
+// LogOperator is the operator a CT_OPERATOR_MAP file assigns to a log.
+type LogOperator struct {
+	Name  string   `json:"name"`
+	Email []string `json:"email"`
+}
+
+// LoadOperatorMap reads a CT_OPERATOR_MAP file: a JSON object mapping log names to
+// operators, e.g. {"argon2025h1": {"name": "Google", "email": ["ct@example.com"]}}.
+func LoadOperatorMap(path string) (map[string]LogOperator, error) {
+	//nolint:gosec // G304: path comes from operator configuration, not user input
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read operator map: %w", err)
+	}
+	var m map[string]LogOperator
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse operator map: %w", err)
+	}
+	for log, op := range m {
+		if op.Name == "" {
+			return nil, fmt.Errorf("operator map: log %q has no operator name", log)
+		}
+	}
+	return m, nil
+}
+
+// groupByOperator splits tiledLogs (sorted by log name) into logs.v3.json operators per
+// operators. Mapped operators come first, sorted by name, with the emails of all their
+// mappings; unmapped logs fall into the default operator, which is always present when
+// there is nothing else to list.
+func groupByOperator(tiledLogs []LogListV3JSONTiledLog, operators map[string]LogOperator) []LogListV3JSONOperator {
+	byName := make(map[string]*LogListV3JSONOperator)
+	var unmapped []LogListV3JSONTiledLog
+	for _, tlog := range tiledLogs {
+		mapped, ok := operators[tlog.LogName]
+		if !ok {
+			unmapped = append(unmapped, tlog)
+			continue
+		}
+		op, ok := byName[mapped.Name]
+		if !ok {
+			op = &LogListV3JSONOperator{Name: mapped.Name, Email: []string{}, Logs: []interface{}{}}
+			byName[mapped.Name] = op
+		}
+		for _, email := range mapped.Email {
+			if !slices.Contains(op.Email, email) {
+				op.Email = append(op.Email, email)
+			}
+		}
+		op.TiledLogs = append(op.TiledLogs, tlog)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]LogListV3JSONOperator, 0, len(names)+1)
+	for _, name := range names {
+		out = append(out, *byName[name])
+	}
+	if len(unmapped) > 0 || len(out) == 0 {
+		if unmapped == nil {
+			unmapped = []LogListV3JSONTiledLog{}
+		}
+		out = append(out, LogListV3JSONOperator{
+			Name:      defaultOperatorName,
+			Email:     []string{},
+			Logs:      []interface{}{},
+			TiledLogs: unmapped,
+		})
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package ctarchiveserve
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/certificate-transparency-go/loglist3"
+)
+
+func TestLogListV3JSONBuilder_OperatorMap(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, log := range []string{"argon", "nimbus", "xenon", "orphan"} {
+		folder := filepath.Join(root, "ct_"+log)
+		mustMkdir(t, folder)
+		mustCreateZip(t, filepath.Join(folder, "000.zip"), map[string][]byte{
+			"log.v3.json": []byte(`{"description":"` + log + `","log_id":"dGVzdF9sb2dfaWRfMzJfYnl0ZXNfbG9uZyEh","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"state":{}}`),
+		})
+	}
+	mapPath := filepath.Join(root, "operators.json")
+	mustWriteFile(t, mapPath, []byte(`{
+		"argon":  {"name": "Google", "email": ["google@example.com"]},
+		"xenon":  {"name": "Google", "email": ["google@example.com", "xenon@example.com"]},
+		"nimbus": {"name": "Cloudflare", "email": []}
+	}`))
+
+	operators, err := LoadOperatorMap(mapPath)
+	if err != nil {
+		t.Fatalf("LoadOperatorMap() error = %v", err)
+	}
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+	builder.SetOperatorMap(operators)
+
+	snap, err := builder.BuildSnapshot("https://example.com")
+	if err != nil {
+		t.Fatalf("BuildSnapshot() error = %v", err)
+	}
+	body, err := json.Marshal(builder.withBaseURL(snap, "https://mirror.example"))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	logList, err := loglist3.NewFromJSON(body)
+	if err != nil {
+		t.Fatalf("loglist3.NewFromJSON() error = %v", err)
+	}
+
+	type opSummary struct {
+		emails int
+		logs   []string
+	}
+	got := make(map[string]opSummary)
+	var order []string
+	for _, op := range logList.Operators {
+		order = append(order, op.Name)
+		s := opSummary{emails: len(op.Email)}
+		for _, tl := range op.TiledLogs {
+			s.logs = append(s.logs, tl.Description)
+			if want := "https://mirror.example/" + tl.Description; tl.MonitoringURL != want {
+				t.Errorf("%s monitoring_url = %q, want %q", tl.Description, tl.MonitoringURL, want)
+			}
+		}
+		got[op.Name] = s
+	}
+
+	if want := []string{"Cloudflare", "Google", defaultOperatorName}; !slices.Equal(order, want) {
+		t.Fatalf("operators = %v, want %v", order, want)
+	}
+	if g := got["Google"]; g.emails != 2 || !slices.Equal(g.logs, []string{"argon", "xenon"}) {
+		t.Errorf("Google = %+v, want 2 emails and logs [argon xenon]", g)
+	}
+	if c := got["Cloudflare"]; c.emails != 0 || !slices.Equal(c.logs, []string{"nimbus"}) {
+		t.Errorf("Cloudflare = %+v, want logs [nimbus]", c)
+	}
+	if d := got[defaultOperatorName]; !slices.Equal(d.logs, []string{"orphan"}) {
+		t.Errorf("%s = %+v, want the unmapped log [orphan]", defaultOperatorName, d)
+	}
+}
+
+func TestLoadOperatorMap_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"not-json.json":   `argon: Google`,
+		"empty-name.json": `{"argon": {"name": ""}}`,
+	} {
+		path := filepath.Join(dir, name)
+		mustWriteFile(t, path, []byte(content))
+		if _, err := LoadOperatorMap(path); err == nil {
+			t.Errorf("LoadOperatorMap(%s) error = nil, want error", name)
+		}
+	}
+	if _, err := LoadOperatorMap(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("LoadOperatorMap(missing) error = nil, want error")
+	}
+}
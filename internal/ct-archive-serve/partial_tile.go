@@ -0,0 +1,72 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// CachedEntry returns entryName of zipPath from the entry content cache, without any
+// zip I/O. It returns false when the entry is not cached or there is no entry cache.
+func (zr *ZipReader) CachedEntry(zipPath, entryName string) ([]byte, bool) {
+	if zr == nil || zr.entryCache == nil || !zr.integrity.allowRead(zipPath) {
+		return nil, false
+	}
+	return zr.entryCache.Get(zipPath, entryName)
+}
+
+// fullTileEntryPath returns the entry path of the full tile a partial tile route is a
+// prefix of: tile/<L>/<N>.p/<W> becomes tile/<L>/<N>.
+func fullTileEntryPath(route Route) string {
+	full, _, _ := strings.Cut(route.EntryPath, ".p/")
+	return full
+}
+
+// slicePartialTile returns the partial tile at route as the prefix of full, the full
+// tile with the same index: the first W hashes of a hash tile, or the first W entries
+// of a data tile. It returns false if full is too short or not a well-formed data tile.
+func slicePartialTile(route Route, full []byte, hashBytes int) ([]byte, bool) {
+	width := tileWidth(route)
+	if route.Kind == RouteDataTile {
+		rest := full
+		for range width {
+			var ok bool
+			if rest, ok = skipTileLeaf(rest); !ok {
+				return nil, false
+			}
+		}
+		return full[:len(full)-len(rest)], true
+	}
+	if len(full) != fullTileWidth*hashBytes {
+		return nil, false
+	}
+	return full[:width*hashBytes], true
+}
+
+// partialFromFull serves a partial tile by slicing the full tile in the entry content
+// cache (CT_PARTIAL_FROM_FULL), so the up to 255 distinct widths of a tile do not each
+// cost a cache miss, a decompression and a cache entry of their own. It reports false,
+// leaving the response untouched, when the full tile is not cached or cannot be sliced;
+// the stored partial is then served as usual.
+func (s *Server) partialFromFull(w http.ResponseWriter, r *http.Request, route Route, zipPath string) bool {
+	if !s.cfg.PartialFromFull || !route.TileIsPartial {
+		return false
+	}
+	full, ok := s.zipReader.CachedEntry(zipPath, s.zipEntryName(fullTileEntryPath(route)))
+	if !ok {
+		return false
+	}
+	hashBytes := s.cfg.TileHashBytes
+	if hashBytes <= 0 {
+		hashBytes = DefaultTileHashBytes
+	}
+	data, ok := slicePartialTile(route, full, hashBytes)
+	if !ok {
+		return false
+	}
+
+	modtime := s.entryMetadata(w, r, zipPath, s.zipEntryName(route.EntryPath))
+	s.setLinkHeaders(w, r, route)
+	s.serveTile(w, r, route, bytes.NewReader(data), modtime, "Failed to read sliced tile", "log", route.Log, "path", route.EntryPath)
+	return true
+}
@@ -0,0 +1,103 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_PartialFromFull(t *testing.T) {
+	t.Parallel()
+
+	hashTile := make([]byte, fullTileWidth*tileHashSize)
+	for i := range hashTile {
+		hashTile[i] = byte(i / tileHashSize)
+	}
+	var dataTile []byte
+	var dataLeaves [][]byte
+	for i := range fullTileWidth {
+		leaf := x509TileLeaf(1 + i%100)
+		if i%7 == 3 {
+			leaf = precertTileLeaf()
+		}
+		dataLeaves = append(dataLeaves, leaf)
+		dataTile = append(dataTile, leaf...)
+	}
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	stored := map[string][]byte{
+		"tile/0/000":        hashTile,
+		"tile/0/000.p/5":    hashTile[:5*tileHashSize],
+		"tile/data/000":     dataTile,
+		"tile/data/000.p/4": bytes.Join(dataLeaves[:4], nil),
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), stored)
+
+	newServer := func(partialFromFull bool) *Server {
+		cfg := Config{
+			ArchivePath:          root,
+			ArchiveFolderPattern: "ct_*",
+			ArchiveFolderPrefix:  "ct_",
+			PartialFromFull:      partialFromFull,
+		}
+		metrics := NewMetrics(prometheus.NewRegistry())
+		archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+		zipPartCache := NewZipPartCache(16, metrics, 4)
+		t.Cleanup(func() { _ = zipPartCache.Close() })
+		zr.SetZipPartCache(zipPartCache)
+		zr.SetEntryContentCache(NewEntryContentCache(1<<20, metrics))
+		return NewServer(cfg, NewLogger(LoggerOptions{}), metrics, archiveIndex, zr, nil)
+	}
+	get := func(server *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/"+path, nil))
+		return w
+	}
+
+	server := newServer(true)
+	for _, full := range []string{"tile/0/000", "tile/data/000"} {
+		if w := get(server, full); w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", full, w.Code, http.StatusOK)
+		}
+	}
+
+	// Sliced partials match the stored ones.
+	for _, partial := range []string{"tile/0/000.p/5", "tile/data/000.p/4"} {
+		w := get(server, partial)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", partial, w.Code, http.StatusOK)
+		}
+		if !bytes.Equal(w.Body.Bytes(), stored[partial]) {
+			t.Errorf("GET %s body = %d bytes, want the stored %d bytes", partial, w.Body.Len(), len(stored[partial]))
+		}
+	}
+
+	// A width that is not stored can only have come from the cached full tile.
+	w := get(server, "tile/0/000.p/9")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET tile/0/000.p/9 status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if want := hashTile[:9*tileHashSize]; !bytes.Equal(w.Body.Bytes(), want) {
+		t.Errorf("GET tile/0/000.p/9 body = %d bytes, want the first %d bytes of the full tile", w.Body.Len(), len(want))
+	}
+
+	// Disabled, partials are only ever read from the archive.
+	server = newServer(false)
+	if w := get(server, "tile/0/000"); w.Code != http.StatusOK {
+		t.Fatalf("GET tile/0/000 status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := get(server, "tile/0/000.p/9"); w.Code != http.StatusNotFound {
+		t.Errorf("GET tile/0/000.p/9 with CT_PARTIAL_FROM_FULL=false status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
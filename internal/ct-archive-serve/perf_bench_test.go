@@ -2,6 +2,7 @@ package ctarchiveserve
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,7 +35,7 @@ func BenchmarkZipReader_OpenEntry(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		rc, err := zr.OpenEntry(zipPath, "test.txt")
+		rc, err := zr.OpenEntry(context.Background(), zipPath, "test.txt")
 		if err != nil {
 			b.Fatalf("OpenEntry() error = %v", err)
 		}
@@ -63,7 +64,7 @@ func BenchmarkZipPartCache_Get(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		zipPath := zipFiles[i%len(zipFiles)]
-		entry, err := cache.Get(zipPath)
+		entry, err := cache.Get(context.Background(), zipPath)
 		if err != nil {
 			b.Fatalf("Get() error = %v", err)
 		}
@@ -94,7 +95,7 @@ func BenchmarkZipPartCache_ConcurrentContention(b *testing.B) {
 
 	// Warm cache.
 	for _, p := range zipPaths {
-		if _, err := cache.Get(p); err != nil {
+		if _, err := cache.Get(context.Background(), p); err != nil {
 			b.Fatalf("warm Get(%q) error = %v", p, err)
 		}
 	}
@@ -104,7 +105,7 @@ func BenchmarkZipPartCache_ConcurrentContention(b *testing.B) {
 		i := 0
 		for pb.Next() {
 			p := zipPaths[i%len(zipPaths)]
-			entry, err := cache.Get(p)
+			entry, err := cache.Get(context.Background(), p)
 			if err != nil {
 				b.Fatalf("Get() error = %v", err)
 			}
@@ -191,7 +192,7 @@ func BenchmarkZipPartCache_GetParallel_GOMAXPROCS(b *testing.B) {
 
 	// Warm cache.
 	for _, p := range zipPaths {
-		if _, err := cache.Get(p); err != nil {
+		if _, err := cache.Get(context.Background(), p); err != nil {
 			b.Fatalf("warm Get(%q) error = %v", p, err)
 		}
 	}
@@ -210,7 +211,7 @@ func BenchmarkZipPartCache_GetParallel_GOMAXPROCS(b *testing.B) {
 					defer wg.Done()
 					for i := 0; i < perG; i++ {
 						p := zipPaths[(gid*perG+i)%numFiles]
-						entry, err := cache.Get(p)
+						entry, err := cache.Get(context.Background(), p)
 						if err != nil {
 							b.Errorf("Get() error = %v", err)
 							return
@@ -0,0 +1,117 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// preopenQueueSize bounds the zip parts waiting to be pre-opened. Parts discovered while
+// the queue is full are skipped; their first request opens them as usual.
+const preopenQueueSize = 256
+
+// Preopen integrity checks the zip part at zipPath and opens it into the zip part cache,
+// like the first read of an entry would, so that read skips the cold open. It is a no-op
+// without a zip part cache. A failed check is not recorded (see ZipIntegrityCache.probe):
+// a new part is often still being written when a refresh discovers it.
+func (zr *ZipReader) Preopen(ctx context.Context, zipPath string) error {
+	if zr == nil {
+		return errors.New("zip reader is nil")
+	}
+	if zr.cache == nil {
+		return nil
+	}
+	if !zr.integrity.allowRead(zipPath) {
+		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, errLogCircuitOpen)
+	}
+	if zr.integrity != nil {
+		if err := zr.integrity.probe(zipPath); err != nil {
+			return err
+		}
+	}
+	if _, err := zr.cache.Get(ctx, zipPath); err != nil {
+		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+	}
+	return nil
+}
+
+// ZipPartPreopener pre-opens the newest zip part of each log after an archive refresh
+// discovers it (CT_PREOPEN_NEW_PARTS). Clients following a log fetch the newest tiles as
+// soon as a part lands; this moves the cold open of that part off their requests. Opens
+// go through the zip part cache, so they share its open worker pool and are evicted like
+// any other entry.
+type ZipPartPreopener struct {
+	archiveIndex *ArchiveIndex
+	zipReader    *ZipReader
+	logger       *slog.Logger
+
+	// newest maps each log to the newest zip part index already seen. Only the refresh
+	// hook uses it, and hooks run one at a time under the archive index's refresh lock.
+	newest map[string]int
+	queue  chan string
+}
+
+// NewZipPartPreopener constructs a ZipPartPreopener. Parts already in the archive index
+// are not pre-opened; only parts found by later refreshes are.
+func NewZipPartPreopener(archiveIndex *ArchiveIndex, zipReader *ZipReader, logger *slog.Logger) *ZipPartPreopener {
+	return &ZipPartPreopener{
+		archiveIndex: archiveIndex,
+		zipReader:    zipReader,
+		logger:       logger,
+		newest:       newestZipParts(archiveIndex.GetAllLogs()),
+		queue:        make(chan string, preopenQueueSize),
+	}
+}
+
+// Start hooks into archive refreshes and pre-opens queued zip parts, one at a time,
+// until ctx is done.
+func (p *ZipPartPreopener) Start(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	p.archiveIndex.OnRefresh(p.refreshed)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case zipPath := <-p.queue:
+				if err := p.zipReader.Preopen(ctx, zipPath); err != nil && p.logger != nil {
+					p.logger.Debug("Failed to pre-open zip part", "zip_path", zipPath, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// refreshed queues the newest zip part of each log whose newest part changed.
+func (p *ZipPartPreopener) refreshed(snap ArchiveSnapshot) {
+	newest := newestZipParts(snap)
+	for log, idx := range newest {
+		if prev, ok := p.newest[log]; ok && prev >= idx {
+			continue
+		}
+		zipPath := snap.Logs[log].ZipPartPath(idx)
+		select {
+		case p.queue <- zipPath:
+		default:
+			if p.logger != nil {
+				p.logger.Debug("Zip part pre-open queue full, skipping", "zip_path", zipPath)
+			}
+		}
+	}
+	p.newest = newest
+}
+
+// newestZipParts maps each log in snap to the index of its newest zip part.
+func newestZipParts(snap ArchiveSnapshot) map[string]int {
+	out := make(map[string]int, len(snap.Logs))
+	for name, l := range snap.Logs {
+		if len(l.ZipParts) > 0 {
+			out[name] = l.ZipParts[len(l.ZipParts)-1]
+		}
+	}
+	return out
+}
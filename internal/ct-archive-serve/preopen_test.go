@@ -0,0 +1,109 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestZipPartPreopener_NewPart(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{"checkpoint": checkpointBody(1)})
+
+	cfg := Config{ArchivePath: root, ArchiveFolderPattern: "ct_*", ArchiveFolderPrefix: "ct_"}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	ai, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zipPartCache := NewZipPartCache(16, metrics, 4)
+	t.Cleanup(func() { _ = zipPartCache.Close() })
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	zr.SetZipPartCache(zipPartCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewZipPartPreopener(ai, zr, nil).Start(ctx)
+
+	cached := func() []string {
+		var paths []string
+		for _, item := range zipPartCache.Snapshot() {
+			paths = append(paths, item.Path)
+		}
+		return paths
+	}
+
+	newPart := filepath.Join(logFolder, "001.zip")
+	mustCreateZip(t, newPart, map[string][]byte{"tile/0/256": make([]byte, 32)})
+	if err := ai.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(cached(), newPart) {
+		if time.Now().After(deadline) {
+			t.Fatalf("zip part cache = %v, want %s pre-opened after refresh", cached(), newPart)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Parts that were already indexed are left to their first request.
+	if paths := cached(); slices.Contains(paths, filepath.Join(logFolder, "000.zip")) {
+		t.Errorf("zip part cache = %v, want 000.zip not pre-opened", paths)
+	}
+}
+
+func TestZipReader_PreopenFailureNotRecorded(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	files := map[string][]byte{"tile/0/256": make([]byte, 32)}
+	complete := filepath.Join(t.TempDir(), "complete.zip")
+	mustCreateZip(t, complete, files)
+	//nolint:gosec // G304: path comes from t.TempDir
+	data, err := os.ReadFile(complete)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	// The part is still being written when it is pre-opened.
+	part := filepath.Join(logFolder, "001.zip")
+	mustWriteFile(t, part, data[:len(data)/2])
+
+	zic := NewZipIntegrityCache(time.Hour, time.Now, nil, nil)
+	zic.SetLogCircuitBreaker(1, time.Minute, time.Hour)
+	zipPartCache := NewZipPartCache(16, nil, 4)
+	t.Cleanup(func() { _ = zipPartCache.Close() })
+	zr := NewZipReader(zic)
+	zr.SetZipPartCache(zipPartCache)
+
+	if err := zr.Preopen(t.Context(), part); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Fatalf("Preopen() of an incomplete part error = %v, want %v", err, ErrZipTemporarilyUnavailable)
+	}
+	if !zic.allowRead(part) {
+		t.Fatalf("log circuit breaker tripped by a failed pre-open")
+	}
+
+	// Once complete, the part is served at once rather than after the fail TTL.
+	mustWriteFile(t, part, data)
+	if err := zic.Check(part); err != nil {
+		t.Fatalf("Check() after completion error = %v, want nil", err)
+	}
+	rc, err := zr.OpenEntry(t.Context(), part, "tile/0/256")
+	if err != nil {
+		t.Fatalf("OpenEntry() after completion error = %v", err)
+	}
+	_ = rc.Close()
+}
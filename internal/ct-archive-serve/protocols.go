@@ -0,0 +1,17 @@
+package ctarchiveserve
+
+import "net/http"
+
+// HTTPProtocols returns the protocols for the main http.Server. With CT_HTTP2_H2C it adds
+// unencrypted HTTP/2 next to HTTP/1.1; net/http serves it to clients that connect with
+// prior knowledge (the HTTP/1.1 Upgrade handshake is not supported). It returns nil,
+// i.e. net/http's defaults, otherwise.
+func HTTPProtocols(cfg Config) *http.Protocols {
+	if !cfg.HTTP2H2C {
+		return nil
+	}
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetUnencryptedHTTP2(true)
+	return p
+}
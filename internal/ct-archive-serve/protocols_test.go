@@ -0,0 +1,97 @@
+package ctarchiveserve
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHTTPProtocols_H2C(t *testing.T) {
+	t.Parallel()
+
+	if p := HTTPProtocols(Config{}); p != nil {
+		t.Fatalf("HTTPProtocols(disabled) = %v, want nil", p)
+	}
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	tiles := make(map[string][]byte)
+	for i := 0; i < 20; i++ {
+		tiles[fmt.Sprintf("tile/data/x%03d", i)] = []byte(fmt.Sprintf("data tile %d", i))
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), tiles)
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		HTTP2H2C:             true,
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil)
+
+	var conns atomic.Int64
+	ts := httptest.NewUnstartedServer(server)
+	ts.Config.Protocols = HTTPProtocols(cfg)
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	// HTTP/2 with prior knowledge only, so an HTTP/1.1 fallback would fail the requests.
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	defer client.CloseIdleConnections()
+
+	fetch := func(i int) {
+		resp, err := client.Get(fmt.Sprintf("%s/test_log/tile/data/x%03d", ts.URL, i))
+		if err != nil {
+			t.Errorf("GET tile %d error = %v", i, err)
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.ProtoMajor != 2 {
+			t.Errorf("GET tile %d proto = %s, want HTTP/2", i, resp.Proto)
+		}
+		if want := fmt.Sprintf("data tile %d", i); string(body) != want {
+			t.Errorf("GET tile %d body = %q, want %q", i, body, want)
+		}
+	}
+
+	// Establish the connection first; concurrent first requests may each dial.
+	fetch(0)
+	var wg sync.WaitGroup
+	for i := 1; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetch(i)
+		}()
+	}
+	wg.Wait()
+
+	if got := conns.Load(); got != 1 {
+		t.Errorf("connections = %d, want 1 (requests multiplexed over one h2c connection)", got)
+	}
+}
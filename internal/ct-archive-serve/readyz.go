@@ -0,0 +1,73 @@
+package ctarchiveserve
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// handleReadyz serves GET /readyz for load balancer readiness probes.
+//
+// The server is ready once the archive index holds at least CT_READY_MIN_LOGS logs, so a
+// node whose archive mount has not appeared yet (e.g. slow NFS) stays out of rotation.
+// With CT_MIN_FREE_DISK_BYTES it is also not ready while the archive filesystem is low on
+// space, and with CT_ARCHIVE_PROBE_INTERVAL while the archive mount probe is failing. Not
+// ready is reported as 503 with a one-line reason.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if s.archiveIndex == nil {
+		s.writeReadyz(w, r, http.StatusServiceUnavailable, "not ready: archive index not initialized\n")
+		return
+	}
+	if n := s.archiveIndex.LogCount(); n < s.cfg.ReadyMinLogs {
+		s.writeReadyz(w, r, http.StatusServiceUnavailable,
+			fmt.Sprintf("not ready: %d of %d required logs discovered\n", n, s.cfg.ReadyMinLogs))
+		return
+	}
+	if !s.mountProbe.Healthy() {
+		s.writeReadyz(w, r, http.StatusServiceUnavailable, "not ready: archive mount probe failing\n")
+		return
+	}
+	if free, low := s.checkFreeDisk(); low {
+		s.writeReadyz(w, r, http.StatusServiceUnavailable,
+			fmt.Sprintf("not ready: %d bytes free on archive filesystem, %d required\n", free, s.cfg.MinFreeDiskBytes))
+		return
+	}
+	s.writeReadyz(w, r, http.StatusOK, "ok\n")
+}
+
+// checkFreeDisk samples free space on the archive filesystem and reports whether it is
+// below CT_MIN_FREE_DISK_BYTES. Crossing the threshold is logged once per direction. A
+// failed sample (e.g. on a platform without statfs) does not affect readiness.
+func (s *Server) checkFreeDisk() (uint64, bool) {
+	if s.cfg.MinFreeDiskBytes == 0 || s.diskFree == nil {
+		return 0, false
+	}
+	free, err := s.diskFree(s.cfg.ArchivePath)
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Debug("Failed to sample free disk space", "path", s.cfg.ArchivePath, "error", err)
+		}
+		return 0, false
+	}
+	low := free < s.cfg.MinFreeDiskBytes
+	if s.diskLow.Swap(low) != low && s.logger != nil {
+		if low {
+			s.logger.Warn("Archive filesystem below CT_MIN_FREE_DISK_BYTES, reporting not ready",
+				"path", s.cfg.ArchivePath, "free_bytes", free, "min_free_bytes", s.cfg.MinFreeDiskBytes)
+		} else {
+			s.logger.Info("Archive filesystem free space recovered, reporting ready",
+				"path", s.cfg.ArchivePath, "free_bytes", free, "min_free_bytes", s.cfg.MinFreeDiskBytes)
+		}
+	}
+	return free, low
+}
+
+func (s *Server) writeReadyz(w http.ResponseWriter, r *http.Request, status int, body string) {
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write([]byte(body))
+}
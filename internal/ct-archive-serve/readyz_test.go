@@ -0,0 +1,112 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServer_Readyz_MinLogs(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	cfg := Config{
+		ArchivePath:         root,
+		ArchiveFolderPrefix: "ct_",
+		ReadyMinLogs:        1,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	server := NewServer(cfg, nil, nil, archiveIndex, nil, nil)
+
+	readyz := func() int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Empty mount: not ready.
+	if got := readyz(); got != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz with 0 logs status = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	mustMkdir(t, filepath.Join(root, "ct_log1"))
+	mustWriteFile(t, filepath.Join(root, "ct_log1", "000.zip"), []byte("x"))
+	if err := archiveIndex.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+
+	if got := readyz(); got != http.StatusOK {
+		t.Fatalf("GET /readyz with 1 log status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestServer_Readyz_DefaultReadyWithoutLogs(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{ArchivePath: t.TempDir(), ArchiveFolderPrefix: "ct_"}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	server := NewServer(cfg, nil, nil, archiveIndex, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /readyz status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestServer_Readyz_MinFreeDisk(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{ArchivePath: t.TempDir(), ArchiveFolderPrefix: "ct_", MinFreeDiskBytes: 1000}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), nil, archiveIndex, nil, nil)
+
+	var free atomic.Uint64
+	var sampled atomic.Value
+	server.diskFree = func(path string) (uint64, error) {
+		sampled.Store(path)
+		return free.Load(), nil
+	}
+
+	readyz := func() (int, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return w.Code, w.Body.String()
+	}
+
+	free.Store(999)
+	if code, body := readyz(); code != http.StatusServiceUnavailable || !strings.Contains(body, "999 bytes free") {
+		t.Fatalf("GET /readyz with 999 bytes free = %d %q, want 503 with the free space", code, body)
+	}
+	if got := sampled.Load(); got != cfg.ArchivePath {
+		t.Errorf("sampled path = %v, want %q", got, cfg.ArchivePath)
+	}
+
+	free.Store(1000)
+	if code, _ := readyz(); code != http.StatusOK {
+		t.Fatalf("GET /readyz with 1000 bytes free status = %d, want %d", code, http.StatusOK)
+	}
+
+	// A failed sample leaves the node ready.
+	server.diskFree = func(string) (uint64, error) { return 0, errors.ErrUnsupported }
+	if code, _ := readyz(); code != http.StatusOK {
+		t.Fatalf("GET /readyz with failing disk sample status = %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,29 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	archiveRefreshIntervalHeader = "X-Archive-Refresh-Interval"
+	logListRefreshIntervalHeader = "X-LogList-Refresh-Interval"
+)
+
+// setRefreshHeaders tells clients of the JSON endpoints how often the archive index
+// (CT_ARCHIVE_REFRESH_INTERVAL) and the /logs.v3.json snapshot
+// (CT_LOGLISTV3_JSON_REFRESH_INTERVAL) are rebuilt, in seconds, so they can pick a poll
+// rate (CT_EMIT_REFRESH_HEADERS).
+func (s *Server) setRefreshHeaders(w http.ResponseWriter) {
+	if !s.cfg.EmitRefreshHeaders {
+		return
+	}
+	w.Header().Set(archiveRefreshIntervalHeader, formatSeconds(s.cfg.ArchiveRefreshInterval))
+	w.Header().Set(logListRefreshIntervalHeader, formatSeconds(s.cfg.LogListV3JSONRefreshInterval))
+}
+
+// formatSeconds formats d as a decimal number of seconds, e.g. "300" or "0.5".
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
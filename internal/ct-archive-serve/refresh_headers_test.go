@@ -0,0 +1,73 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_RefreshHeaders(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint":  checkpointBody(1),
+		"log.v3.json": []byte(`{"description":"test"}`),
+		"issuer/0a1b": []byte("issuer"),
+	})
+
+	newServer := func(enabled bool) *Server {
+		cfg := Config{
+			ArchivePath:                  root,
+			ArchiveFolderPattern:         "ct_*",
+			ArchiveFolderPrefix:          "ct_",
+			EnableIssuerListing:          true,
+			ArchiveRefreshInterval:       5 * time.Minute,
+			LogListV3JSONRefreshInterval: 90 * time.Second,
+			EmitRefreshHeaders:           enabled,
+		}
+		archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+		builder.refreshOnce("http://example.com")
+		return NewServer(cfg, nil, nil, archiveIndex, zr, builder)
+	}
+
+	paths := []string{"/logs.v3.json", "/test_log/log.v3.json", "/test_log/issuers.json"}
+	server := newServer(true)
+	for _, path := range paths {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get(archiveRefreshIntervalHeader); got != "300" {
+			t.Errorf("GET %s %s = %q, want %q", path, archiveRefreshIntervalHeader, got, "300")
+		}
+		if got := w.Header().Get(logListRefreshIntervalHeader); got != "90" {
+			t.Errorf("GET %s %s = %q, want %q", path, logListRefreshIntervalHeader, got, "90")
+		}
+	}
+
+	// Tiles and checkpoints are not JSON endpoints; disabled servers send nothing.
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil))
+	if got := w.Header().Get(archiveRefreshIntervalHeader); got != "" {
+		t.Errorf("checkpoint %s = %q, want unset", archiveRefreshIntervalHeader, got)
+	}
+	server = newServer(false)
+	for _, path := range paths {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := w.Header().Get(archiveRefreshIntervalHeader); got != "" {
+			t.Errorf("disabled: GET %s %s = %q, want unset", path, archiveRefreshIntervalHeader, got)
+		}
+	}
+}
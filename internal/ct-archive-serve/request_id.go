@@ -0,0 +1,86 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader carries the request ID in and out (echoed on every response).
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds an incoming X-Request-ID adopted from a trusted proxy.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// crockfordBase32 is the ULID alphabet (Crockford's base32, no I, L, O or U).
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID for now: a 48-bit millisecond timestamp followed by 80 random
+// bits, as 26 Crockford base32 characters. IDs sort by creation time.
+func newULID(now time.Time) string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(now.UnixMilli())<<16) //nolint:gosec // G115: Unix ms fits 48 bits
+	_, _ = rand.Read(b[6:])
+
+	// 128 bits as 26 base32 digits: the first digit holds the top 3 bits.
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// requestID returns the ID for r: X-Request-ID when the request comes from a trusted
+// source (CT_HTTP_TRUSTED_SOURCES) and the value is sane, otherwise a new ULID.
+func (s *Server) requestID(r *http.Request) string {
+	if id := r.Header.Get(requestIDHeader); id != "" && validRequestID(id) && s.isTrustedSource(r) {
+		return id
+	}
+	return newULID(time.Now())
+}
+
+// validRequestID accepts up to maxRequestIDLength visible ASCII characters, so an adopted
+// ID cannot smuggle control characters into logs or response headers.
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// withRequestID returns r with id stored in its context.
+func withRequestID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// requestIDFrom returns the request ID stored by ServeHTTP, or "".
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns s.logger with the request's request_id attached, so handler logs
+// correlate with the access log line. It returns nil when logging is disabled.
+func (s *Server) requestLogger(r *http.Request) *slog.Logger {
+	if s.logger == nil {
+		return nil
+	}
+	if id := requestIDFrom(r.Context()); id != "" {
+		return s.logger.With("request_id", id)
+	}
+	return s.logger
+}
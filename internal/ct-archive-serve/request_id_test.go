@@ -0,0 +1,92 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_RequestIDHeaderMatchesLog(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	server := NewServer(Config{}, logger, nil, nil, nil, nil)
+
+	// 404s are always access-logged.
+	req := httptest.NewRequest(http.MethodGet, "/nope", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	id := w.Header().Get("X-Request-ID")
+	if len(id) != 26 {
+		t.Fatalf("X-Request-ID = %q, want a 26-character ULID", id)
+	}
+
+	var line struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+		t.Fatalf("Unmarshal(%q) error = %v", logs.String(), err)
+	}
+	if line.Msg != "HTTP request" || line.RequestID != id {
+		t.Errorf("log line = %+v, want HTTP request with request_id %q", line, id)
+	}
+}
+
+func TestServer_RequestIDFromTrustedSource(t *testing.T) {
+	t.Parallel()
+
+	// httptest requests come from 192.0.2.1.
+	trusted := NewServer(Config{HTTPTrustedSources: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}, nil, nil, nil, nil, nil)
+	untrusted := NewServer(Config{}, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name     string
+		server   *Server
+		incoming string
+		adopted  bool
+	}{
+		{name: "trusted", server: trusted, incoming: "edge-4f2a9c", adopted: true},
+		{name: "untrusted", server: untrusted, incoming: "edge-4f2a9c", adopted: false},
+		{name: "trusted but invalid", server: trusted, incoming: "bad id", adopted: false},
+		{name: "trusted but too long", server: trusted, incoming: strings.Repeat("a", maxRequestIDLength+1), adopted: false},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/nope", nil)
+		req.Header.Set("X-Request-ID", tc.incoming)
+		w := httptest.NewRecorder()
+		tc.server.ServeHTTP(w, req)
+
+		got := w.Header().Get("X-Request-ID")
+		if (got == tc.incoming) != tc.adopted {
+			t.Errorf("%s: X-Request-ID = %q, adopted = %v, want %v", tc.name, got, got == tc.incoming, tc.adopted)
+		}
+		if !tc.adopted && len(got) != 26 {
+			t.Errorf("%s: X-Request-ID = %q, want a generated ULID", tc.name, got)
+		}
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	t.Parallel()
+
+	if got := newULID(time.UnixMilli(0)); !strings.HasPrefix(got, "0000000000") {
+		t.Errorf("newULID(epoch) = %q, want a zero timestamp prefix", got)
+	}
+	a := newULID(time.UnixMilli(1_700_000_000_000))
+	b := newULID(time.UnixMilli(1_700_000_000_001))
+	if len(a) != 26 || strings.Trim(a, crockfordBase32) != "" {
+		t.Errorf("newULID() = %q, want 26 Crockford base32 characters", a)
+	}
+	if a[:10] >= b[:10] {
+		t.Errorf("timestamp prefixes %q, %q do not sort by time", a[:10], b[:10])
+	}
+}
@@ -0,0 +1,17 @@
+package ctarchiveserve
+
+import "net/http"
+
+// handleSubmission answers /<log>/ct/v1/{add-chain,add-pre-chain,get-roots} for any method.
+//
+// The archive never accepts submissions. For logs listed in CT_RETIRED_LOGS the answer is
+// 410 Gone, telling clients the log is permanently closed rather than missing; other logs
+// keep the plain 404 of an unknown route. Tiles and checkpoints of retired logs are served
+// as usual, and logs.v3.json already lists every archived log as retired.
+func (s *Server) handleSubmission(w http.ResponseWriter, r *http.Request, route Route) {
+	if !matchesAnyGlob(route.Log, s.cfg.RetiredLogs) {
+		s.notFound(w, r)
+		return
+	}
+	http.Error(w, "Log is retired; submissions are no longer accepted", http.StatusGone)
+}
@@ -0,0 +1,103 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newRetiredTestServer(t *testing.T) (*Server, *ArchiveIndex, *LogListV3JSONBuilder) {
+	t.Helper()
+
+	root := t.TempDir()
+	for _, name := range []string{"old_log", "live_log"} {
+		logFolder := filepath.Join(root, "ct_"+name)
+		mustMkdir(t, logFolder)
+		mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+			"checkpoint":  checkpointBody(10),
+			"log.v3.json": []byte(`{"description":"` + name + `","log_id":"aWQ=","key":"a2V5","mmd":86400,"log_type":"prod","state":{"usable":{"timestamp":"2024-01-01T00:00:00Z"}}}`),
+		})
+	}
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		RetiredLogs:          []string{"old_*"},
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, logger)
+	return NewServer(cfg, logger, metrics, archiveIndex, zr, builder), archiveIndex, builder
+}
+
+func TestServer_RetiredLogSubmissionsGone(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newRetiredTestServer(t)
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+	}{
+		{method: http.MethodPost, path: "/old_log/ct/v1/add-chain", wantCode: http.StatusGone},
+		{method: http.MethodPost, path: "/old_log/ct/v1/add-pre-chain", wantCode: http.StatusGone},
+		{method: http.MethodGet, path: "/old_log/ct/v1/get-roots", wantCode: http.StatusGone},
+		// Not listed in CT_RETIRED_LOGS: unchanged 404.
+		{method: http.MethodPost, path: "/live_log/ct/v1/add-chain", wantCode: http.StatusNotFound},
+		// Archive content of a retired log is still served.
+		{method: http.MethodGet, path: "/old_log/checkpoint", wantCode: http.StatusOK},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != tc.wantCode {
+			t.Errorf("%s %s status = %d, want %d", tc.method, tc.path, w.Code, tc.wantCode)
+		}
+	}
+}
+
+func TestLogListV3JSON_RetiredLogState(t *testing.T) {
+	t.Parallel()
+
+	_, archiveIndex, builder := newRetiredTestServer(t)
+
+	snap, err := builder.BuildSnapshot("https://archive.example")
+	if err != nil {
+		t.Fatalf("BuildSnapshot() error = %v", err)
+	}
+	archiveLog, ok := archiveIndex.LookupLog("old_log")
+	if !ok {
+		t.Fatalf("old_log not discovered")
+	}
+
+	var found bool
+	for _, op := range snap.Operators {
+		for _, tl := range op.TiledLogs {
+			if tl.LogName != "old_log" {
+				continue
+			}
+			found = true
+			retired, ok := tl.State["retired"].(map[string]interface{})
+			if !ok || len(tl.State) != 1 {
+				t.Fatalf("state = %v, want only retired", tl.State)
+			}
+			if got, want := retired["timestamp"], archiveLog.FirstDiscovered.UTC().Format(time.RFC3339); got != want {
+				t.Errorf("retired timestamp = %v, want %v (FirstDiscovered)", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("old_log missing from tiled_logs")
+	}
+}
@@ -0,0 +1,37 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// parseRootRedirect validates a CT_ROOT_REDIRECT target: an absolute path such as
+// /logs.v3.json, or an absolute http(s) URL.
+func parseRootRedirect(v string) (string, error) {
+	u, err := url.Parse(v)
+	if err != nil {
+		return "", err //nolint:wrapcheck // wrapped with the variable name by the caller
+	}
+	switch {
+	case u.Scheme == "" && u.Host == "":
+		if !strings.HasPrefix(v, "/") || strings.HasPrefix(v, "//") {
+			return "", errors.New("must be an absolute path or an http(s) URL")
+		}
+	case u.Scheme != "http" && u.Scheme != "https", u.Host == "":
+		return "", errors.New("must be an absolute path or an http(s) URL")
+	}
+	return v, nil
+}
+
+// handleRoot serves GET / as a 302 to CT_ROOT_REDIRECT. Without it the route does not
+// exist, so API clients probing / still get 404.
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.RootRedirect == "" {
+		s.notFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, s.cfg.RootRedirect, http.StatusFound)
+}
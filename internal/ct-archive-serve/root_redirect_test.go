@@ -0,0 +1,49 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_RootRedirect(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []string{"/logs.v3.json", "https://docs.example.com/ct"} {
+		server := NewServer(Config{RootRedirect: target}, nil, nil, nil, nil, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusFound {
+			t.Fatalf("GET / status = %d, want %d", w.Code, http.StatusFound)
+		}
+		if got := w.Header().Get("Location"); got != target {
+			t.Errorf("Location = %q, want %q", got, target)
+		}
+	}
+}
+
+func TestServer_RootRedirect_DisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(Config{}, nil, nil, nil, nil, nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("GET / status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestParseRootRedirect(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []string{"/logs.v3.json", "/", "http://example.com", "https://example.com/docs?x=1"} {
+		if _, err := parseRootRedirect(v); err != nil {
+			t.Errorf("parseRootRedirect(%q) error = %v", v, err)
+		}
+	}
+	for _, v := range []string{"logs.v3.json", "//evil.example.com", "ftp://example.com", "https:///nohost", "javascript:alert(1)"} {
+		if _, err := parseRootRedirect(v); err == nil {
+			t.Errorf("parseRootRedirect(%q) error = nil, want error", v)
+		}
+	}
+}
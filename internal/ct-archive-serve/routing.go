@@ -3,6 +3,7 @@ package ctarchiveserve
 import (
 	"fmt"
 	"math"
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -19,6 +20,20 @@ const (
 	RouteIssuer
 	RouteHashTile
 	RouteDataTile
+	RouteZipPartManifest
+	RouteFavicon
+	RouteRobotsTXT
+	RouteReadyz
+	RouteIssuerList
+	RouteSubmission
+	RouteZipCacheSnapshot
+	RouteBulkDownload
+	RouteLogsTXT
+	RouteAdminConfig
+	RouteCheckpointWitnessed
+	RouteRoot
+	RouteAdminMetadata
+	RouteAdminArchiveSnapshot
 )
 
 type Route struct {
@@ -39,15 +54,57 @@ type Route struct {
 
 	// IssuerFingerprint is set when Kind is RouteIssuer.
 	IssuerFingerprint string
+
+	// ZipPart is the NNN zip part index when Kind is RouteZipPartManifest.
+	ZipPart int
+}
+
+// DefaultMaxLogNameLength is the default maximum length of the <log> path segment
+// (CT_MAX_LOG_NAME_LENGTH).
+const DefaultMaxLogNameLength = 128
+
+// RouteOptions controls optional validation performed by ParseRouteWithOptions.
+type RouteOptions struct {
+	// MaxLogNameLength is the maximum accepted length of the <log> segment.
+	// Values <= 0 use DefaultMaxLogNameLength.
+	MaxLogNameLength int
+
+	// AllowUppercaseX accepts an uppercase `X` prefix on tile index segments, as emitted by
+	// some older clients (CT_TILE_ALLOW_UPPERCASE_X). The entry path is normalized to the
+	// lowercase `x` that archives store.
+	AllowUppercaseX bool
+
+	// AllowPercentEncoding decodes a percent-encoded path before routing it instead of
+	// rejecting it (CT_ALLOW_PERCENT_ENCODING). See unescapeRoutePath.
+	AllowPercentEncoding bool
+
+	// MonitorJSONAlias routes the legacy /monitor.json path to the log list, as an alias
+	// of /logs.v3.json (CT_MONITOR_JSON_ALIAS). Otherwise it is an unknown route.
+	MonitorJSONAlias bool
 }
 
-// ParseRoute parses a request path and returns (route, true) only if the path is a supported
-// route and all parameters validate. Otherwise it returns (zero, false) and the caller should
-// respond with 404.
+// ParseRoute parses a request path using default RouteOptions.
+// See ParseRouteWithOptions.
+func ParseRoute(path string) (Route, bool) {
+	return ParseRouteWithOptions(path, RouteOptions{})
+}
+
+// ParseRouteWithOptions parses a request path and returns (route, true) only if the path is a
+// supported route and all parameters validate. Otherwise it returns (zero, false) and the caller
+// should respond with 404.
 //
 // Security note: to avoid traversal tricks and ambiguity, this parser rejects any percent-escaped
-// path inputs and any path containing ".." (spec Edge Cases).
-func ParseRoute(path string) (Route, bool) {
+// path inputs and any path containing ".." (spec Edge Cases). The <log> segment must also pass
+// isValidLogName, so arbitrary probe strings are rejected before any archive lookup. With
+// opts.AllowPercentEncoding the path is decoded first and the same rules apply to the result.
+func ParseRouteWithOptions(path string, opts RouteOptions) (Route, bool) {
+	if opts.AllowPercentEncoding && strings.Contains(path, "%") {
+		decoded, ok := unescapeRoutePath(path)
+		if !ok {
+			return Route{}, false
+		}
+		path = decoded
+	}
 	if path == "" || path[0] != '/' {
 		return Route{}, false
 	}
@@ -59,10 +116,34 @@ func ParseRoute(path string) (Route, bool) {
 	}
 
 	switch path {
+	case "/":
+		return Route{Kind: RouteRoot}, true
 	case "/logs.v3.json":
 		return Route{Kind: RouteLogListV3JSON}, true
+	case "/monitor.json":
+		// The legacy name of the same log list, served from the same snapshot when enabled.
+		if !opts.MonitorJSONAlias {
+			return Route{}, false
+		}
+		return Route{Kind: RouteLogListV3JSON}, true
+	case "/logs.txt":
+		return Route{Kind: RouteLogsTXT}, true
 	case "/metrics":
 		return Route{Kind: RouteMetrics}, true
+	case "/favicon.ico":
+		return Route{Kind: RouteFavicon}, true
+	case "/robots.txt":
+		return Route{Kind: RouteRobotsTXT}, true
+	case "/readyz":
+		return Route{Kind: RouteReadyz}, true
+	case "/admin/zipcache.json":
+		return Route{Kind: RouteZipCacheSnapshot}, true
+	case "/admin/config.json":
+		return Route{Kind: RouteAdminConfig}, true
+	case "/admin/metadata.json":
+		return Route{Kind: RouteAdminMetadata}, true
+	case "/admin/archive-snapshot.json":
+		return Route{Kind: RouteAdminArchiveSnapshot}, true
 	}
 
 	trimmed := strings.TrimPrefix(path, "/")
@@ -72,7 +153,7 @@ func ParseRoute(path string) (Route, bool) {
 	}
 
 	log := parts[0]
-	if log == "" || log == "." || log == ".." {
+	if !isValidLogName(log, opts.MaxLogNameLength) {
 		return Route{}, false
 	}
 
@@ -82,8 +163,14 @@ func ParseRoute(path string) (Route, bool) {
 		switch suffix[0] {
 		case "checkpoint":
 			return Route{Kind: RouteCheckpoint, Log: log, EntryPath: "checkpoint"}, true
+		case "checkpoint.witnessed":
+			return Route{Kind: RouteCheckpointWitnessed, Log: log, EntryPath: checkpointWitnessedEntryName}, true
 		case "log.v3.json":
 			return Route{Kind: RouteLogV3JSON, Log: log, EntryPath: "log.v3.json"}, true
+		case "issuers.json":
+			return Route{Kind: RouteIssuerList, Log: log}, true
+		case "download.tar":
+			return Route{Kind: RouteBulkDownload, Log: log}, true
 		default:
 			return Route{}, false
 		}
@@ -106,21 +193,70 @@ func ParseRoute(path string) (Route, bool) {
 		}, true
 
 	case "tile":
-		return parseTileRoute(log, suffix)
+		return parseTileRoute(log, suffix, opts.AllowUppercaseX)
+
+	case "ct":
+		// /<log>/ct/v1/<endpoint>: RFC 6962 submission endpoints, which an archive never
+		// serves; recognized only so retired logs can answer 410 (see handleSubmission).
+		if len(suffix) != 3 || suffix[1] != "v1" || !isSubmissionEndpoint(suffix[2]) {
+			return Route{}, false
+		}
+		return Route{Kind: RouteSubmission, Log: log}, true
+
+	case "parts":
+		// /<log>/parts/<NNN>/manifest.json (admin)
+		if len(suffix) != 3 || suffix[2] != "manifest.json" || !isThreeDigits(suffix[1]) {
+			return Route{}, false
+		}
+		n, err := strconv.Atoi(suffix[1])
+		if err != nil {
+			return Route{}, false
+		}
+		return Route{Kind: RouteZipPartManifest, Log: log, ZipPart: n}, true
 
 	default:
 		return Route{}, false
 	}
 }
 
-func parseTileRoute(log string, suffix []string) (Route, bool) {
+// unescapeRoutePath decodes a percent-encoded request path. Encoded path separators
+// (%2F, %5C) are rejected rather than decoded, as they would change the segment
+// structure, and so are decoded control characters such as NUL. A `%` left after decoding
+// (double encoding) is rejected by the caller like any other.
+func unescapeRoutePath(path string) (string, bool) {
+	lower := strings.ToLower(path)
+	if strings.Contains(lower, "%2f") || strings.Contains(lower, "%5c") {
+		return "", false
+	}
+	decoded, err := url.PathUnescape(path)
+	if err != nil {
+		return "", false
+	}
+	for i := 0; i < len(decoded); i++ {
+		if decoded[i] < 0x20 || decoded[i] == 0x7f {
+			return "", false
+		}
+	}
+	return decoded, true
+}
+
+func isSubmissionEndpoint(name string) bool {
+	switch name {
+	case "add-chain", "add-pre-chain", "get-roots":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseTileRoute(log string, suffix []string, allowUppercaseX bool) (Route, bool) {
 	// suffix starts with "tile".
 	if len(suffix) < 3 {
 		return Route{}, false
 	}
 
 	if suffix[1] == "data" {
-		ti, ok := parseTileIndexAndPartial(suffix[2:])
+		ti, ok := parseTileIndexAndPartial(suffix[2:], allowUppercaseX)
 		if !ok {
 			return Route{}, false
 		}
@@ -143,7 +279,7 @@ func parseTileRoute(log string, suffix []string) (Route, bool) {
 		return Route{}, false
 	}
 
-	ti, ok := parseTileIndexAndPartial(suffix[2:])
+	ti, ok := parseTileIndexAndPartial(suffix[2:], allowUppercaseX)
 	if !ok {
 		return Route{}, false
 	}
@@ -167,7 +303,8 @@ type tileIndexInfo struct {
 
 // parseTileIndexAndPartial parses `<N...>` or `<N...>.p/<W>` starting at the first `<N>` segment.
 // It also returns the entry-path segments for the tile portion (i.e. `<N...>` or `<N...>.p/<W>`).
-func parseTileIndexAndPartial(parts []string) (tileIndexInfo, bool) {
+// With allowUppercaseX, an `X` prefix is accepted and lowercased in the entry-path segments.
+func parseTileIndexAndPartial(parts []string, allowUppercaseX bool) (tileIndexInfo, bool) {
 	if len(parts) < 1 {
 		return tileIndexInfo{}, false
 	}
@@ -189,6 +326,13 @@ func parseTileIndexAndPartial(parts []string) (tileIndexInfo, bool) {
 	} else {
 		nSegs = append([]string(nil), parts...)
 	}
+	if allowUppercaseX {
+		for i, s := range nSegs {
+			if rest, ok := strings.CutPrefix(s, "X"); ok {
+				nSegs[i] = "x" + rest
+			}
+		}
+	}
 
 	decSegs := make([]string, 0, len(nSegs))
 	for i, s := range nSegs {
@@ -278,6 +422,31 @@ func decodeTlogIndexSegments(segs []string) (uint64, error) {
 	return n, nil
 }
 
+// isValidLogName reports whether log is a routable <log> name: non-empty, at most maxLen
+// bytes (DefaultMaxLogNameLength if maxLen <= 0), and only ASCII letters, digits, '_' and '-'.
+// This covers log names derived from archive folder names such as ct_digicert_nessie_2022.
+func isValidLogName(log string, maxLen int) bool {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLogNameLength
+	}
+	if log == "" || len(log) > maxLen {
+		return false
+	}
+	for i := 0; i < len(log); i++ {
+		c := log[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// isThreeDigits reports whether s is exactly three ASCII decimal digits (a zip part NNN).
+func isThreeDigits(s string) bool {
+	return len(s) == 3 && s[0] >= '0' && s[0] <= '9' && s[1] >= '0' && s[1] <= '9' && s[2] >= '0' && s[2] <= '9'
+}
+
 func isLowerHex(s string) bool {
 	if s == "" {
 		return false
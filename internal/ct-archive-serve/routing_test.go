@@ -1,6 +1,9 @@
 package ctarchiveserve
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseRoute(t *testing.T) {
 	t.Parallel()
@@ -13,8 +16,15 @@ func TestParseRoute(t *testing.T) {
 		want    RouteKind
 	}{
 		{name: "logs v3 json", path: "/logs.v3.json", wantOK: true, want: RouteLogListV3JSON},
+		{name: "legacy monitor json without alias", path: "/monitor.json", wantOK: false},
 		{name: "metrics", path: "/metrics", wantOK: true, want: RouteMetrics},
+		{name: "favicon", path: "/favicon.ico", wantOK: true, want: RouteFavicon},
+		{name: "robots", path: "/robots.txt", wantOK: true, want: RouteRobotsTXT},
+		{name: "logs txt", path: "/logs.txt", wantOK: true, want: RouteLogsTXT},
+		{name: "readyz", path: "/readyz", wantOK: true, want: RouteReadyz},
+		{name: "root", path: "/", wantOK: true, want: RouteRoot},
 		{name: "checkpoint", path: "/digicert/checkpoint", wantOK: true, want: RouteCheckpoint, wantLog: "digicert"},
+		{name: "checkpoint witnessed", path: "/digicert/checkpoint.witnessed", wantOK: true, want: RouteCheckpointWitnessed, wantLog: "digicert"},
 		{name: "log v3", path: "/digicert/log.v3.json", wantOK: true, want: RouteLogV3JSON, wantLog: "digicert"},
 		{name: "issuer", path: "/digicert/issuer/0a1b2c", wantOK: true, want: RouteIssuer, wantLog: "digicert"},
 		{name: "hash tile full", path: "/digicert/tile/0/x000", wantOK: true, want: RouteHashTile, wantLog: "digicert"},
@@ -31,6 +41,24 @@ func TestParseRoute(t *testing.T) {
 		{name: "invalid tile index segment no prefix non-last", path: "/digicert/tile/0/001/x234", wantOK: false}, // non-last segment must have x prefix
 		{name: "invalid tile partial width 0", path: "/digicert/tile/0/x001.p/0", wantOK: false},
 		{name: "invalid tile partial width 256", path: "/digicert/tile/0/x001.p/256", wantOK: false},
+		{name: "log name with dash and digits", path: "/digicert-nessie2022/checkpoint", wantOK: true, want: RouteCheckpoint, wantLog: "digicert-nessie2022"},
+		{name: "log name at max length", path: "/" + strings.Repeat("a", DefaultMaxLogNameLength) + "/checkpoint", wantOK: true, want: RouteCheckpoint, wantLog: strings.Repeat("a", DefaultMaxLogNameLength)},
+		{name: "invalid log name over max length", path: "/" + strings.Repeat("a", DefaultMaxLogNameLength+1) + "/checkpoint", wantOK: false},
+		{name: "invalid log name dot", path: "/digi.cert/checkpoint", wantOK: false},
+		{name: "invalid log name space", path: "/digi cert/checkpoint", wantOK: false},
+		{name: "invalid log name non-ascii", path: "/digicért/checkpoint", wantOK: false},
+		{name: "invalid log name colon", path: "/digi:cert/checkpoint", wantOK: false},
+		{name: "issuer list", path: "/digicert/issuers.json", wantOK: true, want: RouteIssuerList, wantLog: "digicert"},
+		{name: "bulk download", path: "/digicert/download.tar", wantOK: true, want: RouteBulkDownload, wantLog: "digicert"},
+		{name: "submission add-chain", path: "/digicert/ct/v1/add-chain", wantOK: true, want: RouteSubmission, wantLog: "digicert"},
+		{name: "unknown ct v1 endpoint", path: "/digicert/ct/v1/get-sth", wantOK: false},
+		{name: "zip part manifest", path: "/digicert/parts/001/manifest.json", wantOK: true, want: RouteZipPartManifest, wantLog: "digicert"},
+		{name: "invalid zip part manifest index", path: "/digicert/parts/1/manifest.json", wantOK: false},
+		{name: "invalid zip part manifest name", path: "/digicert/parts/001/index.json", wantOK: false},
+		{name: "zip cache snapshot", path: "/admin/zipcache.json", wantOK: true, want: RouteZipCacheSnapshot},
+		{name: "admin config", path: "/admin/config.json", wantOK: true, want: RouteAdminConfig},
+		{name: "admin metadata", path: "/admin/metadata.json", wantOK: true, want: RouteAdminMetadata},
+		{name: "admin archive snapshot", path: "/admin/archive-snapshot.json", wantOK: true, want: RouteAdminArchiveSnapshot},
 		{name: "unknown route under log", path: "/digicert/unknown", wantOK: false},
 		{name: "unknown top-level", path: "/nope", wantOK: false},
 	}
@@ -56,6 +84,116 @@ func TestParseRoute(t *testing.T) {
 	}
 }
 
+func TestParseRouteWithOptions_MaxLogNameLength(t *testing.T) {
+	t.Parallel()
+
+	opts := RouteOptions{MaxLogNameLength: 8}
+	if _, ok := ParseRouteWithOptions("/digicert/checkpoint", opts); !ok {
+		t.Fatalf("ParseRouteWithOptions(8-byte log) ok = false, want true")
+	}
+	if _, ok := ParseRouteWithOptions("/digicert1/checkpoint", opts); ok {
+		t.Fatalf("ParseRouteWithOptions(9-byte log) ok = true, want false")
+	}
+}
+
+func TestParseRouteWithOptions_MonitorJSONAlias(t *testing.T) {
+	t.Parallel()
+
+	r, ok := ParseRouteWithOptions("/monitor.json", RouteOptions{MonitorJSONAlias: true})
+	if !ok || r.Kind != RouteLogListV3JSON {
+		t.Fatalf("ParseRouteWithOptions(/monitor.json) = %+v, %v, want RouteLogListV3JSON", r, ok)
+	}
+}
+
+func TestParseRouteWithOptions_AllowUppercaseX(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path          string
+		wantKind      RouteKind
+		wantEntryPath string
+		wantIndex     uint64
+		wantWidth     uint8
+	}{
+		{path: "/digicert/tile/1/X001/X234/067", wantKind: RouteHashTile, wantEntryPath: "tile/1/x001/x234/067", wantIndex: 1_234_067},
+		{path: "/digicert/tile/0/X001/234", wantKind: RouteHashTile, wantEntryPath: "tile/0/x001/234", wantIndex: 1234},
+		{path: "/digicert/tile/0/X001.p/7", wantKind: RouteHashTile, wantEntryPath: "tile/0/x001.p/7", wantIndex: 1, wantWidth: 7},
+		{path: "/digicert/tile/data/X005/482", wantKind: RouteDataTile, wantEntryPath: "tile/data/x005/482", wantIndex: 5482},
+		{path: "/digicert/tile/data/x001/X234.p/17", wantKind: RouteDataTile, wantEntryPath: "tile/data/x001/x234.p/17", wantIndex: 1234, wantWidth: 17},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			// Strict lowercase by default.
+			if _, ok := ParseRoute(tt.path); ok {
+				t.Fatalf("ParseRoute(%q) ok = true, want false without AllowUppercaseX", tt.path)
+			}
+
+			got, ok := ParseRouteWithOptions(tt.path, RouteOptions{AllowUppercaseX: true})
+			if !ok {
+				t.Fatalf("ParseRouteWithOptions(%q) ok = false, want true", tt.path)
+			}
+			if got.Kind != tt.wantKind || got.EntryPath != tt.wantEntryPath || got.TileIndex != tt.wantIndex ||
+				got.TilePartialWidth != tt.wantWidth || got.TileIsPartial != (tt.wantWidth != 0) {
+				t.Errorf("ParseRouteWithOptions(%q) = %+v, want kind %v, entry %q, index %d, width %d",
+					tt.path, got, tt.wantKind, tt.wantEntryPath, tt.wantIndex, tt.wantWidth)
+			}
+		})
+	}
+
+	// Digits after the prefix must still be decimal, and only a single prefix is stripped.
+	for _, path := range []string{"/digicert/tile/0/X00a/234", "/digicert/tile/0/XX01/234", "/digicert/tile/0/X1/234"} {
+		if _, ok := ParseRouteWithOptions(path, RouteOptions{AllowUppercaseX: true}); ok {
+			t.Errorf("ParseRouteWithOptions(%q) ok = true, want false", path)
+		}
+	}
+}
+
+func TestParseRouteWithOptions_AllowPercentEncoding(t *testing.T) {
+	t.Parallel()
+
+	opts := RouteOptions{AllowPercentEncoding: true}
+	tests := []struct {
+		name          string
+		path          string
+		wantOK        bool
+		wantKind      RouteKind
+		wantEntryPath string
+	}{
+		{name: "encoded letter", path: "/digicert/%63heckpoint", wantOK: true, wantKind: RouteCheckpoint, wantEntryPath: "checkpoint"},
+		{name: "encoded partial dot", path: "/digicert/tile/data/x001%2Ep/17", wantOK: true, wantKind: RouteDataTile, wantEntryPath: "tile/data/x001.p/17"},
+		{name: "encoded log name", path: "/digi%63ert/tile/0/x001/234", wantOK: true, wantKind: RouteHashTile, wantEntryPath: "tile/0/x001/234"},
+		{name: "unencoded path", path: "/digicert/checkpoint", wantOK: true, wantKind: RouteCheckpoint, wantEntryPath: "checkpoint"},
+		{name: "encoded traversal", path: "/digicert/%2e%2e/checkpoint", wantOK: false},
+		{name: "half encoded traversal", path: "/digicert/.%2E/checkpoint", wantOK: false},
+		{name: "encoded slash", path: "/digicert/tile%2F0%2Fx001/234", wantOK: false},
+		{name: "encoded lowercase slash", path: "/digicert/tile%2f0/000", wantOK: false},
+		{name: "encoded backslash", path: "/digicert/%5Ccheckpoint", wantOK: false},
+		{name: "encoded null", path: "/digicert/checkpoint%00", wantOK: false},
+		{name: "double encoded traversal", path: "/digicert/%252e%252e/checkpoint", wantOK: false},
+		{name: "invalid escape", path: "/digicert/%zzcheckpoint", wantOK: false},
+		{name: "truncated escape", path: "/digicert/checkpoint%2", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := ParseRouteWithOptions(tt.path, opts)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseRouteWithOptions(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			}
+			if ok && (got.Kind != tt.wantKind || got.EntryPath != tt.wantEntryPath || got.Log != "digicert") {
+				t.Errorf("ParseRouteWithOptions(%q) = %+v, want kind %v, entry %q", tt.path, got, tt.wantKind, tt.wantEntryPath)
+			}
+			// Without the option any percent-encoding is rejected.
+			if _, ok := ParseRoute(tt.path); ok && strings.Contains(tt.path, "%") {
+				t.Errorf("ParseRoute(%q) ok = true, want false by default", tt.path)
+			}
+		})
+	}
+}
+
 func TestDecodeTlogIndexSegments(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,120 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SelfTest serves a known log end-to-end through the route handlers without a network
+// listener (CT_STARTUP_SELFTEST). It picks the first discovered log (by name) that has
+// 000.zip and requests its checkpoint, log.v3.json and the first tile found in 000.zip,
+// returning an error if any response is not 2xx. This surfaces archive or permission
+// problems at startup instead of on the first external request. The requests bypass
+// ServeHTTP, so they are not counted in the request metrics or access logged.
+func (s *Server) SelfTest(ctx context.Context) error {
+	if s.archiveIndex == nil || s.zipReader == nil {
+		return errors.New("self-test: server not fully initialized")
+	}
+
+	archiveLog, ok := s.selfTestLog()
+	if !ok {
+		return errors.New("self-test: no log with 000.zip discovered")
+	}
+
+	tilePath, err := s.selfTestTilePath(ctx, archiveLog)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{
+		"/" + archiveLog.Log + "/checkpoint",
+		"/" + archiveLog.Log + "/log.v3.json",
+		tilePath,
+	}
+	for _, path := range paths {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return fmt.Errorf("self-test: GET %s: %w", path, err)
+		}
+		route, ok := ParseRouteWithOptions(path, RouteOptions{MaxLogNameLength: s.cfg.MaxLogNameLength})
+		if !ok {
+			return fmt.Errorf("self-test: GET %s: not a valid route", path)
+		}
+		w := &selfTestResponseWriter{header: make(http.Header), status: http.StatusOK}
+		s.dispatch(w, req, route)
+		if w.status < 200 || w.status >= 300 {
+			return fmt.Errorf("self-test: GET %s returned %d", path, w.status)
+		}
+		if s.logger != nil {
+			s.logger.Debug("Self-test request succeeded", "path", path, "status", w.status, "bytes", w.written)
+		}
+	}
+	return nil
+}
+
+// selfTestResponseWriter records the status and body size of a self-test response and
+// discards the body.
+type selfTestResponseWriter struct {
+	header      http.Header
+	status      int
+	written     int
+	wroteHeader bool
+}
+
+func (w *selfTestResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *selfTestResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+}
+
+func (w *selfTestResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.written += len(b)
+	return len(b), nil
+}
+
+// selfTestLog returns the first log (by name) that has a 000.zip part.
+func (s *Server) selfTestLog() (ArchiveLog, bool) {
+	logs := s.archiveIndex.GetAllLogs().Logs
+	names := make([]string, 0, len(logs))
+	for name := range logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		l := logs[name]
+		if len(l.ZipParts) > 0 && l.ZipParts[0] == 0 {
+			return l, true
+		}
+	}
+	return ArchiveLog{}, false
+}
+
+// selfTestTilePath returns the request path of the first tile entry in the log's 000.zip.
+func (s *Server) selfTestTilePath(ctx context.Context, archiveLog ArchiveLog) (string, error) {
+	entries, err := s.zipReader.ListEntries(ctx, archiveLog.ZipPartPath(0))
+	if err != nil {
+		return "", fmt.Errorf("self-test: list %s 000.zip: %w", archiveLog.Log, err)
+	}
+	for _, e := range entries {
+		rel, ok := strings.CutPrefix(e.Name, s.zipEntryName(""))
+		if !ok || !strings.HasPrefix(rel, "tile/") {
+			continue
+		}
+		path := "/" + archiveLog.Log + "/" + rel
+		route, ok := ParseRouteWithOptions(path, RouteOptions{MaxLogNameLength: s.cfg.MaxLogNameLength})
+		if ok && (route.Kind == RouteHashTile || route.Kind == RouteDataTile) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("self-test: no tile entries found in %s 000.zip", archiveLog.Log)
+}
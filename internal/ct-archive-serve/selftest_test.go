@@ -0,0 +1,109 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_SelfTest(t *testing.T) {
+	t.Parallel()
+
+	healthy := map[string][]byte{
+		"checkpoint":  []byte("checkpoint data"),
+		"log.v3.json": []byte(`{"description":"Test"}`),
+		"tile/0/x000": []byte("hash tile"),
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string][]byte // nil writes a corrupt 000.zip
+		wantErr bool
+	}{
+		{name: "healthy archive", files: healthy},
+		{name: "missing checkpoint", files: map[string][]byte{
+			"log.v3.json": []byte(`{"description":"Test"}`),
+			"tile/0/x000": []byte("hash tile"),
+		}, wantErr: true},
+		{name: "no tiles", files: map[string][]byte{
+			"checkpoint":  []byte("checkpoint data"),
+			"log.v3.json": []byte(`{"description":"Test"}`),
+		}, wantErr: true},
+		{name: "corrupt zip", files: nil, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			logFolder := filepath.Join(root, "ct_test_log")
+			if err := os.MkdirAll(logFolder, 0o700); err != nil {
+				t.Fatalf("MkdirAll() error = %v", err)
+			}
+			zipPath := filepath.Join(logFolder, "000.zip")
+			if tc.files == nil {
+				if err := os.WriteFile(zipPath, []byte("not-a-zip"), 0o600); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			} else {
+				mustCreateZip(t, zipPath, tc.files)
+			}
+
+			cfg := Config{
+				ArchivePath:          root,
+				ArchiveFolderPattern: "ct_*",
+				ArchiveFolderPrefix:  "ct_",
+			}
+			reg := prometheus.NewRegistry()
+			metrics := NewMetrics(reg)
+			archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+			if err != nil {
+				t.Fatalf("NewArchiveIndex() error = %v", err)
+			}
+			zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+			server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+
+			err = server.SelfTest(context.Background())
+			if tc.wantErr && err == nil {
+				t.Fatalf("SelfTest() error = nil, want error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("SelfTest() error = %v, want nil", err)
+			}
+
+			// Self-test requests are not client traffic.
+			mfs, err := reg.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+			if got := metricLogLabels(mfs, "ct_archive_serve_http_log_requests_total"); len(got) != 0 {
+				t.Errorf("log_requests_total series = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestServer_SelfTest_NoLogs(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{
+		ArchivePath:          t.TempDir(),
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	server := NewServer(cfg, nil, NewMetrics(prometheus.NewRegistry()), archiveIndex, zr, nil)
+
+	if err := server.SelfTest(context.Background()); err == nil {
+		t.Fatalf("SelfTest() error = nil, want error for empty archive")
+	}
+}
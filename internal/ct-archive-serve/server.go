@@ -1,7 +1,8 @@
 package ctarchiveserve
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,11 +10,16 @@ import (
 	"net"
 	"net/http"
 	"net/netip"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/semaphore"
 )
 
 // copyBufPool is a sync.Pool for reusable 32KB buffers used with io.CopyBuffer.
@@ -25,10 +31,48 @@ var copyBufPool = sync.Pool{
 	},
 }
 
+// copyWithContext copies src to dst like io.CopyBuffer, but checks ctx between chunks so a
+// cancelled request (e.g. client disconnect) stops reading from the zip promptly instead of
+// decompressing until the next write fails.
+func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader, buf []byte) (int64, error) {
+	var written int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err //nolint:wrapcheck // context errors are returned as-is for errors.Is checks
+		}
+		nr, rerr := src.Read(buf)
+		if nr > 0 {
+			nw, werr := dst.Write(buf[:nr])
+			written += int64(nw)
+			if werr != nil {
+				return written, werr //nolint:wrapcheck // pass-through, same as io.Copy
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		if rerr == io.EOF {
+			return written, nil
+		}
+		if rerr != nil {
+			return written, rerr //nolint:wrapcheck // pass-through, same as io.Copy
+		}
+	}
+}
+
 // immutableCacheControl is the Cache-Control header value for immutable archive content.
 // Archive tiles, issuers, and checkpoints are content-addressed and never change.
 const immutableCacheControl = "public, max-age=31536000, immutable"
 
+// acceptRangesNone is the Accept-Ranges header value for streamed archive content.
+// Only tiles support Range requests (see serveTile); say so explicitly elsewhere so
+// clients don't attempt resumable downloads that would be silently ignored.
+const acceptRangesNone = "none"
+
+// staleWarning is the Warning header value emitted when /logs.v3.json is served from a
+// snapshot that is older than expected.
+const staleWarning = `110 - "Response is Stale"`
+
 // Server is the HTTP server for ct-archive-serve.
 type Server struct {
 	cfg     Config
@@ -40,6 +84,37 @@ type Server struct {
 	archiveIndex *ArchiveIndex
 	zipReader    *ZipReader
 	logListV3JSON  *LogListV3JSONBuilder
+
+	// checkpointWatcher serves checkpoint long-polls; nil when they are disabled.
+	checkpointWatcher *checkpointWatcher
+
+	// checkpointInfo caches parsed checkpoints for CT_CHECKPOINT_HEADERS; nil when disabled.
+	checkpointInfo *checkpointInfoCache
+
+	// issuerSem bounds concurrent issuer reads (CT_ISSUER_READ_CONCURRENCY); nil when
+	// unlimited.
+	issuerSem *semaphore.Weighted
+
+	// bulkSem bounds concurrent /<log>/download.tar streams (CT_BULK_DOWNLOAD_CONCURRENCY);
+	// nil when bulk download is disabled.
+	bulkSem *semaphore.Weighted
+
+	// heavySem is the shared budget for bulk downloads, issuer listings and zip part
+	// manifests (CT_HEAVY_OP_CONCURRENCY); nil when unlimited.
+	heavySem *semaphore.Weighted
+
+	// diskFree reports free bytes on the archive filesystem for CT_MIN_FREE_DISK_BYTES;
+	// diskLow records whether the last probe was below it, to log only transitions.
+	diskFree func(path string) (uint64, error)
+	diskLow  atomic.Bool
+
+	// mountProbe feeds /readyz with the archive mount health (CT_ARCHIVE_PROBE_INTERVAL);
+	// nil when disabled.
+	mountProbe *MountProbe
+
+	// upstream serves logs missing from the archive index from another instance
+	// (CT_UPSTREAM_BASE_URL); nil when disabled.
+	upstream *upstreamReader
 }
 
 // NewServer constructs a new Server instance.
@@ -51,7 +126,7 @@ func NewServer(
 	zipReader *ZipReader,
 	logListV3JSON *LogListV3JSONBuilder,
 ) *Server {
-	return &Server{
+	s := &Server{
 		cfg:          cfg,
 		logger:      logger,
 		metrics:     metrics,
@@ -59,7 +134,37 @@ func NewServer(
 		archiveIndex: archiveIndex,
 		zipReader:   zipReader,
 		logListV3JSON: logListV3JSON,
+		diskFree:    freeDiskBytes,
 	}
+	if cfg.CheckpointLongPollMaxWait > 0 && archiveIndex != nil {
+		entryName := cfg.CheckpointEntryName
+		if entryName == "" {
+			entryName = DefaultCheckpointEntryName
+		}
+		s.checkpointWatcher = newCheckpointWatcher(cfg.ZipEntryPrefix+entryName, cfg.CheckpointLongPollMaxWaiters, logger)
+		archiveIndex.OnRefresh(s.checkpointWatcher.refresh)
+	}
+	if cfg.CheckpointHeaders {
+		s.checkpointInfo = newCheckpointInfoCache()
+	}
+	if cfg.IssuerReadConcurrency > 0 {
+		s.issuerSem = semaphore.NewWeighted(int64(cfg.IssuerReadConcurrency))
+	}
+	if cfg.EnableBulkDownload && cfg.BulkDownloadConcurrency > 0 {
+		s.bulkSem = semaphore.NewWeighted(int64(cfg.BulkDownloadConcurrency))
+	}
+	if cfg.HeavyOpConcurrency > 0 {
+		s.heavySem = semaphore.NewWeighted(int64(cfg.HeavyOpConcurrency))
+	}
+	if cfg.UpstreamBaseURL != "" && zipReader != nil {
+		s.upstream = newUpstreamReader(cfg.UpstreamBaseURL, cfg.UpstreamTimeout, zipReader.entryCache)
+	}
+	return s
+}
+
+// SetMountProbe makes /readyz report not ready while p finds the archive mount unhealthy.
+func (s *Server) SetMountProbe(p *MountProbe) {
+	s.mountProbe = p
 }
 
 // SetVerbose enables verbose logging (logs 2xx responses).
@@ -70,35 +175,108 @@ func (s *Server) SetVerbose(v bool) {
 // ServeHTTP implements http.Handler.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	route, ok := ParseRoute(r.URL.Path)
+	s.metrics.IncRequestMethod(r.Method)
+	id := s.requestID(r)
+	r = withRequestID(r, id)
+	w.Header().Set(requestIDHeader, id)
+	// Route the path as sent: net/http has already decoded r.URL.Path, which would let
+	// percent-encoded paths past the parser's checks.
+	route, ok := ParseRouteWithOptions(r.URL.EscapedPath(), RouteOptions{
+		MaxLogNameLength:     s.cfg.MaxLogNameLength,
+		AllowUppercaseX:      s.cfg.TileAllowUppercaseX,
+		AllowPercentEncoding: s.cfg.AllowPercentEncoding,
+		MonitorJSONAlias:     s.cfg.MonitorJSONAlias,
+	})
+
+	// Checkpoint long-polls write nothing while they wait, so a progress deadline would
+	// cut them off; CT_HTTP_WRITE_TIMEOUT still bounds them.
+	if s.cfg.HTTPStreamTimeout > 0 && !(ok && s.isCheckpointLongPoll(r, route)) {
+		w = newStreamDeadlineWriter(w, s.cfg.HTTPStreamTimeout)
+	}
+	if s.cfg.HTTPStreamFlushInterval > 0 {
+		w = newStreamFlushWriter(w, s.cfg.HTTPStreamFlushInterval)
+	}
 	
 	// Create a response writer that captures status code
-	rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK, defaultCacheControl: s.cfg.HTTPDefaultCacheControl}
+
+	if s.cfg.HTTPMaxHeaderCount > 0 && headerLineCount(r.Header) > s.cfg.HTTPMaxHeaderCount {
+		http.Error(rw, "Request Header Fields Too Large", http.StatusRequestHeaderFieldsTooLarge)
+		s.logRequest(r, route, rw.statusCode, time.Since(start))
+		return
+	}
+
+	if s.blockedUserAgent(r) {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		s.logRequest(r, route, rw.statusCode, time.Since(start))
+		return
+	}
 
 	if !ok {
 		// Unknown/unsupported routes return 404 regardless of method per spec.md FR-002a
-		http.NotFound(rw, r)
+		s.notFound(rw, r)
+		s.logRequest(r, route, rw.statusCode, time.Since(start))
+		return
+	}
+
+	// Enforce HTTP method policy per spec.md FR-002a: content routes allow only GET and
+	// HEAD, and each route advertises its own methods (see allowedMethods).
+	if !methodAllowed(route, r.Method) {
+		s.methodNotAllowed(rw, r, route)
 		s.logRequest(r, route, rw.statusCode, time.Since(start))
 		return
 	}
 
-	// Enforce HTTP method policy per spec.md FR-002a
-	// For supported routes, only GET and HEAD are allowed
-	if !s.isMethodAllowed(r.Method) {
-		rw.Header().Set("Allow", "GET, HEAD")
-		rw.statusCode = http.StatusMethodNotAllowed
-		http.Error(rw, "Method Not Allowed", http.StatusMethodNotAllowed)
+	if route.Kind == RouteSubmission {
+		s.handleSubmission(rw, r, route)
 		s.logRequest(r, route, rw.statusCode, time.Since(start))
 		return
 	}
 
+	if route.Log != "" {
+		if _, collided := s.archiveIndex.LookupCollision(route.Log); collided {
+			// The folders are only logged at refresh time; they are not the client's business.
+			http.Error(rw, "Conflict: several archive folders map to this log name", http.StatusConflict)
+			s.logRequest(r, route, rw.statusCode, time.Since(start))
+			return
+		}
+	}
+
+	if timeout := s.contentTimeout(r, route); timeout > 0 {
+		if !s.serveWithContentTimeout(rw, r, route, timeout) {
+			// Part of the body is already out; abort the connection instead of ending
+			// the response cleanly.
+			s.logRequest(r, route, rw.statusCode, time.Since(start))
+			panic(http.ErrAbortHandler)
+		}
+	} else {
+		s.dispatch(rw, r, route)
+	}
+	// Handlers that return without writing (e.g. HEAD) get an implicit 200 from net/http.
+	rw.applyDefaultCacheControl(rw.statusCode)
+	
+	s.logRequest(r, route, rw.statusCode, time.Since(start))
+}
+
+// dispatch calls the handler for a parsed GET/HEAD route.
+func (s *Server) dispatch(rw http.ResponseWriter, r *http.Request, route Route) {
+	if s.upstream != nil && upstreamRoute(route.Kind) {
+		if _, ok := s.archiveIndex.LookupLog(route.Log); !ok {
+			s.serveUpstream(rw, r, route)
+			return
+		}
+	}
 	switch route.Kind {
 	case RouteMetrics:
 		s.handleMetrics(rw, r)
 	case RouteLogListV3JSON:
 		s.handleLogListV3JSON(rw, r)
+	case RouteLogsTXT:
+		s.handleLogsTXT(rw, r)
 	case RouteCheckpoint:
 		s.handleCheckpoint(rw, r, route)
+	case RouteCheckpointWitnessed:
+		s.handleCheckpointWitnessed(rw, r, route)
 	case RouteLogV3JSON:
 		s.handleLogV3JSON(rw, r, route)
 	case RouteHashTile:
@@ -107,31 +285,61 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.handleDataTile(rw, r, route)
 	case RouteIssuer:
 		s.handleIssuer(rw, r, route)
+	case RouteIssuerList:
+		s.handleIssuerList(rw, r, route)
+	case RouteBulkDownload:
+		s.handleBulkDownload(rw, r, route)
+	case RouteZipPartManifest:
+		s.handleZipPartManifest(rw, r, route)
+	case RouteZipCacheSnapshot:
+		s.handleZipCacheSnapshot(rw, r)
+	case RouteAdminConfig:
+		s.handleAdminConfig(rw, r)
+	case RouteAdminMetadata:
+		s.handleAdminMetadata(rw, r)
+	case RouteAdminArchiveSnapshot:
+		s.handleAdminArchiveSnapshot(rw, r)
+	case RouteFavicon:
+		s.handleFavicon(rw, r)
+	case RouteRobotsTXT:
+		s.handleRobotsTXT(rw, r)
+	case RouteReadyz:
+		s.handleReadyz(rw, r)
+	case RouteRoot:
+		s.handleRoot(rw, r)
 	default:
 		// Other routes will be implemented in later tasks
-		http.NotFound(rw, r)
+		s.notFound(rw, r)
 	}
-	
-	s.logRequest(r, route, rw.statusCode, time.Since(start))
 }
 
-// isMethodAllowed returns true if the HTTP method is allowed (GET or HEAD).
-func (s *Server) isMethodAllowed(method string) bool {
-	return method == http.MethodGet || method == http.MethodHead
+// headerLineCount returns the number of request header lines (repeated fields count once
+// per occurrence). CT_HTTP_MAX_HEADER_BYTES bounds their total size; this bounds how many.
+func headerLineCount(h http.Header) int {
+	n := 0
+	for _, vs := range h {
+		n += len(vs)
+	}
+	return n
 }
 
-// handleMetrics serves GET /metrics via promhttp.
+// handleMetrics serves GET /metrics via promhttp, from the registry s.metrics is
+// registered with.
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	// Exemplars are only exposed in the OpenMetrics format, which promhttp negotiates
+	// with scrapers that ask for it.
+	handler := promhttp.HandlerFor(s.metrics.Gatherer(), promhttp.HandlerOpts{EnableOpenMetrics: s.cfg.MetricsExemplars})
 	
 	// For HEAD requests, use a response writer that discards the body
 	if r.Method == http.MethodHead {
 		headWriter := &headResponseWriter{ResponseWriter: w}
-		promhttp.Handler().ServeHTTP(headWriter, r)
+		handler.ServeHTTP(headWriter, r)
 		return
 	}
 	
-	promhttp.Handler().ServeHTTP(w, r)
+	handler.ServeHTTP(w, r)
 }
 
 // headResponseWriter wraps http.ResponseWriter to discard body for HEAD requests.
@@ -146,17 +354,53 @@ func (w *headResponseWriter) Write(b []byte) (int, error) {
 
 // handleLogListV3JSON serves GET /logs.v3.json per spec.md FR-006.
 func (s *Server) handleLogListV3JSON(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.DisableLogListV3JSON {
+		// Endpoint turned off via CT_ENABLE_LOGLISTV3_JSON=false.
+		s.notFound(w, r)
+		return
+	}
+	if s.cfg.LogListV3JSONStaticGz != "" || s.cfg.HTTPBrotli {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	useBrotli := s.cfg.HTTPBrotli && acceptsBrotli(r.Header.Get("Accept-Encoding"))
+	if s.cfg.LogListV3JSONStaticGz != "" && !useBrotli {
+		// The precompressed file only has the unfiltered list; other requests are rendered.
+		if !r.URL.Query().Has("has_issuers") && acceptsGzip(r.Header.Get("Accept-Encoding")) &&
+			s.serveLogListV3JSONStaticGz(w, r) {
+			return
+		}
+	}
 	if s.logListV3JSON == nil {
 		http.Error(w, "Logs.v3.json not initialized", http.StatusInternalServerError)
 		return
 	}
+	s.setRefreshHeaders(w)
+
+	// ?has_issuers=true|false restricts the tiled logs to those with (or without) issuers.
+	var hasIssuers *bool
+	if v := r.URL.Query().Get("has_issuers"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "has_issuers must be true or false", http.StatusBadRequest)
+			return
+		}
+		hasIssuers = &b
+	}
 
 	// Derive public base URL from request
 	publicBaseURL := s.derivePublicBaseURL(r)
 
-	// Get snapshot with URLs set from this request's publicBaseURL
-	snap := s.logListV3JSON.GetSnapshotForRequest(publicBaseURL)
-	if snap == nil || snap.LastError != nil {
+	// Get the body rendered with URLs from this request's publicBaseURL. It is cached per
+	// base URL and filter, so a HEAD followed by a GET renders once.
+	snap, body, err := s.logListV3JSON.RenderFilteredForRequest(publicBaseURL, hasIssuers)
+	if err != nil {
+		if s.logger != nil {
+			s.requestLogger(r).Error("Failed to encode logs.v3.json", "error", err)
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if body == nil {
 		// Refresh failure behavior per FR-006: return 503
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -165,11 +409,49 @@ func (s *Server) handleLogListV3JSON(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(snap); err != nil {
+	// Tell clients when the snapshot is older than expected (RFC 7234 warn-code 110).
+	// The age is only logged so the JSON body keeps validating as a v3 log list.
+	if age, stale := s.logListV3JSON.Staleness(snap); stale {
+		w.Header().Set("Warning", staleWarning)
 		if s.logger != nil {
-			s.logger.Error("Failed to encode logs.v3.json", "error", err)
+			s.requestLogger(r).Warn("Serving stale logs.v3.json", "stale_seconds", int64(age.Seconds()), "built_at", snap.BuiltAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	etag := snap.ETagForRequest(logListBodyKey(publicBaseURL, hasIssuers))
+	if useBrotli {
+		w.Header().Set("Content-Encoding", "br")
+		etag = brotliETag(etag)
+	}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+		// If-Match is evaluated before If-None-Match (RFC 9110 section 13.2.2).
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && !etagMatchesStrong(ifMatch, etag) {
+			http.Error(w, "Precondition Failed", http.StatusPreconditionFailed)
+			return
+		}
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	if useBrotli {
+		// The compressed length is only known once written, so the response is chunked.
+		if r.Method == http.MethodHead {
+			return // HEAD: no body
+		}
+		if err := writeBrotli(w, body); err != nil {
+			s.logCopyError(r, "Failed to write logs.v3.json response", "error", err)
 		}
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+	if _, err := w.Write(body); err != nil {
+		s.logCopyError(r, "Failed to write logs.v3.json response", "error", err)
 	}
 }
 
@@ -182,42 +464,50 @@ func (s *Server) handleCheckpoint(w http.ResponseWriter, r *http.Request, route
 
 	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
-	zipPath := archiveLog.FolderPath + "/000.zip"
-	rc, err := s.zipReader.OpenEntry(zipPath, "checkpoint")
+	entryName := s.cfg.CheckpointEntryName
+	if entryName == "" {
+		entryName = DefaultCheckpointEntryName
+	}
+	s.setLinkHeaders(w, r, route)
+
+	if s.checkpointWatcher != nil && r.URL.Query().Has("wait") {
+		s.handleCheckpointLongPoll(w, r, route, archiveLog, entryName)
+		return
+	}
+
+	zipPath := archiveLog.ZipPartPath(0)
+	rc, err := s.openMetadataEntry(r, zipPath, s.zipEntryName(entryName))
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			http.NotFound(w, r)
-			return
-		}
-		if errors.Is(err, ErrZipTemporarilyUnavailable) {
-			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		s.writeOpenEntryError(w, r, err)
 		return
 	}
 	defer func() { _ = rc.Close() }()
 
+	if s.checkpointInfo != nil {
+		s.serveCheckpointWithHeaders(w, r, route, rc)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
 	if r.Method == http.MethodHead {
 		return // HEAD: no body
 	}
 
 	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
 	defer copyBufPool.Put(bufp)
-	if _, err := io.CopyBuffer(w, rc, *bufp); err != nil {
-		if s.logger != nil {
-			s.logger.Error("Failed to write checkpoint response", "log", route.Log, "error", err)
-		}
+	if _, err := copyWithContext(r.Context(), w, rc, *bufp); err != nil {
+		s.logCopyError(r, "Failed to write checkpoint response", "log", route.Log, "error", err)
 	}
 }
 
-// handleLogV3JSON serves GET /<log>/log.v3.json per spec.md FR-002, FR-009.
+// handleLogV3JSON serves GET /<log>/log.v3.json per spec.md FR-002, FR-009, from the log
+// folder's log.v3.json file or the 000.zip entry as CT_METADATA_SOURCE selects.
 func (s *Server) handleLogV3JSON(w http.ResponseWriter, r *http.Request, route Route) {
 	if s.zipReader == nil || s.archiveIndex == nil {
 		http.Error(w, "Server not fully initialized", http.StatusInternalServerError)
@@ -226,38 +516,37 @@ func (s *Server) handleLogV3JSON(w http.ResponseWriter, r *http.Request, route R
 
 	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
-	zipPath := archiveLog.FolderPath + "/000.zip"
-	rc, err := s.zipReader.OpenEntry(zipPath, "log.v3.json")
+	var rc io.ReadCloser
+	metaPath, _, err := metadataFile(s.cfg.MetadataSource, archiveLog.FolderPath)
+	switch {
+	case err != nil:
+	case metaPath != "":
+		rc, err = os.Open(metaPath)
+	default:
+		rc, err = s.openMetadataEntry(r, archiveLog.ZipPartPath(0), s.zipEntryName(logV3JSONFileName))
+	}
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			http.NotFound(w, r)
-			return
-		}
-		if errors.Is(err, ErrZipTemporarilyUnavailable) {
-			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		s.writeOpenEntryError(w, r, err)
 		return
 	}
 	defer func() { _ = rc.Close() }()
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
+	s.setRefreshHeaders(w)
 	if r.Method == http.MethodHead {
 		return // HEAD: no body
 	}
 
 	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
 	defer copyBufPool.Put(bufp)
-	if _, err := io.CopyBuffer(w, rc, *bufp); err != nil {
-		if s.logger != nil {
-			s.logger.Error("Failed to write log.v3.json response", "log", route.Log, "error", err)
-		}
+	if _, err := copyWithContext(r.Context(), w, rc, *bufp); err != nil {
+		s.logCopyError(r, "Failed to write log.v3.json response", "log", route.Log, "error", err)
 	}
 }
 
@@ -270,46 +559,32 @@ func (s *Server) handleHashTile(w http.ResponseWriter, r *http.Request, route Ro
 
 	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
 	// Select zip part for this tile
 	zipIndex, ok := s.archiveIndex.SelectZipPart(route.Log, route.TileLevel, route.TileIndex, false)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
-	zipPath := fmt.Sprintf("%s/%03d.zip", archiveLog.FolderPath, zipIndex)
-	rc, err := s.zipReader.OpenEntry(zipPath, route.EntryPath)
+	zipPath := archiveLog.ZipPartPath(zipIndex)
+	if s.partialFromFull(w, r, route, zipPath) {
+		return
+	}
+	rc, err := s.openEntry(r, zipPath, s.zipEntryName(route.EntryPath))
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			http.NotFound(w, r)
-			return
-		}
-		if errors.Is(err, ErrZipTemporarilyUnavailable) {
-			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		s.writeOpenEntryError(w, r, err)
 		return
 	}
 	defer func() { _ = rc.Close() }()
 
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Cache-Control", immutableCacheControl)
-	if r.Method == http.MethodHead {
-		return // HEAD: no body
-	}
-
-	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
-	defer copyBufPool.Put(bufp)
-	if _, err := io.CopyBuffer(w, rc, *bufp); err != nil {
-		if s.logger != nil {
-			s.logger.Error("Failed to write hash tile response", "log", route.Log, "level", route.TileLevel, "index", route.TileIndex, "error", err)
-		}
-	}
+	modtime := s.entryMetadata(w, r, zipPath, s.zipEntryName(route.EntryPath))
+	s.setLinkHeaders(w, r, route)
+	s.setContentHash(w, zipPath, s.zipEntryName(route.EntryPath))
+	s.serveTile(w, r, route, rc, modtime, "Failed to read hash tile", "log", route.Log, "level", route.TileLevel, "index", route.TileIndex)
 }
 
 // handleDataTile serves GET /<log>/tile/data/<N>[.p/<W>] per spec.md FR-002, FR-008, FR-008a.
@@ -321,48 +596,67 @@ func (s *Server) handleDataTile(w http.ResponseWriter, r *http.Request, route Ro
 
 	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
 	// Select zip part for this data tile
 	zipIndex, ok := s.archiveIndex.SelectZipPart(route.Log, 0, route.TileIndex, true)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
-	zipPath := fmt.Sprintf("%s/%03d.zip", archiveLog.FolderPath, zipIndex)
-	rc, err := s.zipReader.OpenEntry(zipPath, route.EntryPath)
+	zipPath := archiveLog.ZipPartPath(zipIndex)
+	if s.partialFromFull(w, r, route, zipPath) {
+		return
+	}
+	rc, err := s.openEntry(r, zipPath, s.zipEntryName(route.EntryPath))
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			http.NotFound(w, r)
-			return
-		}
-		if errors.Is(err, ErrZipTemporarilyUnavailable) {
-			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		s.writeOpenEntryError(w, r, err)
 		return
 	}
 	defer func() { _ = rc.Close() }()
 
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Cache-Control", immutableCacheControl)
-	if r.Method == http.MethodHead {
-		return // HEAD: no body
+	modtime := s.entryMetadata(w, r, zipPath, s.zipEntryName(route.EntryPath))
+	s.setLinkHeaders(w, r, route)
+	s.setContentHash(w, zipPath, s.zipEntryName(route.EntryPath))
+	s.serveTile(w, r, route, rc, modtime, "Failed to read data tile", "log", route.Log, "index", route.TileIndex)
+}
+
+// serveTile writes a tile through http.ServeContent, which handles Range, If-Range and
+// If-None-Match. Tiles are small, so the entry is read into memory to get a seekable body
+// and an ETag (a hash of the content). The ETag is strong for full tiles and weak for
+// partial ones, whose index gains leaves as the log grows; net/http compares
+// If-None-Match weakly and If-Range strongly (RFC 9110), so a partial tile still answers
+// revalidation with 304 but never serves a Range of a stale representation.
+// Last-Modified is only sent when modtime is non-zero (CT_ENTRY_METADATA_HEADERS);
+// otherwise an If-Range date never matches and yields the full tile.
+func (s *Server) serveTile(w http.ResponseWriter, r *http.Request, route Route, rc io.Reader, modtime time.Time, msg string, attrs ...interface{}) {
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		s.logCopyError(r, msg, append(attrs, "error", err)...)
+		s.writeOpenEntryError(w, r, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err))
+		return
+	}
+	if s.cfg.ValidateTileSize || (s.cfg.ValidateDataTiles && route.Kind == RouteDataTile && route.TileIsPartial) {
+		s.validateTileSize(r, route, data)
 	}
 
-	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
-	defer copyBufPool.Put(bufp)
-	if _, err := io.CopyBuffer(w, rc, *bufp); err != nil {
-		if s.logger != nil {
-			s.logger.Error("Failed to write data tile response", "log", route.Log, "index", route.TileIndex, "error", err)
-		}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", immutableCacheControl)
+	etag := `"` + strconv.FormatUint(xxhash.Sum64(data), 16) + `"`
+	if route.TileIsPartial {
+		etag = "W/" + etag
 	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", modtime, bytes.NewReader(data))
 }
 
+// issuerRetryAfterSeconds is the Retry-After sent when CT_ISSUER_READ_CONCURRENCY is
+// reached. Issuer reads are short, so slots free up quickly.
+const issuerRetryAfterSeconds = "1"
+
 // handleIssuer serves GET /<log>/issuer/<fingerprint> per spec.md FR-002, FR-009.
 func (s *Server) handleIssuer(w http.ResponseWriter, r *http.Request, route Route) {
 	if s.zipReader == nil || s.archiveIndex == nil {
@@ -372,56 +666,223 @@ func (s *Server) handleIssuer(w http.ResponseWriter, r *http.Request, route Rout
 
 	archiveLog, ok := s.archiveIndex.LookupLog(route.Log)
 	if !ok {
-		http.NotFound(w, r)
+		s.notFound(w, r)
 		return
 	}
 
-	// Issuers are in 000.zip
-	zipPath := archiveLog.FolderPath + "/000.zip"
-	rc, err := s.zipReader.OpenEntry(zipPath, route.EntryPath)
-	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			http.NotFound(w, r)
+	// A burst of issuer reads (e.g. a monitor backfilling chains) is shed here rather than
+	// queueing on the zip open semaphore that tile requests also need.
+	if s.issuerSem != nil {
+		if !s.issuerSem.TryAcquire(1) {
+			w.Header().Set("Retry-After", issuerRetryAfterSeconds)
+			http.Error(w, "Too many issuer reads", http.StatusServiceUnavailable)
 			return
 		}
-		if errors.Is(err, ErrZipTemporarilyUnavailable) {
-			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		defer s.issuerSem.Release(1)
+	}
+
+	// Issuers are in 000.zip
+	zipPath := archiveLog.ZipPartPath(0)
+	rc, err := s.openMetadataEntry(r, zipPath, s.zipEntryName(route.EntryPath))
+	if err != nil {
+		s.writeOpenEntryError(w, r, err)
 		return
 	}
 	defer func() { _ = rc.Close() }()
 
 	w.Header().Set("Content-Type", "application/pkix-cert")
 	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
 	if r.Method == http.MethodHead {
 		return // HEAD: no body
 	}
 
 	bufp, _ := copyBufPool.Get().(*[]byte) //nolint:errcheck // pool New always returns *[]byte
 	defer copyBufPool.Put(bufp)
-	if _, err := io.CopyBuffer(w, rc, *bufp); err != nil {
-		if s.logger != nil {
-			s.logger.Error("Failed to write issuer response", "log", route.Log, "fingerprint", route.IssuerFingerprint, "error", err)
+	if _, err := copyWithContext(r.Context(), w, rc, *bufp); err != nil {
+		s.logCopyError(r, "Failed to write issuer response", "log", route.Log, "fingerprint", route.IssuerFingerprint, "error", err)
+	}
+}
+
+// notFound writes a 404 response. By default this is http.NotFound's
+// "404 page not found"; CT_HTTP_NOT_FOUND_BODY and CT_HTTP_NOT_FOUND_CONTENT_TYPE
+// override the body and content type (e.g. for a JSON API surface).
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.HTTPNotFoundBody == "" && s.cfg.HTTPNotFoundContentType == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	body := s.cfg.HTTPNotFoundBody
+	if body == "" {
+		body = "404 page not found\n"
+	}
+	contentType := s.cfg.HTTPNotFoundContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusNotFound)
+	if r.Method == http.MethodHead {
+		return
+	}
+	//nolint:errcheck // If Write fails after WriteHeader, there's nothing we can do
+	_, _ = io.WriteString(w, body)
+}
+
+// statusClientClosedRequest is the de-facto (nginx) status recorded when the client went
+// away before a response could be produced. It is only visible in logs and metrics.
+const statusClientClosedRequest = 499
+
+// zipEntryName returns the zip entry name for an archive-relative path such as
+// route.EntryPath, applying CT_ZIP_ENTRY_PREFIX.
+func (s *Server) zipEntryName(name string) string {
+	return s.cfg.ZipEntryPrefix + name
+}
+
+// openEntry opens a zip entry for a response, counting where its content came from in
+// ct_archive_serve_content_source_total.
+func (s *Server) openEntry(r *http.Request, zipPath, entryName string) (io.ReadCloser, error) {
+	rc, src, err := s.zipReader.OpenEntrySource(r.Context(), zipPath, entryName)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.IncContentSource(src)
+	return rc, nil
+}
+
+// openMetadataEntry is openEntry for a log's metadata part (000.zip), which it then pins
+// in the zip part cache so tile reads do not evict it.
+func (s *Server) openMetadataEntry(r *http.Request, zipPath, entryName string) (io.ReadCloser, error) {
+	rc, err := s.openEntry(r, zipPath, entryName)
+	if err == nil {
+		s.zipReader.PinPart(zipPath)
+	}
+	return rc, err
+}
+
+// writeOpenEntryError maps a ZipReader.OpenEntry error to an HTTP response:
+// ErrNotFound -> 404, ErrZipTemporarilyUnavailable -> 503 (with Retry-After while a log
+// circuit breaker is open), a cancelled request -> 499,
+// a request deadline -> 503, anything else -> 500.
+func (s *Server) writeOpenEntryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		s.notFound(w, r)
+	case errors.Is(err, errLogCircuitOpen):
+		if d := s.cfg.LogCircuitCooldown; d > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((d+time.Second-1)/time.Second)))
 		}
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+	case errors.Is(err, ErrZipTemporarilyUnavailable):
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+	case errors.Is(err, context.Canceled):
+		// The client is gone; nothing useful can be written.
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
+// etagMatches reports whether an If-None-Match header value matches etag, using the weak
+// comparison RFC 9110 prescribes for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatchesStrong reports whether an If-Match header value matches etag, using the
+// strong comparison RFC 9110 prescribes for If-Match: weak candidates never match.
+func etagMatchesStrong(ifMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// logCopyError logs a failed response body copy. Copies aborted because the request
+// context was cancelled (client disconnected) are expected and logged at debug level.
+func (s *Server) logCopyError(r *http.Request, msg string, attrs ...interface{}) {
+	if s.logger == nil {
+		return
+	}
+	logger := s.requestLogger(r)
+	if r.Context().Err() != nil {
+		logger.Debug(msg, attrs...)
+		return
+	}
+	logger.Error(msg, attrs...)
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code.
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
+
+	// defaultCacheControl is CT_HTTP_DEFAULT_CACHE_CONTROL; see applyDefaultCacheControl.
+	defaultCacheControl string
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
+	rw.applyDefaultCacheControl(code)
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// logRequest logs HTTP requests per spec.md NFR-010.
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(b) //nolint:wrapcheck // pass-through writer
+}
+
+// applyDefaultCacheControl sets the default Cache-Control once the final status is known,
+// unless the handler chose its own policy or the response is an error. Informational
+// statuses are skipped.
+func (rw *responseWriter) applyDefaultCacheControl(code int) {
+	if rw.wroteHeader || code < http.StatusOK {
+		return
+	}
+	rw.wroteHeader = true
+	if rw.defaultCacheControl == "" || code >= http.StatusBadRequest {
+		return
+	}
+	if rw.Header().Get("Cache-Control") == "" {
+		rw.Header().Set("Cache-Control", rw.defaultCacheControl)
+	}
+}
+
+// logRequest records request metrics per spec.md NFR-009 and logs HTTP requests per spec.md NFR-010.
 // Always logs non-2xx responses. Logs 2xx only when verbose mode is enabled.
 func (s *Server) logRequest(r *http.Request, route Route, statusCode int, duration time.Duration) {
+	switch {
+	case route.Kind == RouteLogListV3JSON:
+		s.metrics.ObserveLogListV3JSONRequest(duration, s.requestTraceID(r))
+	case route.Log != "":
+		// Only logs in the index get series, so probing made-up log names cannot grow
+		// the metrics' cardinality.
+		if _, ok := s.archiveIndex.LookupLog(route.Log); ok {
+			s.metrics.ObserveLogRequest(route.Log, duration, s.requestTraceID(r))
+		}
+	}
+
 	if s.logger == nil {
 		return
 	}
@@ -443,6 +904,7 @@ func (s *Server) logRequest(r *http.Request, route Route, statusCode int, durati
 		"path", r.URL.Path,
 		"status", statusCode,
 		"duration_ms", duration.Milliseconds(),
+		"request_id", requestIDFrom(r.Context()),
 	}
 
 	if route.Log != "" {
@@ -473,27 +935,7 @@ func (s *Server) logRequest(r *http.Request, route Route, statusCode int, durati
 // matches a trusted source, it uses X-Forwarded-Host/X-Forwarded-Proto. Otherwise, it ignores
 // X-Forwarded-* headers and uses Host/http.
 func (s *Server) derivePublicBaseURL(r *http.Request) string {
-	// Extract source IP from RemoteAddr (format: "IP:port")
-	sourceIPStr, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		// Fallback: treat as untrusted if we can't parse
-		sourceIPStr = r.RemoteAddr
-	}
-
-	sourceIP, err := netip.ParseAddr(sourceIPStr)
-	if err != nil {
-		// Fallback: treat as untrusted if we can't parse
-		sourceIP = netip.Addr{}
-	}
-
-	// Check if source IP is trusted
-	isTrusted := false
-	for _, prefix := range s.cfg.HTTPTrustedSources {
-		if prefix.Contains(sourceIP) {
-			isTrusted = true
-			break
-		}
-	}
+	isTrusted := s.isTrustedSource(r)
 
 	// Determine host
 	var host string
@@ -519,7 +961,33 @@ func (s *Server) derivePublicBaseURL(r *http.Request) string {
 	scheme = strings.ToLower(scheme)
 
 	return scheme + "://" + host
-}// firstNonEmptyAfterTrim returns the first non-empty element after trimming ASCII whitespace.
+}
+
+// isTrustedSource reports whether the request's source IP matches CT_HTTP_TRUSTED_SOURCES,
+// i.e. whether proxy-supplied headers such as X-Forwarded-* may be believed.
+func (s *Server) isTrustedSource(r *http.Request) bool {
+	// Extract source IP from RemoteAddr (format: "IP:port")
+	sourceIPStr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// Fallback: treat as untrusted if we can't parse
+		sourceIPStr = r.RemoteAddr
+	}
+
+	sourceIP, err := netip.ParseAddr(sourceIPStr)
+	if err != nil {
+		// Fallback: treat as untrusted if we can't parse
+		return false
+	}
+
+	for _, prefix := range s.cfg.HTTPTrustedSources {
+		if prefix.Contains(sourceIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstNonEmptyAfterTrim returns the first non-empty element after trimming ASCII whitespace.
 func firstNonEmptyAfterTrim(elems []string) string {
 	for _, elem := range elems {
 		trimmed := strings.TrimSpace(elem)
@@ -1,15 +1,24 @@
 package ctarchiveserve
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/netip"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/certificate-transparency-go/loglist3"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -99,6 +108,53 @@ func TestHTTPMethodPolicy_UnknownRoutes_404(t *testing.T) {
 	}
 }
 
+func TestServer_NotFound_CustomBody(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{
+		ArchivePath:             "/tmp/test",
+		ArchiveFolderPattern:    "ct_*",
+		HTTPNotFoundBody:        `{"error":"not found"}`,
+		HTTPNotFoundContentType: "application/json",
+	}
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), NewMetrics(prometheus.NewRegistry()), nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown/route", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want %q", ct, "application/json")
+	}
+	if body := w.Body.String(); body != `{"error":"not found"}` {
+		t.Errorf("body = %q, want %q", body, `{"error":"not found"}`)
+	}
+}
+
+func TestServer_NotFound_DefaultBody(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{
+		ArchivePath:          "/tmp/test",
+		ArchiveFolderPattern: "ct_*",
+	}
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), NewMetrics(prometheus.NewRegistry()), nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown/route", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want %q", ct, "text/plain; charset=utf-8")
+	}
+	if body := w.Body.String(); body != "404 page not found\n" {
+		t.Errorf("body = %q, want %q", body, "404 page not found\n")
+	}
+}
+
 func TestPublicBaseURL_UntrustedSource_UsesHost(t *testing.T) {
 	t.Parallel()
 
@@ -270,6 +326,98 @@ func TestServer_HandleCheckpoint_200(t *testing.T) {
 	}
 }
 
+func TestServer_PercentEncodedPath(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"checkpoint": []byte("test checkpoint data"),
+	})
+
+	newServer := func(allow bool) *Server {
+		cfg := Config{
+			ArchivePath:          root,
+			ArchiveFolderPattern: "ct_*",
+			ArchiveFolderPrefix:  "ct_",
+			AllowPercentEncoding: allow,
+		}
+		archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		return NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+	}
+
+	for _, tt := range []struct {
+		allow bool
+		path  string
+		want  int
+	}{
+		// net/http decodes r.URL.Path; the router must still see the encoding.
+		{allow: false, path: "/test_log/%63heckpoint", want: http.StatusNotFound},
+		{allow: false, path: "/test_log/checkpoint", want: http.StatusOK},
+		{allow: true, path: "/test_log/%63heckpoint", want: http.StatusOK},
+		{allow: true, path: "/test_log/%2e%2e/test_log/checkpoint", want: http.StatusNotFound},
+		{allow: true, path: "/test_log%2Fcheckpoint", want: http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		newServer(tt.allow).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("allow=%v GET %s status = %d, want %d", tt.allow, tt.path, w.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && w.Body.String() != "test checkpoint data" {
+			t.Errorf("allow=%v GET %s body = %q", tt.allow, tt.path, w.Body.String())
+		}
+	}
+}
+
+func TestServer_HandleCheckpoint_CustomEntryName(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	zipPath := filepath.Join(logFolder, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		"checkpoint.txt": []byte("custom checkpoint data"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		CheckpointEntryName:  "checkpoint.txt",
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	zr := NewZipReader(zic)
+	server := NewServer(cfg, logger, metrics, archiveIndex, zr, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /test_log/checkpoint status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if body := w.Body.String(); body != "custom checkpoint data" {
+		t.Errorf("body = %q, want %q", body, "custom checkpoint data")
+	}
+}
+
 func TestServer_HandleCheckpoint_404(t *testing.T) {
 	t.Parallel()
 
@@ -431,6 +579,104 @@ func TestServer_HandleHashTile_200(t *testing.T) {
 	if body := w.Body.String(); body != "hash tile data" {
 		t.Errorf("body = %q, want %q", body, "hash tile data")
 	}
+	if ar := w.Header().Get("Accept-Ranges"); ar != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want %q", ar, "bytes")
+	}
+}
+
+func TestServer_HandleHashTile_BackslashEntryName(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	// Zip written with Windows-style separators.
+	zipPath := filepath.Join(logFolder, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		`tile\0\x000`: []byte("hash tile data"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	zr := NewZipReader(zic)
+	server := NewServer(cfg, logger, metrics, archiveIndex, zr, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/test_log/tile/0/x000", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /test_log/tile/0/x000 status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if body := w.Body.String(); body != "hash tile data" {
+		t.Errorf("body = %q, want %q", body, "hash tile data")
+	}
+}
+
+func TestServer_ZipEntryPrefix(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+
+	// Tiles and metadata nested under logdata/ rather than at the zip root.
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"logdata/tile/0/000": []byte("hash tile data"),
+		"logdata/checkpoint": checkpointBody(10),
+		"tile/0/001":         []byte("unprefixed"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		ZipEntryPrefix:       "logdata/",
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/test_log/tile/0/000", wantCode: http.StatusOK, wantBody: "hash tile data"},
+		{path: "/test_log/checkpoint", wantCode: http.StatusOK, wantBody: string(checkpointBody(10))},
+		{path: "/test_log/tile/0/001", wantCode: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.wantCode {
+			t.Errorf("GET %s status = %d, want %d", tc.path, w.Code, tc.wantCode)
+			continue
+		}
+		if tc.wantBody != "" && w.Body.String() != tc.wantBody {
+			t.Errorf("GET %s body = %q, want %q", tc.path, w.Body.String(), tc.wantBody)
+		}
+	}
 }
 
 func TestServer_HandleDataTile_200(t *testing.T) {
@@ -478,6 +724,141 @@ func TestServer_HandleDataTile_200(t *testing.T) {
 	if body := w.Body.String(); body != "data tile data" {
 		t.Errorf("body = %q, want %q", body, "data tile data")
 	}
+	if ar := w.Header().Get("Accept-Ranges"); ar != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want %q", ar, "bytes")
+	}
+}
+
+func TestServer_HandleDataTile_RangeAndIfRange(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/data/x000": []byte("0123456789"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil)
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test_log/tile/data/x000", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	etag := get(nil).Header().Get("ETag")
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("ETag = %q, want a strong validator", etag)
+	}
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+		wantBody string
+	}{
+		{name: "range", headers: map[string]string{"Range": "bytes=2-4"}, wantCode: http.StatusPartialContent, wantBody: "234"},
+		{name: "if-range matching etag", headers: map[string]string{"Range": "bytes=2-4", "If-Range": etag}, wantCode: http.StatusPartialContent, wantBody: "234"},
+		{name: "if-range stale etag", headers: map[string]string{"Range": "bytes=2-4", "If-Range": `"stale"`}, wantCode: http.StatusOK, wantBody: "0123456789"},
+		{name: "if-range date", headers: map[string]string{"Range": "bytes=2-4", "If-Range": "Mon, 02 Jan 2006 15:04:05 GMT"}, wantCode: http.StatusOK, wantBody: "0123456789"},
+		{name: "if-none-match", headers: map[string]string{"If-None-Match": etag}, wantCode: http.StatusNotModified, wantBody: ""},
+		{name: "if-match matching etag", headers: map[string]string{"If-Match": `"other", ` + etag}, wantCode: http.StatusOK, wantBody: "0123456789"},
+		{name: "if-match stale etag", headers: map[string]string{"If-Match": `"stale"`}, wantCode: http.StatusPreconditionFailed, wantBody: ""},
+		{name: "if-match weak etag", headers: map[string]string{"If-Match": "W/" + etag}, wantCode: http.StatusPreconditionFailed, wantBody: ""},
+	}
+	for _, tc := range tests {
+		w := get(tc.headers)
+		if w.Code != tc.wantCode {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.wantCode)
+		}
+		if body := w.Body.String(); body != tc.wantBody {
+			t.Errorf("%s: body = %q, want %q", tc.name, body, tc.wantBody)
+		}
+	}
+}
+
+func TestServer_Tile_WeakETagForPartial(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/0/000":     []byte("full tile data"),
+		"tile/0/001.p/3": []byte("partial tile data"),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil)
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	full := get("/test_log/tile/0/000", nil).Header().Get("ETag")
+	if full == "" || strings.HasPrefix(full, "W/") {
+		t.Fatalf("full tile ETag = %q, want a strong validator", full)
+	}
+	weak := get("/test_log/tile/0/001.p/3", nil).Header().Get("ETag")
+	if !strings.HasPrefix(weak, `W/"`) {
+		t.Fatalf("partial tile ETag = %q, want a weak validator", weak)
+	}
+	opaque := strings.TrimPrefix(weak, "W/")
+
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		wantCode int
+	}{
+		// If-None-Match uses the weak comparison: W/"x" and "x" match each other.
+		{name: "partial if-none-match weak", path: "/test_log/tile/0/001.p/3", headers: map[string]string{"If-None-Match": weak}, wantCode: http.StatusNotModified},
+		{name: "partial if-none-match strong form", path: "/test_log/tile/0/001.p/3", headers: map[string]string{"If-None-Match": opaque}, wantCode: http.StatusNotModified},
+		{name: "full if-none-match weak form", path: "/test_log/tile/0/000", headers: map[string]string{"If-None-Match": "W/" + full}, wantCode: http.StatusNotModified},
+		{name: "partial if-none-match stale", path: "/test_log/tile/0/001.p/3", headers: map[string]string{"If-None-Match": `W/"stale"`}, wantCode: http.StatusOK},
+		// If-Range uses the strong comparison, so a weak ETag never yields a partial body.
+		{name: "partial if-range weak", path: "/test_log/tile/0/001.p/3", headers: map[string]string{"Range": "bytes=0-3", "If-Range": weak}, wantCode: http.StatusOK},
+		{name: "full if-range strong", path: "/test_log/tile/0/000", headers: map[string]string{"Range": "bytes=0-3", "If-Range": full}, wantCode: http.StatusPartialContent},
+	}
+	for _, tc := range tests {
+		if w := get(tc.path, tc.headers); w.Code != tc.wantCode {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.wantCode)
+		}
+	}
 }
 
 func TestServer_HandleHashTile_Partial_200(t *testing.T) {
@@ -599,3 +980,556 @@ func TestServer_HandleIssuer_404(t *testing.T) {
 		t.Errorf("GET /nonexistent/issuer/abc123 status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
+
+func TestServer_HandleLogListV3JSON_Disabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{
+		ArchivePath:                  t.TempDir(),
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: 5 * time.Millisecond,
+		DisableLogListV3JSON:         true,
+	}
+	builder := NewLogListV3JSONBuilder(cfg, nil, nil, nil)
+	var builds atomic.Int64
+	builder.build = func(string) (*LogListV3JSONSnapshot, error) {
+		builds.Add(1)
+		return &LogListV3JSONSnapshot{Version: "3.0"}, nil
+	}
+
+	// Start must neither build nor leave a refresh loop running.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	builder.Start(ctx)
+	time.Sleep(50 * time.Millisecond)
+	if got := builds.Load(); got != 0 {
+		t.Fatalf("builds with logs.v3.json disabled = %d, want 0", got)
+	}
+
+	server := NewServer(cfg, nil, NewMetrics(prometheus.NewRegistry()), nil, nil, builder)
+	req := httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("GET /logs.v3.json status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestServer_HandleLogListV3JSON_StaleWarning(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"log.v3.json": []byte(`{"description":"Test Log","log_id":"dGVzdF9sb2dfaWRfMzJfYnl0ZXNfbG9uZyEh","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"log_type":"prod","state":{}}`),
+	})
+
+	cfg := Config{
+		ArchivePath:                  root,
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: time.Minute,
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+
+	builtAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := builtAt
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, logger)
+	builder.now = func() time.Time { return now }
+	builder.refreshOnce("http://placeholder")
+
+	server := NewServer(cfg, logger, metrics, archiveIndex, zr, builder)
+
+	// Fresh snapshot: no Warning header.
+	now = builtAt.Add(90 * time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /logs.v3.json status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Warning"); got != "" {
+		t.Errorf("Warning header = %q on fresh snapshot, want empty", got)
+	}
+
+	// Snapshot older than two refresh intervals: Warning header set, body still valid.
+	now = builtAt.Add(10 * time.Minute)
+	req = httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /logs.v3.json status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Warning"), `110 - "Response is Stale"`; got != want {
+		t.Errorf("Warning header = %q, want %q", got, want)
+	}
+	if strings.Contains(w.Body.String(), "stale") {
+		t.Errorf("body = %q, want no staleness fields in JSON", w.Body.String())
+	}
+}
+
+// cancelAfterReader cancels its context after the first Read and counts reads.
+type cancelAfterReader struct {
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (c *cancelAfterReader) Read(p []byte) (int, error) {
+	c.reads++
+	if c.reads == 1 {
+		c.cancel()
+	}
+	return len(p), nil // infinite stream
+}
+
+func TestCopyWithContext_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &cancelAfterReader{cancel: cancel}
+	var dst bytes.Buffer
+	buf := make([]byte, 1024)
+
+	n, err := copyWithContext(ctx, &dst, src, buf)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("copyWithContext() error = %v, want context.Canceled", err)
+	}
+	if got, want := src.reads, 1; got != want {
+		t.Fatalf("reads after cancel = %d, want %d", got, want)
+	}
+	if got, want := n, int64(len(buf)); got != want {
+		t.Fatalf("copyWithContext() written = %d, want %d", got, want)
+	}
+}
+
+func TestCopyWithContext_CopiesUntilEOF(t *testing.T) {
+	t.Parallel()
+
+	want := bytes.Repeat([]byte("tile"), 10000)
+	var dst bytes.Buffer
+	n, err := copyWithContext(context.Background(), &dst, bytes.NewReader(want), make([]byte, 1024))
+	if err != nil {
+		t.Fatalf("copyWithContext() error = %v", err)
+	}
+	if n != int64(len(want)) || !bytes.Equal(dst.Bytes(), want) {
+		t.Fatalf("copyWithContext() copied %d bytes, want %d identical bytes", n, len(want))
+	}
+}
+
+func TestServer_HandleLogListV3JSON_MonitorAliasSharesSnapshot(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"log.v3.json": []byte(`{"description":"Test Log","log_id":"dGVzdF9sb2dfaWRfMzJfYnl0ZXNfbG9uZyEh","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"log_type":"prod","state":{}}`),
+	})
+
+	cfg := Config{
+		ArchivePath:                  root,
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: time.Minute,
+		MonitorJSONAlias:             true,
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, logger)
+	builds := 0
+	build := builder.build
+	builder.build = func(publicBaseURL string) (*LogListV3JSONSnapshot, error) {
+		builds++
+		return build(publicBaseURL)
+	}
+	builder.refreshOnce("http://placeholder")
+
+	server := NewServer(cfg, logger, metrics, archiveIndex, zr, builder)
+
+	bodies := make(map[string]string, 2)
+	for _, path := range []string{"/logs.v3.json", "/monitor.json"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		bodies[path] = w.Body.String()
+	}
+
+	if builds != 1 {
+		t.Fatalf("builds = %d, want 1", builds)
+	}
+	if bodies["/logs.v3.json"] != bodies["/monitor.json"] {
+		t.Fatalf("/monitor.json body differs from /logs.v3.json:\n%s\nvs\n%s", bodies["/monitor.json"], bodies["/logs.v3.json"])
+	}
+
+	// Without the opt-in the legacy path is not routed.
+	cfg.MonitorJSONAlias = false
+	server = NewServer(cfg, logger, metrics, archiveIndex, zr, builder)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/monitor.json", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("GET /monitor.json without CT_MONITOR_JSON_ALIAS status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestServer_HandleLogListV3JSON_HeadThenGetEncodesOnce(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"log.v3.json": []byte(`{"description":"Test Log","log_id":"dGVzdF9sb2dfaWRfMzJfYnl0ZXNfbG9uZyEh","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"log_type":"prod","state":{}}`),
+	})
+
+	cfg := Config{
+		ArchivePath:                  root,
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: time.Minute,
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, logger)
+	encodes := 0
+	encode := builder.encode
+	builder.encode = func(snap *LogListV3JSONSnapshot) ([]byte, error) {
+		encodes++
+		return encode(snap)
+	}
+	builder.refreshOnce("http://placeholder")
+
+	server := NewServer(cfg, logger, metrics, archiveIndex, zr, builder)
+	serve := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/logs.v3.json", nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status = %d, want %d", method, w.Code, http.StatusOK)
+		}
+		return w
+	}
+
+	head := serve(http.MethodHead)
+	get := serve(http.MethodGet)
+	if encodes != 1 {
+		t.Fatalf("encodes after HEAD+GET = %d, want 1", encodes)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD body length = %d, want 0", head.Body.Len())
+	}
+	if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+		t.Errorf("HEAD Content-Length = %q, want %q", got, want)
+	}
+
+	// A refresh publishes a new snapshot, which must be rendered afresh.
+	builder.refreshOnce("http://placeholder")
+	serve(http.MethodGet)
+	if encodes != 2 {
+		t.Fatalf("encodes after refresh = %d, want 2", encodes)
+	}
+}
+
+func TestServer_DefaultCacheControl(t *testing.T) {
+	t.Parallel()
+
+	const def = "public, max-age=60"
+	server := NewServer(Config{HTTPDefaultCacheControl: def, ServeWellKnown: true}, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+		want         string
+	}{
+		// No route-specific policy: the default applies, also to HEAD without a body.
+		{method: http.MethodGet, path: "/metrics", wantCode: http.StatusOK, want: def},
+		{method: http.MethodHead, path: "/metrics", wantCode: http.StatusOK, want: def},
+		// Route-specific policy wins.
+		{method: http.MethodGet, path: "/robots.txt", wantCode: http.StatusOK, want: wellKnownCacheControl},
+		// Errors never get the default.
+		{method: http.MethodGet, path: "/no/such/route", wantCode: http.StatusNotFound, want: ""},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != tc.wantCode {
+			t.Fatalf("%s %s status = %d, want %d", tc.method, tc.path, w.Code, tc.wantCode)
+		}
+		if got := w.Header().Get("Cache-Control"); got != tc.want {
+			t.Errorf("%s %s Cache-Control = %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestServer_HandleLogListV3JSON_PrecomputedETag(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"log.v3.json": []byte(`{"description":"Test Log","log_id":"dGVzdF9sb2dfaWRfMzJfYnl0ZXNfbG9uZyEh","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"log_type":"prod","state":{}}`),
+	})
+
+	cfg := Config{
+		ArchivePath:                  root,
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: time.Minute,
+		LogListV3JSONETag:            true,
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	builder.now = func() time.Time { return now }
+	builder.refreshOnce("http://placeholder")
+
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, builder)
+	get := func(host, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil)
+		req.Host = host
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("archive.example", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q; want 200 with an ETag", first.Code, etag)
+	}
+	if got := get("archive.example", "").Header().Get("ETag"); got != etag {
+		t.Errorf("second ETag = %q, want stable %q", got, etag)
+	}
+	if w := get("archive.example", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match status = %d, body length = %d; want 304 without body", w.Code, w.Body.Len())
+	}
+	if got := get("mirror.example", "").Header().Get("ETag"); got == etag {
+		t.Errorf("ETag for another base URL = %q, want it to differ", got)
+	}
+
+	ifMatch := func(v string) int {
+		req := httptest.NewRequest(http.MethodGet, "/logs.v3.json", nil)
+		req.Host = "archive.example"
+		req.Header.Set("If-Match", v)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w.Code
+	}
+	for _, tc := range []struct {
+		ifMatch  string
+		wantCode int
+	}{
+		{ifMatch: etag, wantCode: http.StatusOK},
+		{ifMatch: "*", wantCode: http.StatusOK},
+		{ifMatch: `"stale"`, wantCode: http.StatusPreconditionFailed},
+		{ifMatch: "W/" + etag, wantCode: http.StatusPreconditionFailed},
+	} {
+		if got := ifMatch(tc.ifMatch); got != tc.wantCode {
+			t.Errorf("If-Match %s status = %d, want %d", tc.ifMatch, got, tc.wantCode)
+		}
+	}
+
+	// A refresh publishes new content (log_list_timestamp), and with it a new ETag.
+	now = now.Add(time.Hour)
+	builder.refreshOnce("http://placeholder")
+	if w := get("archive.example", etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("after refresh: status = %d, ETag = %q; want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestServer_HandleLogListV3JSON_HasIssuersFilter(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for name, files := range map[string]map[string][]byte{
+		"ct_with_issuers": {
+			"log.v3.json": []byte(`{"description":"With Issuers","log_id":"dGVzdF9sb2dfaWRfMzJfYnl0ZXNfbG9uZyEh","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"log_type":"prod","state":{}}`),
+			"issuer/aa01": []byte("issuer a"),
+		},
+		"ct_without_issuers": {
+			"log.v3.json": []byte(`{"description":"Without Issuers","log_id":"b3RoZXJfbG9nX2lkXzMyX2J5dGVzX2xvbmchIQ==","key":"dGVzdF9rZXlfMzJfYnl0ZXNfbG9uZ19kYXRhISE=","mmd":86400,"log_type":"prod","state":{}}`),
+		},
+	} {
+		mustMkdir(t, filepath.Join(root, name))
+		mustCreateZip(t, filepath.Join(root, name, "000.zip"), files)
+	}
+
+	cfg := Config{
+		ArchivePath:                  root,
+		ArchiveFolderPattern:         "ct_*",
+		ArchiveFolderPrefix:          "ct_",
+		LogListV3JSONRefreshInterval: time.Minute,
+		LogListV3JSONETag:            true,
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	builder := NewLogListV3JSONBuilder(cfg, zr, archiveIndex, nil)
+	builder.refreshOnce("http://placeholder")
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, builder)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logs.v3.json"+query, nil))
+		return w
+	}
+
+	etags := map[string]bool{}
+	for query, want := range map[string][]string{
+		"":                  {"With Issuers", "Without Issuers"},
+		"?has_issuers=true": {"With Issuers"},
+		"?has_issuers=0":    {"Without Issuers"},
+	} {
+		w := get(query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /logs.v3.json%s status = %d, want %d", query, w.Code, http.StatusOK)
+		}
+		logList, err := loglist3.NewFromJSON(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("GET /logs.v3.json%s: loglist3.NewFromJSON() error = %v", query, err)
+		}
+		var got []string
+		for _, tlog := range logList.Operators[0].TiledLogs {
+			got = append(got, tlog.Description)
+		}
+		sort.Strings(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("GET /logs.v3.json%s tiled logs = %v, want %v", query, got, want)
+		}
+		etags[w.Header().Get("ETag")] = true
+	}
+	if len(etags) != 3 {
+		t.Errorf("ETags = %v, want a distinct ETag per filter", etags)
+	}
+
+	if w := get("?has_issuers=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("GET ?has_issuers=maybe status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_MaxHeaderCount(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(Config{HTTPMaxHeaderCount: 3}, nil, nil, nil, nil, nil)
+
+	serve := func(n int) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		for i := 0; i < n; i++ {
+			req.Header.Add("X-Filler", strconv.Itoa(i))
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if got := serve(3); got != http.StatusOK {
+		t.Errorf("3 header lines status = %d, want %d", got, http.StatusOK)
+	}
+	// Repeated fields count per line, not per name.
+	if got := serve(4); got != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("4 header lines status = %d, want %d", got, http.StatusRequestHeaderFieldsTooLarge)
+	}
+}
+
+func TestServer_CollidedLogConflict(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, folder := range []string{"ct_a", "ct_b"} {
+		mustMkdir(t, filepath.Join(root, folder))
+		mustCreateZip(t, filepath.Join(root, folder, "000.zip"), map[string][]byte{
+			"checkpoint": checkpointBody(1),
+		})
+	}
+
+	cfg := Config{
+		ArchivePath:            root,
+		ArchiveFolderPattern:   "ct_*",
+		ArchiveFolderPrefix:    "ct_",
+		ArchiveCollisionPolicy: ArchiveCollisionSkip,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	archiveIndex.readDir = func(path string) ([]os.DirEntry, error) {
+		ents, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read directory: %w", err)
+		}
+		for _, ent := range ents {
+			if ent.Name() == "ct_a" {
+				return append(ents, ent), nil
+			}
+		}
+		return ents, nil
+	}
+	if err := archiveIndex.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{path: "/a/checkpoint", wantCode: http.StatusConflict},
+		{path: "/a/tile/0/000", wantCode: http.StatusConflict},
+		{path: "/b/checkpoint", wantCode: http.StatusOK},
+		{path: "/c/checkpoint", wantCode: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.wantCode {
+			t.Errorf("GET %s status = %d, want %d", tc.path, w.Code, tc.wantCode)
+		}
+	}
+}
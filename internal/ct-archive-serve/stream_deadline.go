@@ -0,0 +1,48 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"time"
+)
+
+// streamDeadlineWriter enforces CT_HTTP_STREAM_TIMEOUT as a progress-based write deadline.
+//
+// The deadline is first set when the request is accepted (bounding time-to-first-byte) and
+// is pushed forward on every Write. A slow client that keeps draining the response is never
+// cut off, while a client that stops reading for longer than the timeout has its connection
+// closed. This allows CT_HTTP_WRITE_TIMEOUT to be disabled for large tile downloads without
+// exposing the server to slow-reader (slowloris-style) attacks.
+//
+// net/http clears the connection write deadline after each request, so the deadline does not
+// leak into subsequent requests on a keep-alive connection.
+type streamDeadlineWriter struct {
+	http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func newStreamDeadlineWriter(w http.ResponseWriter, timeout time.Duration) *streamDeadlineWriter {
+	sw := &streamDeadlineWriter{
+		ResponseWriter: w,
+		rc:             http.NewResponseController(w),
+		timeout:        timeout,
+	}
+	sw.extendDeadline()
+	return sw
+}
+
+// extendDeadline moves the write deadline to now+timeout. Writers that do not support
+// deadlines (e.g. httptest.ResponseRecorder) are left untouched.
+func (sw *streamDeadlineWriter) extendDeadline() {
+	_ = sw.rc.SetWriteDeadline(time.Now().Add(sw.timeout)) //nolint:errcheck // http.ErrNotSupported is expected for some writers
+}
+
+func (sw *streamDeadlineWriter) Write(b []byte) (int, error) {
+	sw.extendDeadline()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter.
+func (sw *streamDeadlineWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
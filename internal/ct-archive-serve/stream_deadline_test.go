@@ -0,0 +1,113 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newStreamTimeoutTestServer serves a single large data tile through a real HTTP server
+// so connection write deadlines are in effect.
+func newStreamTimeoutTestServer(t *testing.T, streamTimeout time.Duration, tileSize int) *httptest.Server {
+	t.Helper()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	if err := os.MkdirAll(logFolder, 0o700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/data/x000": bytes.Repeat([]byte{0x42}, tileSize),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		HTTPStreamTimeout:    streamTimeout,
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+
+	ts := httptest.NewServer(NewServer(cfg, nil, metrics, archiveIndex, zr, nil))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestStreamTimeout_SlowReaderMakingProgress(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tileSize      = 32 << 20
+		streamTimeout = 250 * time.Millisecond
+	)
+	ts := newStreamTimeoutTestServer(t, streamTimeout, tileSize)
+
+	resp, err := http.Get(ts.URL + "/test_log/tile/data/x000")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// Read in small chunks with pauses so the whole transfer takes several times the
+	// stream timeout, while each individual pause stays well under it.
+	start := time.Now()
+	buf := make([]byte, 512*1024)
+	total := 0
+	for {
+		n, err := io.ReadFull(resp.Body, buf)
+		total += n
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read error after %d bytes = %v", total, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if total != tileSize {
+		t.Fatalf("read %d bytes, want %d", total, tileSize)
+	}
+	if elapsed := time.Since(start); elapsed <= streamTimeout {
+		t.Fatalf("transfer took %v, want > %v for the test to be meaningful", elapsed, streamTimeout)
+	}
+}
+
+func TestStreamTimeout_StalledReaderIsCutOff(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tileSize      = 32 << 20
+		streamTimeout = 100 * time.Millisecond
+	)
+	ts := newStreamTimeoutTestServer(t, streamTimeout, tileSize)
+
+	resp, err := http.Get(ts.URL + "/test_log/tile/data/x000")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Stop reading long enough for the server's write deadline to expire.
+	time.Sleep(10 * streamTimeout)
+
+	n, err := io.Copy(io.Discard, resp.Body)
+	if err == nil && n == tileSize {
+		t.Fatalf("stalled reader received the full %d byte response, want connection cut off", n)
+	}
+}
@@ -0,0 +1,37 @@
+package ctarchiveserve
+
+import "net/http"
+
+// streamFlushWriter implements CT_HTTP_STREAM_FLUSH_INTERVAL: it flushes the response
+// every interval bytes written, so large tiles reach clients (and move through proxy
+// buffers) as they are produced rather than when net/http's buffer fills or the handler
+// returns. Flushing is best effort; writers that cannot flush are left untouched.
+type streamFlushWriter struct {
+	http.ResponseWriter
+	rc       *http.ResponseController
+	interval int
+	pending  int // bytes written since the last flush
+}
+
+func newStreamFlushWriter(w http.ResponseWriter, interval int) *streamFlushWriter {
+	return &streamFlushWriter{
+		ResponseWriter: w,
+		rc:             http.NewResponseController(w),
+		interval:       interval,
+	}
+}
+
+func (fw *streamFlushWriter) Write(b []byte) (int, error) {
+	n, err := fw.ResponseWriter.Write(b)
+	fw.pending += n
+	if err == nil && fw.pending >= fw.interval {
+		fw.pending = 0
+		_ = fw.rc.Flush() //nolint:errcheck // http.ErrNotSupported is expected for some writers
+	}
+	return n, err //nolint:wrapcheck // pass-through writer
+}
+
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter.
+func (fw *streamFlushWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
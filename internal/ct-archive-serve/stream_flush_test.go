@@ -0,0 +1,88 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// flushCountingRecorder is an httptest.ResponseRecorder that counts Flush calls.
+type flushCountingRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (r *flushCountingRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+func TestStreamFlushWriter_FlushesEveryInterval(t *testing.T) {
+	t.Parallel()
+
+	rec := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	fw := newStreamFlushWriter(rec, 3000)
+	for range 10 {
+		if _, err := fw.Write(make([]byte, 1000)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	// Flushed after 3000, 6000 and 9000 bytes; the last 1000 are left to net/http.
+	if rec.flushes != 3 {
+		t.Errorf("flushes = %d, want 3", rec.flushes)
+	}
+	if rec.Body.Len() != 10000 {
+		t.Errorf("body length = %d, want 10000", rec.Body.Len())
+	}
+}
+
+func TestServer_StreamFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	tile := bytes.Repeat([]byte{0x42}, 100<<10)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/data/x000": tile,
+		"checkpoint":     checkpointBody(10),
+	})
+
+	cfg := Config{
+		ArchivePath:             root,
+		ArchiveFolderPattern:    "ct_*",
+		ArchiveFolderPrefix:     "ct_",
+		HTTPStreamFlushInterval: 1024,
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics))
+	server := NewServer(cfg, nil, metrics, archiveIndex, zr, nil)
+
+	rec := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test_log/tile/data/x000", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), tile) {
+		t.Fatalf("body differs from the stored tile")
+	}
+	if rec.flushes == 0 {
+		t.Errorf("flushes = 0, want at least one for a %d-byte tile with a 1024-byte interval", len(tile))
+	}
+
+	// Responses smaller than the interval are not flushed early.
+	rec = &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil))
+	if rec.flushes != 0 {
+		t.Errorf("checkpoint flushes = %d, want 0", rec.flushes)
+	}
+}
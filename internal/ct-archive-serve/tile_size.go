@@ -0,0 +1,131 @@
+package ctarchiveserve
+
+import (
+	"encoding/binary"
+	"net/http"
+)
+
+const (
+	// fullTileWidth is the number of hashes in a full hash tile and entries in a full
+	// data tile (c2sp.org/tlog-tiles).
+	fullTileWidth = 256
+	// tileHashSize is the size of one SHA-256 hash: the issuer key hash and chain
+	// fingerprints of a data tile, and the default hash tile hash length.
+	tileHashSize = 32
+)
+
+// DefaultTileHashBytes is the default CT_TILE_HASH_BYTES.
+const DefaultTileHashBytes = tileHashSize
+
+// tileWidth returns the number of hashes or entries the tile at route must hold.
+func tileWidth(route Route) int {
+	if route.TileIsPartial {
+		return int(route.TilePartialWidth)
+	}
+	return fullTileWidth
+}
+
+// checkTileSize reports whether data has the size the tile geometry of route requires:
+// width * hashBytes for hash tiles, and exactly width well-formed entries for data tiles.
+func checkTileSize(route Route, data []byte, hashBytes int) bool {
+	width := tileWidth(route)
+	if route.Kind == RouteDataTile {
+		n, ok := countDataTileEntries(data)
+		return ok && n == width
+	}
+	return len(data) == width*hashBytes
+}
+
+// countDataTileEntries counts the TileLeaf entries of a Static CT API data tile
+// (c2sp.org/static-ct-api). It returns false if data does not split into whole entries.
+func countDataTileEntries(data []byte) (int, bool) {
+	n := 0
+	for len(data) > 0 {
+		rest, ok := skipTileLeaf(data)
+		if !ok {
+			return n, false
+		}
+		data = rest
+		n++
+	}
+	return n, true
+}
+
+// skipTileLeaf returns b past one TileLeaf:
+//
+//	uint64 timestamp; uint16 entry_type;
+//	x509_entry:    opaque certificate<1..2^24-1>;
+//	precert_entry: opaque issuer_key_hash[32]; opaque tbs_certificate<1..2^24-1>;
+//	opaque extensions<0..2^16-1>;
+//	precert_entry: opaque pre_certificate<1..2^24-1>;
+//	Fingerprint certificate_chain<0..2^16-1>;
+func skipTileLeaf(b []byte) ([]byte, bool) {
+	if len(b) < 10 {
+		return nil, false
+	}
+	entryType := binary.BigEndian.Uint16(b[8:10])
+	b = b[10:]
+
+	var ok bool
+	switch entryType {
+	case 0: // x509_entry
+		if b, ok = skipVector(b, 3); !ok {
+			return nil, false
+		}
+	case 1: // precert_entry
+		if len(b) < tileHashSize {
+			return nil, false
+		}
+		if b, ok = skipVector(b[tileHashSize:], 3); !ok {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	if b, ok = skipVector(b, 2); !ok {
+		return nil, false
+	}
+	if entryType == 1 {
+		if b, ok = skipVector(b, 3); !ok {
+			return nil, false
+		}
+	}
+	if len(b) < 2 || int(binary.BigEndian.Uint16(b))%tileHashSize != 0 {
+		return nil, false
+	}
+	return skipVector(b, 2)
+}
+
+// skipVector returns b past a TLS vector with a lenBytes-byte big-endian length prefix.
+func skipVector(b []byte, lenBytes int) ([]byte, bool) {
+	if len(b) < lenBytes {
+		return nil, false
+	}
+	n := 0
+	for _, c := range b[:lenBytes] {
+		n = n<<8 | int(c)
+	}
+	b = b[lenBytes:]
+	if len(b) < n {
+		return nil, false
+	}
+	return b[n:], true
+}
+
+// validateTileSize logs and counts a tile whose size does not match its geometry
+// (CT_VALIDATE_TILE_SIZE, or CT_VALIDATE_DATA_TILES for partial data tiles only). The tile
+// is still served as stored.
+func (s *Server) validateTileSize(r *http.Request, route Route, data []byte) {
+	hashBytes := s.cfg.TileHashBytes
+	if hashBytes <= 0 {
+		hashBytes = DefaultTileHashBytes
+	}
+	if checkTileSize(route, data, hashBytes) {
+		return
+	}
+	s.metrics.IncTileSizeMismatch()
+	if logger := s.requestLogger(r); logger != nil {
+		logger.Warn("Tile size does not match tile geometry",
+			"log", route.Log, "path", route.EntryPath, "size", len(data), "width", tileWidth(route))
+	}
+}
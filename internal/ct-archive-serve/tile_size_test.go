@@ -0,0 +1,184 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// x509TileLeaf returns a minimal x509_entry TileLeaf with a certificate of certLen bytes
+// and one chain fingerprint.
+func x509TileLeaf(certLen int) []byte {
+	var b bytes.Buffer
+	b.Write(make([]byte, 8)) // timestamp
+	b.Write([]byte{0, 0})    // entry_type x509_entry
+	b.Write([]byte{0, 0, byte(certLen)})
+	b.Write(bytes.Repeat([]byte{0x30}, certLen))
+	b.Write([]byte{0, 0})            // extensions
+	b.Write([]byte{0, tileHashSize}) // certificate_chain
+	b.Write(make([]byte, tileHashSize))
+	return b.Bytes()
+}
+
+// precertTileLeaf returns a minimal precert_entry TileLeaf with no chain fingerprints.
+func precertTileLeaf() []byte {
+	var b bytes.Buffer
+	b.Write(make([]byte, 8))
+	b.Write([]byte{0, 1})
+	b.Write(make([]byte, tileHashSize))  // issuer_key_hash
+	b.Write([]byte{0, 0, 2, 0x30, 0x00}) // tbs_certificate
+	b.Write([]byte{0, 0})
+	b.Write([]byte{0, 0, 2, 0x30, 0x00}) // pre_certificate
+	b.Write([]byte{0, 0})
+	return b.Bytes()
+}
+
+func TestCountDataTileEntries(t *testing.T) {
+	t.Parallel()
+
+	tile := append(append(x509TileLeaf(5), precertTileLeaf()...), x509TileLeaf(7)...)
+	if n, ok := countDataTileEntries(tile); !ok || n != 3 {
+		t.Errorf("countDataTileEntries() = %d, %v; want 3, true", n, ok)
+	}
+	if _, ok := countDataTileEntries(tile[:len(tile)-1]); ok {
+		t.Errorf("countDataTileEntries(truncated) ok = true, want false")
+	}
+	if n, ok := countDataTileEntries(nil); !ok || n != 0 {
+		t.Errorf("countDataTileEntries(nil) = %d, %v; want 0, true", n, ok)
+	}
+}
+
+func TestCheckTileSize_HashBytes(t *testing.T) {
+	t.Parallel()
+
+	full := Route{Kind: RouteHashTile}
+	partial := Route{Kind: RouteHashTile, TileIsPartial: true, TilePartialWidth: 3}
+	for _, hashBytes := range []int{32, 48} {
+		if !checkTileSize(full, make([]byte, 256*hashBytes), hashBytes) {
+			t.Errorf("hashBytes=%d: full tile of %d bytes rejected", hashBytes, 256*hashBytes)
+		}
+		if !checkTileSize(partial, make([]byte, 3*hashBytes), hashBytes) {
+			t.Errorf("hashBytes=%d: partial tile of %d bytes rejected", hashBytes, 3*hashBytes)
+		}
+		if checkTileSize(full, make([]byte, 256*hashBytes-1), hashBytes) {
+			t.Errorf("hashBytes=%d: short full tile accepted", hashBytes)
+		}
+	}
+	if checkTileSize(full, make([]byte, 256*32), 48) {
+		t.Errorf("hashBytes=48: full SHA-256 tile accepted")
+	}
+}
+
+func TestServer_ValidateTileSize(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/0/000":        make([]byte, fullTileWidth*tileHashSize),
+		"tile/0/001":        make([]byte, fullTileWidth*tileHashSize-1),
+		"tile/0/002.p/3":    make([]byte, 3*tileHashSize),
+		"tile/data/000.p/2": append(x509TileLeaf(4), precertTileLeaf()...),
+		"tile/data/001.p/2": x509TileLeaf(4),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		ValidateTileSize:     true,
+	}
+	logger := NewLogger(LoggerOptions{})
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil)
+
+	tests := []struct {
+		path         string
+		wantMismatch float64
+	}{
+		{path: "/test_log/tile/0/000", wantMismatch: 0},
+		{path: "/test_log/tile/0/002.p/3", wantMismatch: 0},
+		{path: "/test_log/tile/data/000.p/2", wantMismatch: 0},
+		{path: "/test_log/tile/0/001", wantMismatch: 1},
+		{path: "/test_log/tile/data/001.p/2", wantMismatch: 2},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d (mismatched tiles are still served)", tc.path, w.Code, http.StatusOK)
+		}
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		if got := counterValue(t, mfs, "ct_archive_serve_tile_size_mismatch_total", ""); got != tc.wantMismatch {
+			t.Errorf("after GET %s: tile_size_mismatch_total = %v, want %v", tc.path, got, tc.wantMismatch)
+		}
+	}
+}
+
+func TestServer_ValidateDataTiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{
+		"tile/data/000.p/2": append(x509TileLeaf(4), precertTileLeaf()...),
+		"tile/data/001.p/3": append(x509TileLeaf(4), x509TileLeaf(5)...),
+		"tile/data/002":     x509TileLeaf(4),
+		"tile/0/001":        make([]byte, 7),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		ValidateDataTiles:    true,
+	}
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	archiveIndex, err := NewArchiveIndex(cfg, nil, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), metrics, archiveIndex, NewZipReader(zic), nil)
+
+	tests := []struct {
+		path         string
+		wantMismatch float64
+	}{
+		{path: "/test_log/tile/data/000.p/2", wantMismatch: 0}, // 2 entries, as named
+		{path: "/test_log/tile/data/001.p/3", wantMismatch: 1}, // short: 2 of 3 entries
+		{path: "/test_log/tile/data/002", wantMismatch: 1},     // full tiles are not checked
+		{path: "/test_log/tile/0/001", wantMismatch: 1},        // nor are hash tiles
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d (mismatched tiles are still served)", tc.path, w.Code, http.StatusOK)
+		}
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		if got := counterValue(t, mfs, "ct_archive_serve_tile_size_mismatch_total", ""); got != tc.wantMismatch {
+			t.Errorf("after GET %s: tile_size_mismatch_total = %v, want %v", tc.path, got, tc.wantMismatch)
+		}
+	}
+}
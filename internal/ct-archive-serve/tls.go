@@ -0,0 +1,64 @@
+package ctarchiveserve
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// tls12CipherSuites are the TLS 1.2 suites offered: ECDHE key exchange with AEAD ciphers
+// only. TLS 1.3 suites are not configurable in crypto/tls and are all AEAD.
+var tls12CipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// parseTLSVersion parses CT_HTTP_TLS_MIN_VERSION. Only 1.2 and 1.3 are accepted.
+func parseTLSVersion(v string) (uint16, error) {
+	switch v {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q (want 1.2 or 1.3)", v)
+	}
+}
+
+// NewTLSConfig builds the tls.Config for the main listener from the CT_HTTP_TLS_*
+// policy. Certificates are loaded by the caller (http.Server.ListenAndServeTLS).
+//
+// With CT_HTTP_TLS_CLIENT_CA, client certificates are requested and verified when
+// presented, but only admin endpoints require one (see requireAdmin), so public archive
+// content stays reachable without a client certificate.
+func NewTLSConfig(cfg Config) (*tls.Config, error) {
+	minVersion := cfg.HTTPTLSMinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	tc := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: tls12CipherSuites,
+	}
+
+	if cfg.HTTPTLSClientCAFile != "" {
+		//nolint:gosec // G304: path comes from operator configuration, not user input
+		pem, err := os.ReadFile(cfg.HTTPTLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("read client CA: no PEM certificates found")
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tc, nil
+}
@@ -0,0 +1,143 @@
+package ctarchiveserve
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mustSelfSignedCert returns a self-signed ECDSA certificate valid for 127.0.0.1.
+func mustSelfSignedCert(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ct-archive-serve test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, leaf
+}
+
+func TestNewTLSConfig_TLS13OnlyRejectsTLS12(t *testing.T) {
+	t.Parallel()
+
+	cert, leaf := mustSelfSignedCert(t)
+	tc, err := NewTLSConfig(Config{HTTPTLSMinVersion: tls.VersionTLS13})
+	if err != nil {
+		t.Fatalf("NewTLSConfig() error = %v", err)
+	}
+	tc.Certificates = []tls.Certificate{cert}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	ts.TLS = tc
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	get := func(maxVersion uint16) error {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, MaxVersion: maxVersion},
+		}}
+		defer client.CloseIdleConnections()
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	if err := get(tls.VersionTLS12); err == nil {
+		t.Fatalf("TLS 1.2 handshake succeeded, want rejection by a TLS 1.3-only server")
+	}
+	if err := get(tls.VersionTLS13); err != nil {
+		t.Fatalf("TLS 1.3 request error = %v", err)
+	}
+}
+
+func TestNewTLSConfig_ClientCA(t *testing.T) {
+	t.Parallel()
+
+	_, leaf := mustSelfSignedCert(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tc, err := NewTLSConfig(Config{HTTPTLSClientCAFile: caFile})
+	if err != nil {
+		t.Fatalf("NewTLSConfig() error = %v", err)
+	}
+	if tc.ClientAuth != tls.VerifyClientCertIfGiven || tc.ClientCAs == nil {
+		t.Errorf("ClientAuth = %v, ClientCAs = %v; want VerifyClientCertIfGiven with a pool", tc.ClientAuth, tc.ClientCAs)
+	}
+	if tc.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %#x, want TLS 1.2 default", tc.MinVersion)
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	mustWriteFile(t, notPEM, []byte("not a certificate"))
+	if _, err := NewTLSConfig(Config{HTTPTLSClientCAFile: notPEM}); err == nil {
+		t.Errorf("NewTLSConfig(non-PEM CA) error = nil, want non-nil")
+	}
+}
+
+func TestRequireAdmin_ClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	_, leaf := mustSelfSignedCert(t)
+	server := NewServer(Config{AdminToken: "secret", HTTPTLSClientCAFile: "/ca.pem"}, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name     string
+		tls      *tls.ConnectionState
+		wantCode int
+	}{
+		{name: "plain http", tls: nil, wantCode: http.StatusForbidden},
+		{name: "tls without client cert", tls: &tls.ConnectionState{}, wantCode: http.StatusForbidden},
+		// Past the gate; the server has no archive index, hence 500.
+		{name: "verified client cert", tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}, wantCode: http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/test_log/parts/000/manifest.json", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.TLS = tc.tls
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != tc.wantCode {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.wantCode)
+		}
+	}
+}
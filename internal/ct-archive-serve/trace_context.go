@@ -0,0 +1,64 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// traceparentHeader carries the W3C Trace Context of the caller's active span
+// (https://www.w3.org/TR/trace-context/).
+const traceparentHeader = "traceparent"
+
+// exemplarTraceIDLabel is the exemplar label holding the trace ID, the name tracing
+// backends such as Grafana look for.
+const exemplarTraceIDLabel = "trace_id"
+
+// requestTraceID returns the trace ID of the span the request belongs to, from its
+// traceparent header, for exemplars (CT_METRICS_EXEMPLARS). It returns "" when exemplars
+// are disabled or the request carries no valid trace context.
+func (s *Server) requestTraceID(r *http.Request) string {
+	if !s.cfg.MetricsExemplars {
+		return ""
+	}
+	return parseTraceparent(r.Header.Get(traceparentHeader))
+}
+
+// parseTraceparent returns the trace ID of a "<version>-<trace-id>-<parent-id>-<flags>"
+// traceparent value, or "" if it is malformed. Unknown versions are accepted as long as
+// the leading fields have the version 00 layout, as the specification requires.
+func parseTraceparent(v string) string {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 {
+		return ""
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHexField(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return ""
+	}
+	if !isHexField(traceID, 32) || !isHexField(parentID, 16) || !isHexField(flags, 2) {
+		return ""
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return ""
+	}
+	return traceID
+}
+
+// isHexField reports whether s is a traceparent field of exactly n lowercase hex digits.
+func isHexField(s string, n int) bool {
+	return len(s) == n && isLowerHex(s)
+}
+
+// observeWithTrace observes v on o, with a trace ID exemplar when traceID is set and o
+// supports exemplars.
+func observeWithTrace(o prometheus.Observer, v float64, traceID string) {
+	if traceID != "" {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(v, prometheus.Labels{exemplarTraceIDLabel: traceID})
+			return
+		}
+	}
+	o.Observe(v)
+}
@@ -0,0 +1,144 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	t.Parallel()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "00-" + traceID + "-00f067aa0ba902b7-01", want: traceID},
+		{value: "01-" + traceID + "-00f067aa0ba902b7-00-future", want: traceID}, // later versions may append fields
+		{value: "", want: ""},
+		{value: "00-" + traceID + "-00f067aa0ba902b7-01-extra", want: ""},
+		{value: "ff-" + traceID + "-00f067aa0ba902b7-01", want: ""},
+		{value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", want: ""},
+		{value: "00-" + traceID + "-0000000000000000-01", want: ""},
+		{value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", want: ""},
+		{value: "00-" + traceID[:31] + "-00f067aa0ba902b7-01", want: ""},
+	}
+	for _, tc := range tests {
+		if got := parseTraceparent(tc.value); got != tc.want {
+			t.Errorf("parseTraceparent(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestServer_RequestDurationExemplars(t *testing.T) {
+	t.Parallel()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	// Per-log series are only recorded for logs in the archive index.
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_test_log"))
+	mustCreateZip(t, filepath.Join(root, "ct_test_log", "000.zip"), map[string][]byte{"checkpoint": []byte("checkpoint")})
+	exemplars := func(exemplarsEnabled bool, traceparent string) []string {
+		reg := prometheus.NewRegistry()
+		cfg := Config{ArchivePath: root, ArchiveFolderPattern: "ct_*", ArchiveFolderPrefix: "ct_", MetricsExemplars: exemplarsEnabled}
+		archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		server := NewServer(cfg, nil, NewMetrics(reg), archiveIndex, zr, nil)
+		req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil)
+		if traceparent != "" {
+			req.Header.Set(traceparentHeader, traceparent)
+		}
+		server.ServeHTTP(httptest.NewRecorder(), req)
+
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		var ids []string
+		for _, mf := range mfs {
+			if mf.GetName() != "ct_archive_serve_http_log_request_duration_seconds" {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				for _, b := range m.GetHistogram().GetBucket() {
+					for _, l := range b.GetExemplar().GetLabel() {
+						if l.GetName() == exemplarTraceIDLabel {
+							ids = append(ids, l.GetValue())
+						}
+					}
+				}
+			}
+		}
+		return ids
+	}
+
+	traceparent := "00-" + traceID + "-00f067aa0ba902b7-01"
+	if got := exemplars(true, traceparent); len(got) != 1 || got[0] != traceID {
+		t.Errorf("exemplar trace IDs = %v, want [%s]", got, traceID)
+	}
+	if got := exemplars(true, ""); len(got) != 0 {
+		t.Errorf("exemplar trace IDs without a span context = %v, want none", got)
+	}
+	if got := exemplars(false, traceparent); len(got) != 0 {
+		t.Errorf("exemplar trace IDs with CT_METRICS_EXEMPLARS=false = %v, want none", got)
+	}
+}
+
+func TestServer_MetricsEndpointExemplars(t *testing.T) {
+	t.Parallel()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_test_log"))
+	mustCreateZip(t, filepath.Join(root, "ct_test_log", "000.zip"), map[string][]byte{"checkpoint": []byte("checkpoint")})
+
+	// scrape serves one traced request, then scrapes /metrics as an OpenMetrics scraper.
+	scrape := func(exemplarsEnabled bool) *httptest.ResponseRecorder {
+		t.Helper()
+		cfg := Config{ArchivePath: root, ArchiveFolderPattern: "ct_*", ArchiveFolderPrefix: "ct_", MetricsExemplars: exemplarsEnabled}
+		archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("NewArchiveIndex() error = %v", err)
+		}
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		server := NewServer(cfg, nil, NewMetrics(prometheus.NewRegistry()), archiveIndex, zr, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/test_log/checkpoint", nil)
+		req.Header.Set(traceparentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+		server.ServeHTTP(httptest.NewRecorder(), req)
+
+		req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /metrics status = %d, want %d", w.Code, http.StatusOK)
+		}
+		return w
+	}
+
+	w := scrape(true)
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/openmetrics-text") {
+		t.Errorf("GET /metrics Content-Type = %q, want OpenMetrics", got)
+	}
+	if want := exemplarTraceIDLabel + `="` + traceID + `"`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("GET /metrics body lacks exemplar %s", want)
+	}
+
+	w = scrape(false)
+	if got := w.Header().Get("Content-Type"); strings.HasPrefix(got, "application/openmetrics-text") {
+		t.Errorf("GET /metrics Content-Type with CT_METRICS_EXEMPLARS=false = %q, want the text format", got)
+	}
+	if strings.Contains(w.Body.String(), traceID) {
+		t.Errorf("GET /metrics body has an exemplar with CT_METRICS_EXEMPLARS=false")
+	}
+}
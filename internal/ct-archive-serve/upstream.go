@@ -0,0 +1,242 @@
+package ctarchiveserve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultUpstreamTimeout is the default CT_UPSTREAM_TIMEOUT.
+const DefaultUpstreamTimeout = 10 * time.Second
+
+// upstreamMaxBodyBytes bounds an entry fetched from CT_UPSTREAM_BASE_URL. Tiles,
+// checkpoints and issuers are far smaller; anything larger is treated as a failure.
+const upstreamMaxBodyBytes = 16 << 20
+
+const (
+	// upstreamNotFoundTTL is how long an upstream 404 is remembered, so clients probing
+	// for entries that do not exist (e.g. tiles past the tree size) do not each cost an
+	// upstream request. It is short because the upstream's archive grows.
+	upstreamNotFoundTTL = 30 * time.Second
+
+	// upstreamNotFoundMax bounds the remembered 404s; when full, expired ones are swept
+	// and, if that is not enough, all are forgotten.
+	upstreamNotFoundMax = 16384
+)
+
+// upstreamReader fetches archive entries from another ct-archive-serve instance
+// (CT_UPSTREAM_BASE_URL) for logs this node does not hold, and caches them in the entry
+// content cache.
+type upstreamReader struct {
+	baseURL string // no trailing slash
+	timeout time.Duration
+	client  *http.Client
+	cache   *EntryContentCache // nil when CT_ENTRY_CACHE_MAX_BYTES=0
+
+	// group collapses concurrent fetches of the same path into one upstream request.
+	group singleflight.Group
+
+	now      func() time.Time
+	mu       sync.Mutex
+	notFound map[string]time.Time // path -> expiresAt of an upstream 404
+}
+
+// parseUpstreamBaseURL validates CT_UPSTREAM_BASE_URL, an http(s) URL without userinfo,
+// query or fragment, and returns it without a trailing slash so request paths can be
+// appended. Credentials are refused because the base URL is logged, keys the entry
+// content cache and is shown on /admin/config.json.
+func parseUpstreamBaseURL(v string) (string, error) {
+	u, err := url.Parse(v)
+	if err != nil {
+		// Drop the *url.Error wrapper, which would repeat v, credentials and all.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return "", err //nolint:wrapcheck // wrapped with the variable name by the caller
+	}
+	if u.User != nil {
+		return "", errors.New("must not contain userinfo (user:password@)")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.New("must be an http(s) URL without query or fragment")
+	}
+	return strings.TrimRight(v, "/"), nil
+}
+
+// newUpstreamReader returns nil when baseURL is empty (read-through disabled).
+func newUpstreamReader(baseURL string, timeout time.Duration, cache *EntryContentCache) *upstreamReader {
+	if baseURL == "" {
+		return nil
+	}
+	return &upstreamReader{
+		baseURL:  baseURL,
+		timeout:  timeout,
+		client:   &http.Client{},
+		cache:    cache,
+		now:      time.Now,
+		notFound: make(map[string]time.Time),
+	}
+}
+
+// upstreamRoute reports whether route is served through the upstream when its log is
+// not in the local archive index. These are the routes whose content is a single
+// archive entry, so the upstream's body can be cached and replayed as-is.
+func upstreamRoute(kind RouteKind) bool {
+	switch kind {
+	case RouteCheckpoint, RouteHashTile, RouteDataTile, RouteIssuer:
+		return true
+	default:
+		return false
+	}
+}
+
+// cached returns the entry at path from the entry content cache.
+func (u *upstreamReader) cached(path string) ([]byte, bool) {
+	return u.cache.Get(u.baseURL, path)
+}
+
+// get fetches the entry at path (an escaped request path such as /<log>/tile/0/000) from
+// the upstream and caches it. The upstream's 404 maps to ErrNotFound, and is remembered
+// for upstreamNotFoundTTL; any other failure maps to ErrZipTemporarilyUnavailable.
+func (u *upstreamReader) get(ctx context.Context, path string) ([]byte, error) {
+	if u.knownNotFound(path) {
+		return nil, fmt.Errorf("%w: upstream (cached)", ErrNotFound)
+	}
+	// The fetch is shared by every request waiting on path, so it is not tied to the
+	// context of whichever request started it.
+	v, err, _ := u.group.Do(path, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.timeout)
+		defer cancel()
+		data, err := u.fetch(fetchCtx, path)
+		if errors.Is(err, ErrNotFound) {
+			u.rememberNotFound(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		u.cache.Put(u.baseURL, path, data)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped by fetch
+	}
+	data, _ := v.([]byte) //nolint:errcheck // the group only returns []byte
+	return data, nil
+}
+
+// knownNotFound reports whether the upstream answered 404 for path within
+// upstreamNotFoundTTL.
+func (u *upstreamReader) knownNotFound(path string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	exp, ok := u.notFound[path]
+	if ok && !u.now().Before(exp) {
+		delete(u.notFound, path)
+		return false
+	}
+	return ok
+}
+
+// rememberNotFound records an upstream 404 for path.
+func (u *upstreamReader) rememberNotFound(path string) {
+	now := u.now()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.notFound) >= upstreamNotFoundMax {
+		for p, exp := range u.notFound {
+			if !now.Before(exp) {
+				delete(u.notFound, p)
+			}
+		}
+		if len(u.notFound) >= upstreamNotFoundMax {
+			clear(u.notFound)
+		}
+	}
+	u.notFound[path] = now.Add(upstreamNotFoundTTL)
+}
+
+// fetch requests path from the upstream.
+func (u *upstreamReader) fetch(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("upstream request: %w", err)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: upstream: %w", ErrZipTemporarilyUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: upstream", ErrNotFound)
+	default:
+		return nil, fmt.Errorf("%w: upstream status %d", ErrZipTemporarilyUnavailable, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, upstreamMaxBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: upstream: %w", ErrZipTemporarilyUnavailable, err)
+	}
+	if len(data) > upstreamMaxBodyBytes {
+		return nil, fmt.Errorf("%w: upstream body exceeds %d bytes", ErrZipTemporarilyUnavailable, upstreamMaxBodyBytes)
+	}
+	return data, nil
+}
+
+// serveUpstream serves route for a log that is not in the local archive index from
+// CT_UPSTREAM_BASE_URL. Upstream fetches count against CT_HEAVY_OP_CONCURRENCY; entries
+// already in the entry content cache do not.
+func (s *Server) serveUpstream(w http.ResponseWriter, r *http.Request, route Route) {
+	path := r.URL.EscapedPath()
+	data, ok := s.upstream.cached(path)
+	src := ContentSourceEntryCache
+	if !ok {
+		release, ok := s.acquireHeavyOp(w)
+		if !ok {
+			return
+		}
+		var err error
+		data, err = s.upstream.get(r.Context(), path)
+		src = ContentSourceUpstream
+		release()
+		if err != nil {
+			if s.logger != nil {
+				s.requestLogger(r).Debug("Upstream read failed", "log", route.Log, "path", path, "error", err)
+			}
+			s.writeOpenEntryError(w, r, err)
+			return
+		}
+	}
+	s.metrics.IncContentSource(src)
+
+	switch route.Kind {
+	case RouteHashTile, RouteDataTile:
+		s.setLinkHeaders(w, r, route)
+		s.serveTile(w, r, route, bytes.NewReader(data), time.Time{}, "Failed to read upstream tile", "log", route.Log)
+		return
+	case RouteIssuer:
+		w.Header().Set("Content-Type", "application/pkix-cert")
+	default:
+		s.setLinkHeaders(w, r, route)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("Accept-Ranges", acceptRangesNone)
+	if r.Method == http.MethodHead {
+		return // HEAD: no body
+	}
+	if _, err := w.Write(data); err != nil {
+		s.logCopyError(r, "Failed to write upstream response", "log", route.Log, "error", err)
+	}
+}
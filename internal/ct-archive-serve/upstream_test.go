@@ -0,0 +1,147 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServer_UpstreamReadThrough(t *testing.T) {
+	t.Parallel()
+
+	remoteTile := []byte("remote hash tile")
+	var upstreamRequests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamRequests.Add(1)
+		switch r.URL.Path {
+		case "/remote_log/tile/0/000":
+			_, _ = w.Write(remoteTile)
+		case "/remote_log/checkpoint":
+			_, _ = w.Write(checkpointBody(256))
+		case "/broken_log/checkpoint":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "ct_local_log"))
+	mustCreateZip(t, filepath.Join(root, "ct_local_log", "000.zip"), map[string][]byte{
+		"checkpoint": checkpointBody(2),
+	})
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+		HeavyOpConcurrency:   1,
+		UpstreamBaseURL:      upstream.URL,
+		UpstreamTimeout:      5 * time.Second,
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	entryCache := NewEntryContentCache(1<<20, nil)
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	zr.SetEntryContentCache(entryCache)
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// A local miss is fetched from the upstream once, then served from the entry cache.
+	for range 2 {
+		w := get("/remote_log/tile/0/000")
+		if w.Code != http.StatusOK || w.Body.String() != string(remoteTile) {
+			t.Fatalf("GET remote tile = %d %q, want 200 %q", w.Code, w.Body.String(), remoteTile)
+		}
+		if got := w.Header().Get("ETag"); got == "" {
+			t.Errorf("GET remote tile ETag missing")
+		}
+	}
+	if got := upstreamRequests.Load(); got != 1 {
+		t.Errorf("upstream requests = %d, want 1", got)
+	}
+	if data, ok := entryCache.Get(upstream.URL, "/remote_log/tile/0/000"); !ok || string(data) != string(remoteTile) {
+		t.Errorf("entry cache = (%q, %v), want the remote tile", data, ok)
+	}
+
+	// Upstream errors: 404 passes through, anything else is 503.
+	if w := get("/remote_log/tile/0/001"); w.Code != http.StatusNotFound {
+		t.Errorf("GET tile missing upstream = %d, want 404", w.Code)
+	}
+	if w := get("/broken_log/checkpoint"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET checkpoint failing upstream = %d, want 503", w.Code)
+	}
+
+	// Logs held locally never go upstream.
+	before := upstreamRequests.Load()
+	if w := get("/local_log/checkpoint"); w.Code != http.StatusOK || w.Body.String() != string(checkpointBody(2)) {
+		t.Errorf("GET local checkpoint = %d %q, want 200 with the local checkpoint", w.Code, w.Body.String())
+	}
+	if got := upstreamRequests.Load(); got != before {
+		t.Errorf("upstream requests after a local read = %d, want %d", got, before)
+	}
+
+	// Upstream fetches take a heavy-op slot; cached entries do not.
+	if !server.heavySem.TryAcquire(1) {
+		t.Fatal("heavySem.TryAcquire() = false")
+	}
+	if w := get("/remote_log/checkpoint"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("GET uncached remote checkpoint with no heavy-op slot = %d, want 503 with Retry-After", w.Code)
+	}
+	if w := get("/remote_log/tile/0/000"); w.Code != http.StatusOK {
+		t.Errorf("GET cached remote tile with no heavy-op slot = %d, want 200", w.Code)
+	}
+	server.heavySem.Release(1)
+	if w := get("/remote_log/checkpoint"); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("GET remote checkpoint = %d (Content-Type %q), want 200 text/plain", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestUpstreamReader_RemembersNotFound(t *testing.T) {
+	t.Parallel()
+
+	var upstreamRequests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamRequests.Add(1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(upstream.Close)
+
+	u := newUpstreamReader(upstream.URL, 5*time.Second, nil)
+	now := time.Unix(1_700_000_000, 0)
+	u.now = func() time.Time { return now }
+
+	// Repeated misses within the TTL cost one upstream request.
+	for range 3 {
+		if _, err := u.get(t.Context(), "/remote_log/tile/0/001"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("get() error = %v, want ErrNotFound", err)
+		}
+	}
+	if got := upstreamRequests.Load(); got != 1 {
+		t.Fatalf("upstream requests = %d, want 1", got)
+	}
+
+	// Other paths, and the same path after the TTL, go upstream again.
+	if _, err := u.get(t.Context(), "/remote_log/tile/0/002"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get() error = %v, want ErrNotFound", err)
+	}
+	now = now.Add(upstreamNotFoundTTL)
+	if _, err := u.get(t.Context(), "/remote_log/tile/0/001"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get() error = %v, want ErrNotFound", err)
+	}
+	if got := upstreamRequests.Load(); got != 3 {
+		t.Errorf("upstream requests = %d, want 3", got)
+	}
+}
@@ -0,0 +1,48 @@
+package ctarchiveserve
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// parseUserAgentPatternsCSV parses CT_HTTP_BLOCKED_USER_AGENTS. An item wrapped in
+// slashes (/.../) is a regular expression; any other item is a case-insensitive
+// substring, compiled to a quoted regular expression so matching has a single form.
+// Empty items are skipped.
+func parseUserAgentPatternsCSV(csv string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, raw := range strings.Split(csv, ",") {
+		s := strings.TrimSpace(raw)
+		if s == "" {
+			continue
+		}
+		expr := "(?i)" + regexp.QuoteMeta(s)
+		if len(s) >= 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+			expr = s[1 : len(s)-1]
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// blockedUserAgent reports whether the request User-Agent matches
+// CT_HTTP_BLOCKED_USER_AGENTS. Requests without a User-Agent are matched too, so a
+// pattern such as /^$/ can block them.
+func (s *Server) blockedUserAgent(r *http.Request) bool {
+	if len(s.cfg.HTTPBlockedUserAgents) == 0 {
+		return false
+	}
+	ua := r.UserAgent()
+	for _, re := range s.cfg.HTTPBlockedUserAgents {
+		if re.MatchString(ua) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_BlockedUserAgents(t *testing.T) {
+	t.Parallel()
+
+	patterns, err := parseUserAgentPatternsCSV("badbot, /^python-requests/[0-9.]+$/")
+	if err != nil {
+		t.Fatalf("parseUserAgentPatternsCSV() error = %v", err)
+	}
+	cfg := Config{HTTPBlockedUserAgents: patterns}
+	server := NewServer(cfg, NewLogger(LoggerOptions{}), NewMetrics(prometheus.NewRegistry()), nil, nil, nil)
+
+	tests := []struct {
+		userAgent string
+		want      int
+	}{
+		{userAgent: "Mozilla/5.0 (compatible; BadBot/2.1)", want: http.StatusForbidden}, // substring, any case
+		{userAgent: "python-requests/2.32.3", want: http.StatusForbidden},               // regex
+		{userAgent: "python-requests/2.32.3 via monitor", want: http.StatusOK},          // regex is anchored
+		{userAgent: "ct-monitor/1.0", want: http.StatusOK},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("User-Agent", tc.userAgent)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("User-Agent %q: status = %d, want %d", tc.userAgent, w.Code, tc.want)
+		}
+	}
+}
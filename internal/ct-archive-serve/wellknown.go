@@ -0,0 +1,40 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+)
+
+// wellKnownCacheControl lets browsers and crawlers cache the well-known responses
+// for a day so they stop re-requesting them.
+const wellKnownCacheControl = "public, max-age=86400"
+
+// handleFavicon serves GET /favicon.ico. With CT_SERVE_WELLKNOWN enabled it returns an
+// empty 204 so browsers stop producing 404 noise; otherwise the route does not exist.
+func (s *Server) handleFavicon(w http.ResponseWriter, r *http.Request) {
+	if !s.cfg.ServeWellKnown {
+		s.notFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", wellKnownCacheControl)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRobotsTXT serves GET /robots.txt from CT_ROBOTS_TXT (default: disallow all)
+// when CT_SERVE_WELLKNOWN is enabled; otherwise the route does not exist.
+func (s *Server) handleRobotsTXT(w http.ResponseWriter, r *http.Request) {
+	if !s.cfg.ServeWellKnown {
+		s.notFound(w, r)
+		return
+	}
+	body := s.cfg.RobotsTXT
+	if body == "" {
+		body = DefaultRobotsTXT
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", wellKnownCacheControl)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write([]byte(body))
+}
@@ -0,0 +1,83 @@
+package ctarchiveserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer_WellKnown_Favicon(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	server := NewServer(Config{ServeWellKnown: true}, nil, NewMetrics(reg), nil, nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/favicon.ico", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("GET /favicon.ico status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("GET /favicon.ico body length = %d, want 0", w.Body.Len())
+	}
+
+	// Well-known requests are not attributed to any log.
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() == "ct_archive_serve_http_log_requests_total" && len(mf.GetMetric()) != 0 {
+			t.Fatalf("per-log metrics recorded for /favicon.ico")
+		}
+	}
+}
+
+func TestServer_WellKnown_RobotsTXT(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		robots string
+		want   string
+	}{
+		{name: "default disallows all", robots: "", want: DefaultRobotsTXT},
+		{name: "configured", robots: "User-agent: *\nAllow: /\n", want: "User-agent: *\nAllow: /\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			server := NewServer(Config{ServeWellKnown: true, RobotsTXT: tc.robots}, nil, nil, nil, nil, nil)
+			req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /robots.txt status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Body.String(); got != tc.want {
+				t.Fatalf("GET /robots.txt body = %q, want %q", got, tc.want)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+				t.Fatalf("Content-Type = %q, want text/plain", got)
+			}
+		})
+	}
+}
+
+func TestServer_WellKnown_DisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(Config{}, nil, nil, nil, nil, nil)
+	for _, path := range []string{"/favicon.ico", "/robots.txt"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/sync/semaphore"
 	"golang.org/x/sync/singleflight"
 )
 
@@ -18,10 +22,20 @@ import (
 // (e.g., still downloading / structurally invalid).
 var ErrZipTemporarilyUnavailable = errors.New("zip temporarily unavailable")
 
+// errVerifyStillRunning fails a verification of a zip part whose previous verification
+// timed out (CT_ZIP_INTEGRITY_VERIFY_TIMEOUT) and has not returned yet.
+var errVerifyStillRunning = errors.New("previous verify of this zip part timed out and is still running")
+
+// ZipGrowthCheckDelay is the interval between the two stats of the CT_ZIP_GROWTH_CHECK
+// pre-check (see SetGrowthCheck).
+const ZipGrowthCheckDelay = 100 * time.Millisecond
+
 // ZipIntegrityCache caches zip structural integrity results.
 //
-// Passed entries are cached for the lifetime of the process and are only removed if
-// a later read attempt fails (call InvalidatePassed).
+// Passed entries are cached for the lifetime of the process, or for the pass TTL when one
+// is set (see SetPassTTL), and are removed early if a later read attempt fails (call
+// InvalidatePassed). For immutable archives (see SetImmutable) passed entries are never
+// removed and so never re-tested.
 //
 // Failed entries are cached with TTL to allow re-testing once the zip part becomes complete.
 type ZipIntegrityCache struct {
@@ -30,9 +44,33 @@ type ZipIntegrityCache struct {
 	verify  func(path string) error
 	metrics *Metrics
 
+	// immutable disables InvalidatePassed and passTTL (CT_ARCHIVE_IMMUTABLE).
+	immutable bool
+
+	// passTTL, when > 0, expires passed entries so they are re-verified
+	// (CT_ZIP_INTEGRITY_PASS_TTL).
+	passTTL time.Duration
+
+	// completeMarker must appear in the archive comment of a part (CT_ZIP_COMPLETE_MARKER).
+	// It only applies to the default verify function.
+	completeMarker string
+
+	// growthDelay, when > 0, stats each part twice this far apart before verification and
+	// fails it if the size changed (CT_ZIP_GROWTH_CHECK). sleep is time.Sleep outside tests.
+	growthDelay time.Duration
+	sleep       func(time.Duration)
+
+	// verifyTimeout, when > 0, bounds each verification (CT_ZIP_INTEGRITY_VERIFY_TIMEOUT).
+	verifyTimeout time.Duration
+
+	// circuit trips per-log circuit breakers on repeated failures (CT_LOG_CIRCUIT_FAILURES);
+	// nil when disabled.
+	circuit *logCircuit
+
 	mu     sync.RWMutex
-	passed map[string]struct{}
+	passed map[string]time.Time // path -> expiresAt; zero means never
 	failed map[string]time.Time // path -> expiresAt
+	stuck  map[string]bool      // paths whose timed-out verify is still running
 
 	group singleflight.Group // deduplicates concurrent verifications of the same path
 }
@@ -46,30 +84,47 @@ func NewZipIntegrityCache(
 	if now == nil {
 		now = time.Now
 	}
-	if verify == nil {
-		verify = verifyZipStructural
-	}
 
-	return &ZipIntegrityCache{
+	z := &ZipIntegrityCache{
 		failTTL: failTTL,
 		now:     now,
 		verify:  verify,
 		metrics: metrics,
-		passed:  make(map[string]struct{}),
+		sleep:   time.Sleep,
+		passed:  make(map[string]time.Time),
 		failed:  make(map[string]time.Time),
+		stuck:   make(map[string]bool),
+	}
+	if z.verify == nil {
+		z.verify = func(path string) error {
+			return verifyZipStructural(path, z.completeMarker)
+		}
 	}
+	return z
 }
 
 // Check verifies that the zip part at path is structurally valid (central directory + local headers)
 // or returns ErrZipTemporarilyUnavailable.
 func (z *ZipIntegrityCache) Check(path string) error {
+	return z.check(path, true)
+}
+
+// probe is Check for speculative opens such as pre-opens: a failed verification is
+// returned but not recorded in the fail cache, the metrics or the log circuit breaker. A
+// part discovered while still being written is then verified afresh by its first read
+// instead of answering 503 for CT_ZIP_INTEGRITY_FAIL_TTL.
+func (z *ZipIntegrityCache) probe(path string) error {
+	return z.check(path, false)
+}
+
+func (z *ZipIntegrityCache) check(path string, recordFailure bool) error {
 	if z == nil {
 		return nil
 	}
 
 	// Fast path: read-only check under RLock (hot path, no writes needed).
 	z.mu.RLock()
-	if _, ok := z.passed[path]; ok {
+	if z.passedLocked(path) {
 		z.mu.RUnlock()
 		return nil
 	}
@@ -102,40 +157,181 @@ func (z *ZipIntegrityCache) Check(path string) error {
 	_, err, _ := z.group.Do(path, func() (interface{}, error) {
 		// Re-check cache inside singleflight (another goroutine may have completed).
 		z.mu.RLock()
-		if _, ok := z.passed[path]; ok {
+		if z.passedLocked(path) {
 			z.mu.RUnlock()
 			return nil, nil
 		}
 		z.mu.RUnlock()
 
-		return nil, z.verify(path)
+		return nil, z.verifyBounded(path)
 	})
 
+	if err != nil && !recordFailure {
+		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+	}
 	if err != nil {
 		z.mu.Lock()
 		z.failed[path] = z.now().Add(z.failTTL)
+		delete(z.passed, path)
 		z.mu.Unlock()
 		if z.metrics != nil {
 			z.metrics.IncZipIntegrityFailed()
 		}
+		z.circuit.failure(path)
 		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
 	}
 
+	var passExp time.Time
+	if z.passTTL > 0 && !z.immutable {
+		passExp = z.now().Add(z.passTTL)
+	}
 	z.mu.Lock()
-	z.passed[path] = struct{}{}
+	z.passed[path] = passExp
 	delete(z.failed, path)
 	z.mu.Unlock()
 	if z.metrics != nil {
 		z.metrics.IncZipIntegrityPassed()
 	}
+	z.circuit.success(path)
 
 	return nil
 }
 
+// SetImmutable marks the archive as immutable: once a zip part passes verification it
+// is never re-tested, even if a later read attempt fails. Must be called before use.
+func (z *ZipIntegrityCache) SetImmutable(v bool) {
+	z.immutable = v
+}
+
+// SetPassTTL makes passed entries expire after ttl, so a part replaced in place is
+// re-verified rather than trusted forever. A ttl <= 0 keeps passes for the lifetime of
+// the process. Ignored for immutable archives. Must be called before use.
+func (z *ZipIntegrityCache) SetPassTTL(ttl time.Duration) {
+	z.passTTL = ttl
+}
+
+// passedLocked reports whether path has an unexpired pass. Caller must hold z.mu.
+func (z *ZipIntegrityCache) passedLocked(path string) bool {
+	exp, ok := z.passed[path]
+	return ok && (exp.IsZero() || z.now().Before(exp))
+}
+
+// SetCompleteMarker requires marker to appear in each zip part's archive comment, so parts
+// still being written (whose producer adds the marker last) are treated as temporarily
+// unavailable. An empty marker disables the check. Must be called before use.
+func (z *ZipIntegrityCache) SetCompleteMarker(marker string) {
+	z.completeMarker = marker
+}
+
+// SetGrowthCheck makes Check stat each zip part twice, delay apart, before the structural
+// parse and treat a size change as a part still being written. This catches a growing
+// part without parsing its central directory. A delay <= 0 disables the check. Must be
+// called before use.
+func (z *ZipIntegrityCache) SetGrowthCheck(delay time.Duration) {
+	z.growthDelay = delay
+}
+
+// SetVerifyTimeout bounds each verification of a zip part (growth check included) to
+// timeout. A verification still running at the deadline fails the part like a broken
+// one, so callers waiting on it through the singleflight are released; the stuck call
+// is abandoned and its result discarded. A timeout <= 0 disables the bound. Must be
+// called before use.
+func (z *ZipIntegrityCache) SetVerifyTimeout(timeout time.Duration) {
+	z.verifyTimeout = timeout
+}
+
+// verifyBounded runs the growth check and verify for path, giving up after
+// verifyTimeout when set. A path keeps at most one verification running: while one that
+// timed out is still stuck, e.g. in a read of a failing disk, later checks of the path
+// fail at once instead of stacking more stuck goroutines on it.
+func (z *ZipIntegrityCache) verifyBounded(path string) error {
+	run := func() error {
+		if z.growthDelay > 0 {
+			if err := z.checkNotGrowing(path); err != nil {
+				return err
+			}
+		}
+		return z.verify(path)
+	}
+	if z.verifyTimeout <= 0 {
+		return run()
+	}
+
+	z.mu.RLock()
+	stuck := z.stuck[path]
+	z.mu.RUnlock()
+	if stuck {
+		return errVerifyStillRunning
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), z.verifyTimeout)
+	defer cancel()
+	done := make(chan error, 1) // buffered: an abandoned run must not block forever
+	finished := false           // guarded by z.mu
+	go func() {
+		err := run()
+		z.mu.Lock()
+		finished = true
+		delete(z.stuck, path)
+		z.mu.Unlock()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		z.mu.Lock()
+		if !finished {
+			z.stuck[path] = true
+		}
+		z.mu.Unlock()
+		return fmt.Errorf("verify timed out after %v: %w", z.verifyTimeout, ctx.Err())
+	}
+}
+
+// checkNotGrowing returns an error if the size of path changes within growthDelay.
+func (z *ZipIntegrityCache) checkNotGrowing(path string) error {
+	before, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat zip: %w", err)
+	}
+	z.sleep(z.growthDelay)
+	after, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat zip: %w", err)
+	}
+	if after.Size() != before.Size() {
+		return fmt.Errorf("zip is still growing (%d -> %d bytes)", before.Size(), after.Size())
+	}
+	return nil
+}
+
+// SetLogCircuitBreaker enables per-log circuit breakers (see logCircuit): after failures
+// consecutive integrity failures across a log's parts within window, reads of that log
+// fail fast for cooldown. Must be called before use.
+func (z *ZipIntegrityCache) SetLogCircuitBreaker(failures int, window, cooldown time.Duration) {
+	z.circuit = newLogCircuit(failures, window, cooldown, z.now, z.metrics)
+}
+
+// allowRead reports whether zip part path may be read, i.e. its log's circuit breaker is
+// not open. It does no I/O.
+func (z *ZipIntegrityCache) allowRead(path string) bool {
+	return z == nil || z.circuit.allow(path)
+}
+
+// readSucceeded records a successful entry read of zip part path, closing its log's
+// half-open circuit breaker. It does no I/O.
+func (z *ZipIntegrityCache) readSucceeded(path string) {
+	if z != nil {
+		z.circuit.readSucceeded(path)
+	}
+}
+
 // InvalidatePassed removes a previously-passed zip part from the passed cache.
 // Callers should use this when later open/read attempts fail for that zip part.
+// It is a no-op for immutable archives.
 func (z *ZipIntegrityCache) InvalidatePassed(path string) {
-	if z == nil {
+	if z == nil || z.immutable {
 		return
 	}
 	z.mu.Lock()
@@ -143,6 +339,12 @@ func (z *ZipIntegrityCache) InvalidatePassed(path string) {
 	z.mu.Unlock()
 }
 
+// Close is a no-op: verification opens and closes each zip part itself, so the cache
+// holds no file handles. It exists so shutdown can treat both zip caches alike.
+func (z *ZipIntegrityCache) Close() error {
+	return nil
+}
+
 // verifyZipStructural validates that the zip file's central directory is readable.
 //
 // This is a lightweight check: it only opens the zip (which parses the central
@@ -153,9 +355,10 @@ func (z *ZipIntegrityCache) InvalidatePassed(path string) {
 //
 // If an individual entry is corrupt, it will be caught at read time and the
 // integrity cache will be invalidated via InvalidatePassed.
-func verifyZipStructural(path string) error {
-	//nolint:gosec // G304: path is validated internally from archive index, not user input
-	r, err := zip.OpenReader(path)
+//
+// A non-empty completeMarker must also appear in the zip's archive comment.
+func verifyZipStructural(path, completeMarker string) error {
+	r, err := openZipPart(path)
 	if err != nil {
 		return fmt.Errorf("open zip: %w", err)
 	}
@@ -166,6 +369,9 @@ func verifyZipStructural(path string) error {
 	if len(r.File) == 0 {
 		return errors.New("zip has no entries")
 	}
+	if completeMarker != "" && !strings.Contains(r.Comment, completeMarker) {
+		return errors.New("zip comment lacks the completion marker")
+	}
 
 	return nil
 }
@@ -175,6 +381,35 @@ type ZipEntryIndex struct {
 	entries map[string]*zip.File
 }
 
+// newZipEntryIndex indexes files by their exact name and, for names written with
+// backslash separators (e.g. zips produced on Windows), also by the forward-slash
+// normalized name. Exact names always win over normalized aliases. Matching remains
+// case-sensitive.
+func newZipEntryIndex(files []*zip.File) *ZipEntryIndex {
+	idx := &ZipEntryIndex{
+		entries: make(map[string]*zip.File, len(files)),
+	}
+	for _, f := range files {
+		idx.entries[f.Name] = f
+	}
+	for _, f := range files {
+		norm := normalizeZipEntryName(f.Name)
+		if norm == f.Name {
+			continue
+		}
+		if _, exists := idx.entries[norm]; !exists {
+			idx.entries[norm] = f
+		}
+	}
+	return idx
+}
+
+// normalizeZipEntryName converts backslash separators to forward slashes.
+// Archives should use forward slashes as required by the zip specification.
+func normalizeZipEntryName(name string) string {
+	return strings.ReplaceAll(name, `\`, "/")
+}
+
 // Lookup returns the zip.File for the given entry name, or nil if not found.
 func (idx *ZipEntryIndex) Lookup(entryName string) *zip.File {
 	if idx == nil {
@@ -186,10 +421,14 @@ func (idx *ZipEntryIndex) Lookup(entryName string) *zip.File {
 // ZipPartCacheEntry represents a cached zip part with its open reader and entry index.
 type ZipPartCacheEntry struct {
 	path     string
-	reader   *zip.ReadCloser
+	reader   *zipPartReader
 	index    *ZipEntryIndex
 	lastUsed time.Time
 	element  *list.Element // back-pointer to LRU list position within its shard
+	pinned   bool          // skipped by evictLRU while unpinned entries remain
+
+	// entryFailures counts consecutive failed entry opens (CT_ZIP_ENTRY_FAILURE_THRESHOLD).
+	entryFailures atomic.Int32
 }
 
 // defaultZipPartShards is the number of internal shards used to reduce lock contention
@@ -198,13 +437,26 @@ type ZipPartCacheEntry struct {
 const defaultZipPartShards = 64
 
 // zipPartShard is a single shard of the ZipPartCache. Each shard has its own mutex,
-// LRU list, entries map, and singleflight group, eliminating cross-shard lock contention.
+// LRU list, entries map, and in-flight opens, eliminating cross-shard lock contention.
 type zipPartShard struct {
-	mu      sync.Mutex
-	entries map[string]*ZipPartCacheEntry
-	lru     *list.List
-	group   singleflight.Group
-	maxOpen int
+	mu       sync.Mutex
+	entries  map[string]*ZipPartCacheEntry
+	lru      *list.List
+	inflight map[string]*zipOpenCall
+	maxOpen  int // unpinned entries; pinned ones are bounded by ZipPartCache.maxPinned
+	pinned   int
+}
+
+// zipOpenCall is an open of one zip part in progress. Callers for the same path wait on
+// done; entry and err are set before done is closed.
+type zipOpenCall struct {
+	done  chan struct{}
+	entry *ZipPartCacheEntry
+	err   error
+
+	// waiters counts the callers still waiting on done, guarded by the shard mutex. An
+	// open whose callers have all given up by the time a worker gets to it is skipped.
+	waiters int
 }
 
 // ZipPartCache is a sharded, bounded LRU cache for open zip file handles and entry indices.
@@ -212,15 +464,31 @@ type zipPartShard struct {
 // This cache avoids repeated central-directory parsing for hot zip parts.
 // The cache is internally sharded (default 64 shards) so that concurrent requests
 // for different zip paths do not contend on a single lock. Each shard has its own
-// mutex, LRU list, and singleflight group.
+// mutex, LRU list, and in-flight opens.
+//
+// Opens run on a fixed-size worker pool (zipOpenPool), which limits concurrent
+// zip.OpenReader calls to prevent I/O storms.
 //
-// A global semaphore limits concurrent zip.OpenReader calls to prevent I/O storms.
+// Handles are never closed for being idle; they stay open until evicted by LRU capacity
+// pressure or removed after a read failure. This is what makes CT_ARCHIVE_IMMUTABLE
+// archives effectively open-once.
+//
+// Metadata parts (000.zip) are pinned once the checkpoint, log.v3.json or issuer handlers
+// have read them (see Pin), so a burst of tile reads does not evict them. A quarter of
+// maxOpen is reserved for pinned parts and the rest is split over the shards for
+// everything else; eviction only ever removes unpinned entries. Pinned parts beyond the
+// reserve stay cached unpinned. Parts of logs that leave the archive are dropped on the
+// next refresh (DropRemovedLogs), releasing their pins.
 type ZipPartCache struct {
 	metrics   *Metrics
 	now       func() time.Time
 	shards    []zipPartShard
 	numShards uint64
-	openSem   *semaphore.Weighted
+	opens     *zipOpenPool
+	open      func(path string) (*zipPartReader, error)
+
+	maxPinned int64
+	pinned    atomic.Int64
 }
 
 // NewZipPartCache constructs a new sharded ZipPartCache.
@@ -235,7 +503,8 @@ func NewZipPartCache(maxOpen int, metrics *Metrics, maxConcurrentOpens int) *Zip
 	}
 
 	numShards := uint64(defaultZipPartShards)
-	perShard := maxOpen / int(numShards)
+	maxPinned := maxOpen / 4
+	perShard := (maxOpen - maxPinned) / int(numShards)
 	if perShard < 1 {
 		perShard = 1
 	}
@@ -243,9 +512,10 @@ func NewZipPartCache(maxOpen int, metrics *Metrics, maxConcurrentOpens int) *Zip
 	shards := make([]zipPartShard, numShards)
 	for i := range shards {
 		shards[i] = zipPartShard{
-			entries: make(map[string]*ZipPartCacheEntry),
-			lru:     list.New(),
-			maxOpen: perShard,
+			entries:  make(map[string]*ZipPartCacheEntry),
+			lru:      list.New(),
+			inflight: make(map[string]*zipOpenCall),
+			maxOpen:  perShard,
 		}
 	}
 
@@ -254,10 +524,74 @@ func NewZipPartCache(maxOpen int, metrics *Metrics, maxConcurrentOpens int) *Zip
 		now:       time.Now,
 		shards:    shards,
 		numShards: numShards,
-		openSem:   semaphore.NewWeighted(int64(maxConcurrentOpens)),
+		opens:     newZipOpenPool(maxConcurrentOpens),
+		open:      openZipPart,
+		maxPinned: int64(maxPinned),
+	}
+}
+
+// Pin pins the cached part at path, if the pin reserve allows, so tile reads do not evict
+// it. It is called for a log's metadata part after a metadata handler has read it; a part
+// that is not (or no longer) cached is left alone.
+func (c *ZipPartCache) Pin(path string) {
+	if c == nil {
+		return
+	}
+	shard := c.shardFor(path)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if entry, ok := shard.entries[path]; ok && !entry.pinned {
+		c.tryPin(shard, entry)
+	}
+}
+
+// DropRemovedLogs removes the cached parts of logs that are no longer in snap, releasing
+// their pins. It is registered with ArchiveIndex.OnRefresh.
+func (c *ZipPartCache) DropRemovedLogs(snap ArchiveSnapshot) {
+	if c == nil {
+		return
+	}
+	folders := make(map[string]bool, len(snap.Logs))
+	for _, l := range snap.Logs {
+		folders[filepath.Clean(l.FolderPath)] = true
+	}
+	var removed []string
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		for path := range shard.entries {
+			if !folders[filepath.Dir(path)] {
+				removed = append(removed, path)
+			}
+		}
+		shard.mu.Unlock()
+	}
+	for _, path := range removed {
+		c.Remove(path)
 	}
 }
 
+// tryPin pins entry if the pin budget allows. Caller must hold shard.mu.
+func (c *ZipPartCache) tryPin(shard *zipPartShard, entry *ZipPartCacheEntry) {
+	if c.pinned.Add(1) > c.maxPinned {
+		c.pinned.Add(-1)
+		return
+	}
+	entry.pinned = true
+	shard.pinned++
+}
+
+// dropEntry closes entry and releases its pin. Caller must hold shard.mu and remove the
+// entry from the shard.
+func (c *ZipPartCache) dropEntry(shard *zipPartShard, entry *ZipPartCacheEntry) error {
+	if entry.pinned {
+		entry.pinned = false
+		shard.pinned--
+		c.pinned.Add(-1)
+	}
+	return entry.reader.Close() //nolint:wrapcheck // callers add the path
+}
+
 // shardFor returns the shard index for the given path using FNV-1a hashing.
 func (c *ZipPartCache) shardFor(path string) *zipPartShard {
 	h := fnv.New64a()
@@ -270,12 +604,23 @@ func (c *ZipPartCache) shardFor(path string) *zipPartShard {
 //
 // Only the per-shard mutex is held for fast in-memory cache lookups and insertions.
 // All disk I/O (zip.OpenReader, central directory parsing) is performed outside
-// the mutex and deduplicated via per-shard singleflight so that concurrent requests
-// for the same uncached zip path only perform the I/O once. A global semaphore
-// limits concurrent zip.OpenReader calls to prevent I/O storms during cold starts.
-func (c *ZipPartCache) Get(path string) (*ZipPartCacheEntry, error) {
+// the mutex on the open worker pool, and deduplicated per path so that concurrent
+// requests for the same uncached zip path only perform the I/O once.
+//
+// ctx bounds only this caller's wait: on cancellation Get returns promptly with an error
+// wrapping ctx.Err(), while the open it started or joined carries on for the other
+// callers waiting on it. An open still queued when all of its callers have given up is
+// skipped, so cancelled requests do not open zip parts nobody will read.
+func (c *ZipPartCache) Get(ctx context.Context, path string) (*ZipPartCacheEntry, error) {
+	entry, _, err := c.get(ctx, path)
+	return entry, err
+}
+
+// get is Get, also reporting whether path was already open in the cache (hit) rather
+// than opened (or joined an in-flight open) by this call.
+func (c *ZipPartCache) get(ctx context.Context, path string) (*ZipPartCacheEntry, bool, error) {
 	if c == nil {
-		return nil, errors.New("zip part cache not initialized")
+		return nil, false, errors.New("zip part cache not initialized")
 	}
 
 	shard := c.shardFor(path)
@@ -286,94 +631,141 @@ func (c *ZipPartCache) Get(path string) (*ZipPartCacheEntry, error) {
 		shard.lru.MoveToFront(entry.element)
 		entry.lastUsed = c.now()
 		shard.mu.Unlock()
-		return entry, nil
+		return entry, true, nil
+	}
+	call, joined := shard.inflight[path]
+	if !joined {
+		call = &zipOpenCall{done: make(chan struct{})}
+		shard.inflight[path] = call
 	}
+	call.waiters++
 	shard.mu.Unlock()
 
-	// Slow path: open and index the zip via per-shard singleflight (no lock held).
-	val, err, _ := shard.group.Do(path, func() (interface{}, error) {
-		// Re-check cache inside singleflight (another goroutine may have completed).
+	// Slow path: the first caller queues the open on the worker pool (no lock held). The
+	// open is shared by every caller joining it, so it is not tied to the first caller's
+	// ctx: each caller only stops waiting on its own.
+	if !joined {
+		c.opens.enqueue(func(err error) {
+			var entry *ZipPartCacheEntry
+			switch {
+			case err != nil:
+				err = fmt.Errorf("acquire open slot: %w", err)
+			case !c.awaited(shard, call):
+				err = fmt.Errorf("zip part cache: open abandoned: %w", context.Canceled)
+			default:
+				entry, err = c.openAndInsert(shard, path)
+			}
+			c.finishOpen(shard, path, call, entry, err)
+		})
+	}
+
+	select {
+	case <-ctx.Done():
 		shard.mu.Lock()
-		if entry, ok := shard.entries[path]; ok {
-			shard.lru.MoveToFront(entry.element)
-			entry.lastUsed = c.now()
-			shard.mu.Unlock()
-			return entry, nil
-		}
+		call.waiters--
 		shard.mu.Unlock()
+		return nil, false, fmt.Errorf("zip part cache: %w", ctx.Err())
+	case <-call.done:
+	}
+	if call.err != nil {
+		return nil, false, call.err
+	}
+	return call.entry, false, nil
+}
 
-		// Acquire global semaphore to limit concurrent zip.OpenReader calls.
-		if err := c.openSem.Acquire(context.Background(), 1); err != nil {
-			return nil, fmt.Errorf("acquire open semaphore: %w", err)
-		}
-		defer c.openSem.Release(1)
-
-		// Perform all disk I/O outside the mutex.
-		//nolint:gosec // G304: path is validated internally from archive index, not user input
-		reader, err := zip.OpenReader(path)
-		if err != nil {
-			return nil, fmt.Errorf("open zip reader: %w", err)
-		}
-
-		// Build entry index.
-		index := &ZipEntryIndex{
-			entries: make(map[string]*zip.File, len(reader.File)),
-		}
-		for _, f := range reader.File {
-			index.entries[f.Name] = f
-		}
+// awaited reports whether any caller is still waiting on call, so a worker does not open
+// a zip part nobody will read.
+func (c *ZipPartCache) awaited(shard *zipPartShard, call *zipOpenCall) bool {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return call.waiters > 0
+}
 
-		entry := &ZipPartCacheEntry{
-			path:     path,
-			reader:   reader,
-			index:    index,
-			lastUsed: c.now(),
-		}
+// openAndInsert opens and indexes the zip part at path and inserts it into shard,
+// evicting as needed. Runs on an open worker.
+func (c *ZipPartCache) openAndInsert(shard *zipPartShard, path string) (*ZipPartCacheEntry, error) {
+	reader, err := c.open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open zip reader: %w", err)
+	}
 
-		// Insert into cache under shard lock.
-		shard.mu.Lock()
-		// Double-check: another caller may have inserted while we were opening.
-		if existing, ok := shard.entries[path]; ok {
-			shard.lru.MoveToFront(existing.element)
-			existing.lastUsed = c.now()
-			shard.mu.Unlock()
-			// Close the reader we just opened; the cached one wins.
-			_ = reader.Close()
-			return existing, nil
-		}
+	// Build entry index.
+	index := newZipEntryIndex(reader.File)
 
-		// Evict LRU if at capacity -- O(1).
-		if len(shard.entries) >= shard.maxOpen {
-			c.evictLRU(shard)
-		}
+	entry := &ZipPartCacheEntry{
+		path:     path,
+		reader:   reader,
+		index:    index,
+		lastUsed: c.now(),
+	}
 
-		entry.element = shard.lru.PushFront(path)
-		shard.entries[path] = entry
+	// Insert into cache under shard lock.
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	// Double-check: a Remove/Get race may have inserted while we were opening.
+	if existing, ok := shard.entries[path]; ok {
+		shard.lru.MoveToFront(existing.element)
+		existing.lastUsed = c.now()
+		// Close the reader we just opened; the cached one wins.
+		_ = reader.Close()
+		return existing, nil
+	}
 
-		if c.metrics != nil {
-			c.metrics.SetZipCacheOpen(c.totalOpen())
-		}
-		shard.mu.Unlock()
+	// Evict LRU if at capacity -- O(1). Pinned entries use the separate pin reserve.
+	if len(shard.entries)-shard.pinned >= shard.maxOpen {
+		c.evictLRU(shard)
+	}
 
-		return entry, nil
-	})
+	entry.element = shard.lru.PushFront(path)
+	shard.entries[path] = entry
 
-	if err != nil {
-		return nil, err
+	if c.metrics != nil {
+		c.metrics.SetZipCacheOpen(c.totalOpen())
 	}
+	return entry, nil
+}
 
-	entry, ok := val.(*ZipPartCacheEntry)
-	if !ok {
-		return nil, errors.New("zip part cache: unexpected singleflight result type")
-	}
+// finishOpen publishes the result of call and retires it, so later Gets hit the cache
+// (or start a new open after a failure).
+func (c *ZipPartCache) finishOpen(shard *zipPartShard, path string, call *zipOpenCall, entry *ZipPartCacheEntry, err error) {
+	shard.mu.Lock()
+	delete(shard.inflight, path)
+	shard.mu.Unlock()
+	call.entry, call.err = entry, err
+	close(call.done)
+}
 
-	return entry, nil
+// openUncached opens the zip part at path on the open worker pool without caching it.
+// ctx bounds the wait for a free worker; once started, the open is waited for. The caller
+// must Close the returned reader.
+func (c *ZipPartCache) openUncached(ctx context.Context, path string) (*zipPartReader, error) {
+	var (
+		reader *zipPartReader
+		err    error
+	)
+	done := make(chan struct{})
+	if serr := c.opens.submit(ctx, func() {
+		reader, err = c.open(path)
+		close(done)
+	}); serr != nil {
+		return nil, fmt.Errorf("acquire open slot: %w", serr)
+	}
+	<-done
+	return reader, err
 }
 
-// evictLRU removes the least recently used entry from the given shard -- O(1).
+// evictLRU removes the least recently used unpinned entry from the given shard. O(1)
+// unless pinned entries sit at the LRU tail; pins are bounded, so the walk is short.
 // Caller must hold shard.mu.
 func (c *ZipPartCache) evictLRU(shard *zipPartShard) {
-	elem := shard.lru.Back()
+	var elem *list.Element
+	for e := shard.lru.Back(); e != nil; e = e.Prev() {
+		path, _ := e.Value.(string) //nolint:errcheck // internal invariant: LRU list only contains string path values
+		if entry, ok := shard.entries[path]; ok && !entry.pinned {
+			elem = e
+			break
+		}
+	}
 	if elem == nil {
 		return
 	}
@@ -387,7 +779,7 @@ func (c *ZipPartCache) evictLRU(shard *zipPartShard) {
 	}
 
 	// Close resources
-	_ = entry.reader.Close()
+	_ = c.dropEntry(shard, entry)
 	delete(shard.entries, oldestPath)
 
 	// Update metrics
@@ -417,7 +809,7 @@ func (c *ZipPartCache) Remove(path string) {
 	shard.lru.Remove(entry.element)
 
 	// Close resources
-	_ = entry.reader.Close()
+	_ = c.dropEntry(shard, entry)
 	delete(shard.entries, path)
 
 	// Update metrics
@@ -426,6 +818,95 @@ func (c *ZipPartCache) Remove(path string) {
 	}
 }
 
+// Close closes every cached zip part handle across all shards, empties the cache and
+// stops the open workers. It is meant for shutdown, after the HTTP server has stopped
+// serving: readers still in use by a request would fail. The cache remains usable; a
+// later Get restarts the workers and reopens the part.
+func (c *ZipPartCache) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.opens.close()
+
+	var errs []error
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		for path, entry := range shard.entries {
+			if err := c.dropEntry(shard, entry); err != nil {
+				errs = append(errs, fmt.Errorf("close %s: %w", path, err))
+			}
+			delete(shard.entries, path)
+		}
+		shard.lru.Init()
+		shard.mu.Unlock()
+	}
+
+	if c.metrics != nil {
+		c.metrics.SetZipCacheOpen(c.totalOpen())
+	}
+	return errors.Join(errs...)
+}
+
+// OpenCount returns the number of open zip parts, taking each shard lock.
+func (c *ZipPartCache) OpenCount() int {
+	if c == nil {
+		return 0
+	}
+	total := 0
+	for i := range c.shards {
+		c.shards[i].mu.Lock()
+		total += len(c.shards[i].entries)
+		c.shards[i].mu.Unlock()
+	}
+	return total
+}
+
+// ZipPartCacheItem describes one open zip part in a ZipPartCache snapshot.
+type ZipPartCacheItem struct {
+	Path     string    `json:"path"`
+	LastUsed time.Time `json:"last_used"`
+	Pinned   bool      `json:"pinned"`
+}
+
+// Snapshot lists the open zip parts, most recently used first. Each shard is locked only
+// while it is copied, so the result is not an atomic view across shards.
+func (c *ZipPartCache) Snapshot() []ZipPartCacheItem {
+	if c == nil {
+		return nil
+	}
+	var items []ZipPartCacheItem
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		for path, entry := range shard.entries {
+			items = append(items, ZipPartCacheItem{Path: path, LastUsed: entry.lastUsed, Pinned: entry.pinned})
+		}
+		shard.mu.Unlock()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].LastUsed.Equal(items[j].LastUsed) {
+			return items[i].LastUsed.After(items[j].LastUsed)
+		}
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// Capacity returns the maximum number of open zip parts (the per-shard limit summed over
+// all shards plus the pin reserve, which may differ from CT_ZIP_CACHE_MAX_OPEN after
+// rounding).
+func (c *ZipPartCache) Capacity() int {
+	if c == nil {
+		return 0
+	}
+	total := int(c.maxPinned)
+	for i := range c.shards {
+		total += c.shards[i].maxOpen
+	}
+	return total
+}
+
 // totalOpen returns the total number of open entries across all shards.
 // Callers that need an exact count should hold all shard locks; callers that
 // only need a metric approximation (our case) can call this lock-free --
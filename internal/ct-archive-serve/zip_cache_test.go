@@ -1,13 +1,21 @@
 package ctarchiveserve
 
 import (
+	"archive/zip"
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestZipIntegrityCache_FailedTTLAndRetest(t *testing.T) {
@@ -88,6 +96,274 @@ func TestZipIntegrityCache_PassedCachePersistsUntilInvalidated(t *testing.T) {
 	}
 }
 
+// mustCreateZipWithComment writes a single-entry zip part with the given archive comment.
+func mustCreateZipWithComment(t *testing.T, path, comment string) {
+	t.Helper()
+
+	//nolint:gosec // G304: path is validated and comes from test helpers, not user input
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create(%q) error = %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	w := zip.NewWriter(f)
+	if _, err := w.Create("checkpoint"); err != nil {
+		t.Fatalf("zip.Create() error = %v", err)
+	}
+	if err := w.SetComment(comment); err != nil {
+		t.Fatalf("SetComment() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("zip.Close() error = %v", err)
+	}
+}
+
+func TestZipIntegrityCache_CompleteMarker(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	marked := filepath.Join(root, "000.zip")
+	unmarked := filepath.Join(root, "001.zip")
+	mustCreateZipWithComment(t, marked, "producer v2 COMPLETE")
+	mustCreateZipWithComment(t, unmarked, "")
+
+	z := NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
+	z.SetCompleteMarker("COMPLETE")
+	if err := z.Check(marked); err != nil {
+		t.Errorf("Check(marked) error = %v, want nil", err)
+	}
+	if err := z.Check(unmarked); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Errorf("Check(unmarked) error = %v, want %v", err, ErrZipTemporarilyUnavailable)
+	}
+
+	// Without a marker configured the comment is not consulted.
+	z = NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
+	if err := z.Check(unmarked); err != nil {
+		t.Errorf("Check(unmarked) without marker error = %v, want nil", err)
+	}
+}
+
+func TestZipIntegrityCache_GrowthCheck(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	path := filepath.Join(root, "000.zip")
+	mustCreateZipWithComment(t, path, "")
+
+	z := NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
+	z.SetGrowthCheck(time.Millisecond)
+	z.sleep = func(time.Duration) {
+		// The producer appends while the cache waits between the two stats.
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Errorf("OpenFile() error = %v", err)
+			return
+		}
+		_, _ = f.Write([]byte("more"))
+		_ = f.Close()
+	}
+	if err := z.Check(path); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Fatalf("Check(growing) error = %v, want %v", err, ErrZipTemporarilyUnavailable)
+	}
+
+	// Once the part stops growing it passes (a fresh cache skips the failure TTL).
+	mustCreateZipWithComment(t, path, "")
+	z = NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
+	z.SetGrowthCheck(time.Millisecond)
+	if err := z.Check(path); err != nil {
+		t.Fatalf("Check(stable) error = %v, want nil", err)
+	}
+}
+
+func TestZipIntegrityCache_PassTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time { return now }
+	verifyCalls := 0
+	verify := func(string) error {
+		verifyCalls++
+		return nil
+	}
+
+	z := NewZipIntegrityCache(5*time.Minute, clock, verify, nil)
+	z.SetPassTTL(time.Hour)
+	path := "/tmp/000.zip"
+
+	for _, step := range []struct {
+		advance time.Duration
+		want    int
+	}{
+		{0, 1},                       // first check verifies
+		{time.Hour - time.Second, 1}, // still within the pass TTL
+		{time.Second, 2},             // expired: verified again
+		{30 * time.Minute, 2},        // the new pass lasts another hour
+	} {
+		now = now.Add(step.advance)
+		if err := z.Check(path); err != nil {
+			t.Fatalf("Check() error = %v, want nil", err)
+		}
+		if verifyCalls != step.want {
+			t.Fatalf("after +%v: verify calls = %d, want %d", step.advance, verifyCalls, step.want)
+		}
+	}
+
+	// Immutable archives ignore the pass TTL.
+	verifyCalls = 0
+	z = NewZipIntegrityCache(5*time.Minute, clock, verify, nil)
+	z.SetImmutable(true)
+	z.SetPassTTL(time.Hour)
+	_ = z.Check(path)
+	now = now.Add(48 * time.Hour)
+	_ = z.Check(path)
+	if verifyCalls != 1 {
+		t.Errorf("immutable: verify calls = %d, want 1", verifyCalls)
+	}
+}
+
+func TestZipIntegrityCache_VerifyTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	var verifyCalls atomic.Int64
+	verify := func(string) error {
+		verifyCalls.Add(1)
+		<-release // a read stuck on a bad disk
+		return nil
+	}
+
+	z := NewZipIntegrityCache(5*time.Minute, time.Now, verify, nil)
+	z.SetVerifyTimeout(50 * time.Millisecond)
+	path := "/tmp/000.zip"
+
+	// Concurrent checkers share the hanging verify and are all released at the deadline.
+	const checkers = 4
+	errs := make(chan error, checkers)
+	for range checkers {
+		go func() { errs <- z.Check(path) }()
+	}
+	for range checkers {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrZipTemporarilyUnavailable) {
+				t.Fatalf("Check() error = %v, want ErrZipTemporarilyUnavailable", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Check() error = %v, want it to wrap context.DeadlineExceeded", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Check() did not return after the verify timeout")
+		}
+	}
+
+	// The timeout is cached as a failure: no new verify until the fail TTL expires.
+	before := verifyCalls.Load()
+	if err := z.Check(path); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Fatalf("Check() after timeout error = %v, want ErrZipTemporarilyUnavailable", err)
+	}
+	if got := verifyCalls.Load(); got != before {
+		t.Errorf("verify calls = %d after a cached timeout, want %d", got, before)
+	}
+}
+
+func TestZipIntegrityCache_VerifyTimeout_OneStuckVerifyPerPath(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	t.Cleanup(func() { releaseOnce.Do(func() { close(release) }) })
+	var verifyCalls atomic.Int64
+	verify := func(string) error {
+		verifyCalls.Add(1)
+		<-release // a read stuck on a bad disk
+		return nil
+	}
+
+	var mu sync.Mutex
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	z := NewZipIntegrityCache(time.Minute, clock, verify, nil)
+	z.SetVerifyTimeout(50 * time.Millisecond)
+	path := "/tmp/000.zip"
+
+	if err := z.Check(path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Check() error = %v, want a timeout", err)
+	}
+
+	// After the fail TTL the first verify still hangs: the check fails at once without
+	// starting a second one.
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+	start := time.Now()
+	err := z.Check(path)
+	if !errors.Is(err, ErrZipTemporarilyUnavailable) || !errors.Is(err, errVerifyStillRunning) {
+		t.Fatalf("Check() while stuck error = %v, want errVerifyStillRunning", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("Check() while stuck took %v, want it to fail without waiting for the timeout", elapsed)
+	}
+	if got := verifyCalls.Load(); got != 1 {
+		t.Fatalf("verify calls while stuck = %d, want 1", got)
+	}
+
+	// Once the stuck verify returns, the path is verified again after the fail TTL.
+	releaseOnce.Do(func() { close(release) })
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		z.mu.RLock()
+		stuck := z.stuck[path]
+		z.mu.RUnlock()
+		if !stuck {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stuck verify not cleared after it returned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+	if err := z.Check(path); err != nil {
+		t.Fatalf("Check() after the stuck verify returned error = %v, want nil", err)
+	}
+	if got := verifyCalls.Load(); got != 2 {
+		t.Errorf("verify calls = %d, want 2", got)
+	}
+}
+
+func TestZipIntegrityCache_Immutable_PassedNeverRetested(t *testing.T) {
+	t.Parallel()
+
+	verifyCalls := 0
+	verify := func(string) error {
+		verifyCalls++
+		return nil
+	}
+
+	z := NewZipIntegrityCache(5*time.Minute, time.Now, verify, nil)
+	z.SetImmutable(true)
+	path := "/tmp/000.zip"
+
+	if err := z.Check(path); err != nil {
+		t.Fatalf("Check() error = %v, want nil", err)
+	}
+	z.InvalidatePassed(path)
+	if err := z.Check(path); err != nil {
+		t.Fatalf("Check() error = %v, want nil", err)
+	}
+	if got, want := verifyCalls, 1; got != want {
+		t.Fatalf("verifyCalls = %d, want %d", got, want)
+	}
+}
+
 func TestZipPartCache_GetAndCache(t *testing.T) {
 	t.Parallel()
 
@@ -100,7 +376,7 @@ func TestZipPartCache_GetAndCache(t *testing.T) {
 	cache := NewZipPartCache(10, nil, 0)
 
 	// First get: cache miss, should open and cache
-	entry1, err := cache.Get(zipPath)
+	entry1, err := cache.Get(context.Background(), zipPath)
 	if err != nil {
 		t.Fatalf("Get() error = %v", err)
 	}
@@ -112,7 +388,7 @@ func TestZipPartCache_GetAndCache(t *testing.T) {
 	}
 
 	// Second get: cache hit
-	entry2, err := cache.Get(zipPath)
+	entry2, err := cache.Get(context.Background(), zipPath)
 	if err != nil {
 		t.Fatalf("Get() error = %v", err)
 	}
@@ -136,7 +412,7 @@ func TestZipPartCache_LRUEviction(t *testing.T) {
 		mustCreateZip(t, p, map[string][]byte{
 			fmt.Sprintf("file%d", i): []byte(fmt.Sprintf("data%d", i)),
 		})
-		_, err := cache.Get(p)
+		_, err := cache.Get(context.Background(), p)
 		if err != nil {
 			t.Fatalf("Get(%q) error = %v", p, err)
 		}
@@ -155,6 +431,215 @@ func TestZipPartCache_LRUEviction(t *testing.T) {
 	}
 }
 
+func TestZipPartCache_Close(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	reg := prometheus.NewRegistry()
+	cache := NewZipPartCache(64, NewMetrics(reg), 0)
+
+	var entries []*ZipPartCacheEntry
+	for i := 0; i < 10; i++ {
+		p := filepath.Join(root, fmt.Sprintf("%03d.zip", i))
+		mustCreateZip(t, p, map[string][]byte{"checkpoint": []byte("x")})
+		entry, err := cache.Get(context.Background(), p)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", p, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := cache.totalOpen(); got != 0 {
+		t.Errorf("totalOpen() after Close = %d, want 0", got)
+	}
+	for _, entry := range entries {
+		if err := entry.reader.Close(); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("%s: second Close() error = %v, want %v", entry.path, err, os.ErrClosed)
+		}
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() == "ct_archive_serve_zip_cache_open" {
+			if got := mf.GetMetric()[0].GetGauge().GetValue(); got != 0 {
+				t.Errorf("zip_cache_open after Close = %v, want 0", got)
+			}
+		}
+	}
+
+	// The cache stays usable after Close.
+	if _, err := cache.Get(context.Background(), entries[0].path); err != nil {
+		t.Fatalf("Get() after Close error = %v", err)
+	}
+}
+
+func TestZipPartCache_PinnedMetadataPartSurvivesEviction(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	// With maxOpen=64 every shard holds one entry, so any path hashing to the pinned
+	// part's shard would normally evict it.
+	cache := NewZipPartCache(64, nil, 0)
+	cache.maxPinned = 1
+
+	metaPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, metaPath, map[string][]byte{"checkpoint": []byte("x")})
+	if _, err := cache.Get(context.Background(), metaPath); err != nil {
+		t.Fatalf("Get(000.zip) error = %v", err)
+	}
+	if got := cache.pinned.Load(); got != 0 {
+		t.Fatalf("pinned after Get = %d, want 0 (only Pin pins)", got)
+	}
+	cache.Pin(metaPath)
+	cache.Pin(metaPath) // pinning twice takes one pin
+	metaShard := cache.shardFor(metaPath)
+
+	// Fill the cache with tile parts until several have landed in the pinned part's shard.
+	collisions := 0
+	for i := 1; collisions < 3 && i < 1000; i++ {
+		p := filepath.Join(root, fmt.Sprintf("%03d.zip", i))
+		mustCreateZip(t, p, map[string][]byte{"tile/0/000": []byte("x")})
+		if _, err := cache.Get(context.Background(), p); err != nil {
+			t.Fatalf("Get(%q) error = %v", p, err)
+		}
+		if cache.shardFor(p) == metaShard {
+			collisions++
+		}
+	}
+	if collisions < 3 {
+		t.Fatalf("only %d parts hashed to the pinned part's shard", collisions)
+	}
+
+	metaShard.mu.Lock()
+	entry, ok := metaShard.entries[metaPath]
+	metaShard.mu.Unlock()
+	if !ok || !entry.pinned {
+		t.Fatalf("000.zip evicted or unpinned (present = %v) after filling the cache", ok)
+	}
+	if got := cache.pinned.Load(); got != 1 {
+		t.Errorf("pinned = %d, want 1", got)
+	}
+
+	// The pin is released with the entry.
+	cache.Remove(metaPath)
+	if got := cache.pinned.Load(); got != 0 {
+		t.Errorf("pinned after Remove = %d, want 0", got)
+	}
+}
+
+func TestServer_PinsMetadataPartFromMetadataRoutesOnly(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	metaPath := filepath.Join(logFolder, "000.zip")
+	mustCreateZip(t, metaPath, map[string][]byte{
+		"checkpoint": checkpointBody(1),
+		"tile/0/000": make([]byte, 32),
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	cache := NewZipPartCache(16, nil, 4)
+	t.Cleanup(func() { _ = cache.Close() })
+	zr.SetZipPartCache(cache)
+	server := NewServer(cfg, nil, nil, archiveIndex, zr, nil)
+
+	get := func(path string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", path, w.Code)
+		}
+	}
+	pinned := func() bool {
+		shard := cache.shardFor(metaPath)
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+		entry, ok := shard.entries[metaPath]
+		return ok && entry.pinned
+	}
+
+	get("/test_log/tile/0/000")
+	if pinned() {
+		t.Fatalf("000.zip pinned after a tile read, want unpinned")
+	}
+	get("/test_log/checkpoint")
+	if !pinned() {
+		t.Fatalf("000.zip not pinned after a checkpoint read")
+	}
+
+	// The log disappears: the next refresh drops its parts and their pins.
+	archiveIndex.OnRefresh(cache.DropRemovedLogs)
+	if err := os.RemoveAll(logFolder); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	if err := archiveIndex.refreshOnce(); err != nil {
+		t.Fatalf("refreshOnce() error = %v", err)
+	}
+	if got := cache.totalOpen(); got != 0 {
+		t.Errorf("totalOpen() after the log disappeared = %d, want 0", got)
+	}
+	if got := cache.pinned.Load(); got != 0 {
+		t.Errorf("pinned after the log disappeared = %d, want 0", got)
+	}
+}
+
+func TestZipPartCache_DropRemovedLogsKeepsCurrentLogs(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	cache := NewZipPartCache(16, nil, 0)
+	var keep, drop string
+	for _, name := range []string{"ct_keep", "ct_drop"} {
+		folder := filepath.Join(root, name)
+		mustMkdir(t, folder)
+		p := filepath.Join(folder, "000.zip")
+		mustCreateZip(t, p, map[string][]byte{"checkpoint": []byte("x")})
+		if _, err := cache.Get(context.Background(), p); err != nil {
+			t.Fatalf("Get(%q) error = %v", p, err)
+		}
+		cache.Pin(p)
+		if name == "ct_keep" {
+			keep = p
+		} else {
+			drop = p
+		}
+	}
+
+	cache.DropRemovedLogs(ArchiveSnapshot{Logs: map[string]ArchiveLog{
+		"keep": {FolderPath: filepath.Join(root, "ct_keep") + "/"},
+	}})
+	for path, want := range map[string]bool{keep: true, drop: false} {
+		shard := cache.shardFor(path)
+		shard.mu.Lock()
+		_, ok := shard.entries[path]
+		shard.mu.Unlock()
+		if ok != want {
+			t.Errorf("%s cached = %v, want %v", path, ok, want)
+		}
+	}
+	if got := cache.pinned.Load(); got != 1 {
+		t.Errorf("pinned = %d, want 1", got)
+	}
+}
+
 func TestZipPartCache_ShardedEviction(t *testing.T) {
 	t.Parallel()
 
@@ -174,7 +659,7 @@ func TestZipPartCache_ShardedEviction(t *testing.T) {
 
 	// Insert all 65 entries.
 	for _, p := range zipPaths {
-		_, err := cache.Get(p)
+		_, err := cache.Get(context.Background(), p)
 		if err != nil {
 			t.Fatalf("Get(%q) error = %v", p, err)
 		}
@@ -214,7 +699,7 @@ func TestZipPartCache_ConcurrentAccess(t *testing.T) {
 			defer wg.Done()
 			for j := 0; j < iterationsPerGoroutine; j++ {
 				zipPath := zipFiles[id%len(zipFiles)]
-				entry, err := cache.Get(zipPath)
+				entry, err := cache.Get(context.Background(), zipPath)
 				if err != nil {
 					t.Errorf("goroutine %d iteration %d: Get() error = %v", id, j, err)
 					return
@@ -259,7 +744,7 @@ func TestZipPartCache_SingleflightDeduplication(t *testing.T) {
 		go func(idx int) {
 			defer wg.Done()
 			<-gate // wait for all goroutines to be ready
-			entries[idx], errs[idx] = cache.Get(zipPath)
+			entries[idx], errs[idx] = cache.Get(context.Background(), zipPath)
 		}(i)
 	}
 
@@ -323,7 +808,7 @@ func TestZipPartCache_ConcurrentMultiLogStress(t *testing.T) {
 			<-gate
 			for iter := 0; iter < iterations; iter++ {
 				for _, p := range zipPaths[logIdx] {
-					entry, err := cache.Get(p)
+					entry, err := cache.Get(context.Background(), p)
 					if err != nil {
 						t.Errorf("log %d: Get(%q) error = %v", logIdx, p, err)
 						return
@@ -402,3 +887,259 @@ func TestZipIntegrityCache_ThunderingHerd(t *testing.T) {
 		t.Error("path should be in passed cache after successful verification")
 	}
 }
+
+// saturateOpenPool occupies every open worker of cache until the returned func is called.
+func saturateOpenPool(t *testing.T, cache *ZipPartCache) (release func()) {
+	t.Helper()
+
+	block := make(chan struct{})
+	for i := 0; i < cache.opens.size; i++ {
+		if err := cache.opens.submit(context.Background(), func() { <-block }); err != nil {
+			t.Fatalf("submit() error = %v", err)
+		}
+	}
+	return func() { close(block) }
+}
+
+func TestZipPartCache_Get_CancelWhilePoolSaturated(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		"test.txt": []byte("test content"),
+	})
+
+	cache := NewZipPartCache(10, nil, 1)
+	defer func() { _ = cache.Close() }()
+
+	// Saturate the open workers so Get has to wait for one.
+	release := saturateOpenPool(t, cache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, zipPath)
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Get() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Get() did not return after context cancellation")
+	}
+
+	if got := cache.totalOpen(); got != 0 {
+		t.Fatalf("totalOpen() = %d, want 0 (cancelled Get must not open the zip)", got)
+	}
+
+	// Once a worker is free, the abandoned open is skipped rather than run.
+	var opens atomic.Int32
+	cache.open = func(path string) (*zipPartReader, error) {
+		opens.Add(1)
+		return openZipPart(path)
+	}
+	release()
+	reader, err := cache.openUncached(context.Background(), zipPath)
+	if err != nil {
+		t.Fatalf("openUncached() error = %v", err)
+	}
+	_ = reader.Close()
+	if got := opens.Load(); got != 1 {
+		t.Fatalf("opens = %d, want 1 (only the uncached open; the abandoned one is skipped)", got)
+	}
+}
+
+func TestZipPartCache_Get_WaiterCancelReturnsPromptly(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		"test.txt": []byte("test content"),
+	})
+
+	cache := NewZipPartCache(10, nil, 1)
+	release := saturateOpenPool(t, cache)
+
+	// The leader blocks on the saturated pool with a non-cancellable context.
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(context.Background(), zipPath)
+		leaderDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// A second caller joins the in-flight open and then gives up.
+	ctx, cancel := context.WithCancel(context.Background())
+	waiterDone := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, zipPath)
+		waiterDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-waiterDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("waiter Get() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("waiter Get() did not return after context cancellation")
+	}
+
+	// Freeing the worker lets the leader complete normally.
+	release()
+	select {
+	case err := <-leaderDone:
+		if err != nil {
+			t.Fatalf("leader Get() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("leader Get() did not complete after the worker was freed")
+	}
+}
+
+func TestZipPartCache_Get_LeaderCancelFollowerSucceeds(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		"test.txt": []byte("test content"),
+	})
+
+	cache := NewZipPartCache(10, nil, 1)
+	release := saturateOpenPool(t, cache)
+	defer func() { _ = cache.Close() }()
+
+	// The leader starts the open and then gives up while it is still queued.
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, zipPath)
+		leaderDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// A second caller joins the in-flight open.
+	followerDone := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(context.Background(), zipPath)
+		followerDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-leaderDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("leader Get() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("leader Get() did not return after context cancellation")
+	}
+
+	// The leader's cancellation must not fail the open the follower is waiting on.
+	release()
+	select {
+	case err := <-followerDone:
+		if err != nil {
+			t.Fatalf("follower Get() error = %v, want success", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("follower Get() did not complete after the worker was freed")
+	}
+	if got := cache.OpenCount(); got != 1 {
+		t.Fatalf("OpenCount() = %d, want 1", got)
+	}
+}
+
+// Not parallel: it counts goroutines, which concurrently running tests would skew.
+func TestZipPartCache_ColdBurstBoundedOpenWorkers(t *testing.T) {
+	const (
+		parts           = 500
+		requestsPerPart = 4
+		workers         = 8
+	)
+
+	root := t.TempDir()
+	paths := make([]string, parts)
+	for i := range paths {
+		paths[i] = filepath.Join(root, fmt.Sprintf("ct_log%03d", i), "001.zip")
+		mustMkdir(t, filepath.Dir(paths[i]))
+		mustCreateZip(t, paths[i], map[string][]byte{"tile/0/000": []byte("x")})
+	}
+
+	cache := NewZipPartCache(4*parts, nil, workers)
+	defer func() { _ = cache.Close() }()
+
+	baseline := runtime.NumGoroutine()
+	var (
+		opens, inOpen, peakOpen atomic.Int64
+		peakGoroutines          atomic.Int64
+	)
+	cache.open = func(path string) (*zipPartReader, error) {
+		opens.Add(1)
+		n := inOpen.Add(1)
+		defer inOpen.Add(-1)
+		for {
+			peak := peakOpen.Load()
+			if n <= peak || peakOpen.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		if g := int64(runtime.NumGoroutine()); g > peakGoroutines.Load() {
+			peakGoroutines.Store(g)
+		}
+		time.Sleep(time.Millisecond) // a slow disk keeps the burst queued
+		return openZipPart(path)
+	}
+
+	start := make(chan struct{})
+	errs := make(chan error, parts*requestsPerPart)
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		for j := 0; j < requestsPerPart; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if _, err := cache.Get(context.Background(), path); err != nil {
+					errs <- err
+				}
+			}()
+		}
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if got := opens.Load(); got != parts {
+		t.Errorf("opens = %d, want %d (one per part)", got, parts)
+	}
+	if got := peakOpen.Load(); got > workers {
+		t.Errorf("peak concurrent opens = %d, want <= %d", got, workers)
+	}
+	if got := cache.opens.workers.Load(); got != workers {
+		t.Errorf("open workers = %d, want %d", got, workers)
+	}
+	// Besides the request goroutines themselves, only the fixed pool runs: no goroutine
+	// per queued part. The slack covers runtime and test framework goroutines.
+	if limit := int64(baseline + parts*requestsPerPart + workers + 16); peakGoroutines.Load() > limit {
+		t.Errorf("peak goroutines = %d, want <= %d", peakGoroutines.Load(), limit)
+	}
+	if got := cache.OpenCount(); got != parts {
+		t.Errorf("OpenCount() = %d, want %d", got, parts)
+	}
+}
@@ -0,0 +1,140 @@
+package ctarchiveserve
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// errZipOpenPoolClosed is returned for jobs still queued when the pool is closed.
+var errZipOpenPoolClosed = errors.New("zip open pool closed")
+
+// zipOpenPool runs zip part opens on a fixed number of worker goroutines
+// (CT_ZIP_CACHE_MAX_CONCURRENT_OPENS), bounding both concurrent opens and the goroutines
+// doing them. During a cold-start burst, jobs wait in a FIFO queue until a worker is
+// free; the requests that queued them wait on their own channels, not on a goroutine of
+// their own holding an open in flight.
+//
+// Workers are started on first use and stopped by close; a later enqueue starts them again.
+type zipOpenPool struct {
+	size int
+	wake chan struct{} // a token per queued job, up to size; workers re-check the queue
+
+	mu    sync.Mutex
+	queue []func(err error) // called with nil to run, or with the reason it never will
+	quit  chan struct{}     // closed to stop the running workers; nil when none run
+
+	workers atomic.Int64 // running worker goroutines
+	busy    atomic.Int64 // workers currently running a job
+}
+
+func newZipOpenPool(size int) *zipOpenPool {
+	return &zipOpenPool{
+		size: size,
+		wake: make(chan struct{}, size),
+	}
+}
+
+// enqueue queues job without waiting. A worker calls job(nil) when it gets to it; if the
+// pool is closed first, job is called with errZipOpenPoolClosed instead.
+func (p *zipOpenPool) enqueue(job func(err error)) {
+	p.mu.Lock()
+	if p.quit == nil {
+		p.quit = make(chan struct{})
+		for i := 0; i < p.size; i++ {
+			p.workers.Add(1)
+			go p.work(p.quit)
+		}
+	}
+	p.queue = append(p.queue, job)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default: // every worker already has a token to wake up to
+	}
+}
+
+// submit hands job to a worker, waiting for one to take it until ctx is done. Once
+// taken, job runs to completion even if ctx is cancelled afterwards.
+func (p *zipOpenPool) submit(ctx context.Context, job func()) error {
+	if err := ctx.Err(); err != nil {
+		return err //nolint:wrapcheck // context errors are returned as-is for errors.Is checks
+	}
+
+	const (
+		queued = iota
+		taken
+		withdrawn
+	)
+	var state atomic.Int32
+	accepted := make(chan error, 1)
+	p.enqueue(func(err error) {
+		if err != nil {
+			accepted <- err
+			return
+		}
+		if !state.CompareAndSwap(queued, taken) {
+			return // the caller gave up while the job was queued
+		}
+		accepted <- nil
+		job()
+	})
+
+	select {
+	case err := <-accepted:
+		return err
+	case <-ctx.Done():
+		if state.CompareAndSwap(queued, withdrawn) {
+			return ctx.Err() //nolint:wrapcheck // context errors are returned as-is for errors.Is checks
+		}
+		return <-accepted
+	}
+}
+
+func (p *zipOpenPool) work(quit <-chan struct{}) {
+	defer p.workers.Add(-1)
+	for {
+		if job := p.next(); job != nil {
+			p.busy.Add(1)
+			job(nil)
+			p.busy.Add(-1)
+			continue
+		}
+		select {
+		case <-p.wake:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// next pops the oldest queued job, or returns nil when the queue is empty.
+func (p *zipOpenPool) next() func(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queue) == 0 {
+		return nil
+	}
+	job := p.queue[0]
+	p.queue[0] = nil
+	p.queue = p.queue[1:]
+	return job
+}
+
+// close stops the workers once their current job is done and fails the jobs still queued.
+func (p *zipOpenPool) close() {
+	p.mu.Lock()
+	if p.quit != nil {
+		close(p.quit)
+		p.quit = nil
+	}
+	queued := p.queue
+	p.queue = nil
+	p.mu.Unlock()
+
+	for _, job := range queued {
+		job(errZipOpenPoolClosed)
+	}
+}
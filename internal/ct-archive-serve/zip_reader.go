@@ -3,40 +3,82 @@ package ctarchiveserve
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
+
+	"golang.org/x/sync/semaphore"
 )
 
 // ErrNotFound indicates the requested content does not exist (404).
 var ErrNotFound = errors.New("not found")
 
+// DefaultZipEntryFailureThreshold is the default CT_ZIP_ENTRY_FAILURE_THRESHOLD.
+const DefaultZipEntryFailureThreshold = 3
+
 // ZipReader opens and streams entries from zip parts.
 type ZipReader struct {
 	integrity  *ZipIntegrityCache
 	cache      *ZipPartCache        // Optional: zip part handle cache
 	entryCache *EntryContentCache   // Optional: decompressed entry content cache
+	fillSem    *semaphore.Weighted  // Optional: bounds concurrent entry cache population reads
+
+	// entryFailureThreshold is the number of consecutive failed entry opens on a cached
+	// zip part before it is dropped and re-verified (CT_ZIP_ENTRY_FAILURE_THRESHOLD).
+	entryFailureThreshold int32
+
+	// openFile opens a zip entry; tests replace it to inject read errors.
+	openFile func(f *zip.File) (io.ReadCloser, error)
 }
 
 // NewZipReader constructs a ZipReader that uses the provided integrity cache.
 func NewZipReader(integrity *ZipIntegrityCache) *ZipReader {
 	return &ZipReader{
-		integrity: integrity,
-		cache:     nil, // Cache is optional (Phase 5 optimization)
+		integrity:             integrity,
+		cache:                 nil, // Cache is optional (Phase 5 optimization)
+		entryFailureThreshold: DefaultZipEntryFailureThreshold,
+		openFile:              (*zip.File).Open,
 	}
 }
 
+// SetEntryFailureThreshold sets how many consecutive requests must fail to open an entry
+// of a cached zip part, each after one retry, before the part is dropped from the zip
+// part cache and its integrity re-verified (CT_ZIP_ENTRY_FAILURE_THRESHOLD). n <= 1
+// drops it after the first failed request.
+func (zr *ZipReader) SetEntryFailureThreshold(n int) {
+	zr.entryFailureThreshold = int32(max(n, 1)) //nolint:gosec // thresholds are small
+}
+
 // SetZipPartCache sets the optional zip part cache for performance optimization.
 func (zr *ZipReader) SetZipPartCache(cache *ZipPartCache) {
 	zr.cache = cache
 }
 
+// PinPart pins zipPath in the zip part cache, if it is cached there; see ZipPartCache.Pin.
+func (zr *ZipReader) PinPart(zipPath string) {
+	zr.cache.Pin(zipPath)
+}
+
 // SetEntryContentCache sets the optional decompressed entry content cache.
 func (zr *ZipReader) SetEntryContentCache(cache *EntryContentCache) {
 	zr.entryCache = cache
 }
 
+// SetEntryCacheFillConcurrency limits how many entries may be read fully into memory at
+// once to populate the entry content cache (CT_ENTRY_CACHE_FILL_CONCURRENCY). Beyond the
+// limit, entries are streamed without being cached. n <= 0 means no limit.
+func (zr *ZipReader) SetEntryCacheFillConcurrency(n int) {
+	if n <= 0 {
+		zr.fillSem = nil
+		return
+	}
+	zr.fillSem = semaphore.NewWeighted(int64(n))
+}
+
 // OpenEntry opens a zip entry by name and returns an io.ReadCloser for streaming.
 //
 // The lookup order is optimized to minimize syscalls and I/O on the hot path:
@@ -47,25 +89,76 @@ func (zr *ZipReader) SetEntryContentCache(cache *EntryContentCache) {
 //
 // Errors:
 // - ErrNotFound for missing zip parts or missing entries (404)
-// - ErrZipTemporarilyUnavailable for zip integrity failures (503)
-func (zr *ZipReader) OpenEntry(zipPath, entryName string) (io.ReadCloser, error) {
+// - ErrZipTemporarilyUnavailable for zip integrity failures (503), or without any I/O
+//   while the log's circuit breaker is open (CT_LOG_CIRCUIT_FAILURES)
+// - ctx.Err() (wrapped) if ctx is cancelled while waiting to open a zip part
+//
+// ctx is the request context; it is used to abandon waits for a zip open slot.
+func (zr *ZipReader) OpenEntry(ctx context.Context, zipPath, entryName string) (io.ReadCloser, error) {
+	rc, _, err := zr.OpenEntrySource(ctx, zipPath, entryName)
+	return rc, err
+}
+
+// ContentSource reports where OpenEntrySource found an entry's content.
+type ContentSource int
+
+const (
+	// ContentSourceCold means the zip part had to be opened from disk.
+	ContentSourceCold ContentSource = iota
+	// ContentSourcePartCache means the zip part was already open in the zip part cache.
+	ContentSourcePartCache
+	// ContentSourceEntryCache means the decompressed entry was in the entry content cache.
+	ContentSourceEntryCache
+	// ContentSourceUpstream means the entry was fetched from CT_UPSTREAM_BASE_URL.
+	ContentSourceUpstream
+)
+
+// String returns the metrics label for src.
+func (src ContentSource) String() string {
+	switch src {
+	case ContentSourceEntryCache:
+		return "entry_cache"
+	case ContentSourcePartCache:
+		return "part_cache"
+	case ContentSourceUpstream:
+		return "upstream"
+	default:
+		return "cold"
+	}
+}
+
+// OpenEntrySource is OpenEntry, also reporting which cache (if any) served the entry.
+// The source is only meaningful when err is nil.
+func (zr *ZipReader) OpenEntrySource(ctx context.Context, zipPath, entryName string) (io.ReadCloser, ContentSource, error) {
 	if zr == nil {
-		return nil, errors.New("zip reader is nil")
+		return nil, ContentSourceCold, errors.New("zip reader is nil")
+	}
+	if !zr.integrity.allowRead(zipPath) {
+		return nil, ContentSourceCold, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, errLogCircuitOpen)
 	}
 
 	// Fast path: try entry content cache first (zero I/O, zero decompression).
 	if zr.entryCache != nil {
 		data, ok := zr.entryCache.Get(zipPath, entryName)
 		if ok {
-			return io.NopCloser(bytes.NewReader(data)), nil
+			return io.NopCloser(bytes.NewReader(data)), ContentSourceEntryCache, nil
 		}
 	}
 
 	// Fast path: try zip part cache (skip stat + integrity for cached entries).
 	if zr.cache != nil {
-		cacheEntry, err := zr.cache.Get(zipPath)
+		cacheEntry, hit, err := zr.cache.get(ctx, zipPath)
 		if err == nil {
-			return zr.openFromCacheEntry(cacheEntry, zipPath, entryName)
+			src := ContentSourceCold
+			if hit {
+				src = ContentSourcePartCache
+			}
+			rc, err := zr.openFromCacheEntry(cacheEntry, zipPath, entryName)
+			zr.readDone(zipPath, err)
+			return rc, src, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ContentSourceCold, fmt.Errorf("open entry: %w", ctxErr)
 		}
 		// Cache miss: fall through to full validation path.
 	}
@@ -76,28 +169,43 @@ func (zr *ZipReader) OpenEntry(zipPath, entryName string) (io.ReadCloser, error)
 			zr.integrity.InvalidatePassed(zipPath)
 		}
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("%w: zip part missing", ErrNotFound)
+			return nil, ContentSourceCold, fmt.Errorf("%w: zip part missing", ErrNotFound)
 		}
-		return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+		return nil, ContentSourceCold, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
 	}
 
 	if zr.integrity != nil {
 		if err := zr.integrity.Check(zipPath); err != nil {
-			return nil, err
+			return nil, ContentSourceCold, err
 		}
 	}
 
 	// After validation, try to populate the cache instead of doing a
 	// redundant on-demand open.
 	if zr.cache != nil {
-		cacheEntry, err := zr.cache.Get(zipPath)
+		cacheEntry, err := zr.cache.Get(ctx, zipPath)
 		if err == nil {
-			return zr.openFromCacheEntry(cacheEntry, zipPath, entryName)
+			rc, err := zr.openFromCacheEntry(cacheEntry, zipPath, entryName)
+			zr.readDone(zipPath, err)
+			return rc, ContentSourceCold, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ContentSourceCold, fmt.Errorf("open entry: %w", ctxErr)
 		}
 	}
 
 	// Fallback: on-demand open (when cache is nil or cache.Get failed).
-	return zr.openOnDemand(zipPath, entryName)
+	rc, err := zr.openOnDemand(zipPath, entryName)
+	zr.readDone(zipPath, err)
+	return rc, ContentSourceCold, err
+}
+
+// readDone closes the log circuit breaker of zipPath if it is half-open and an entry of
+// the part was just opened from disk (err is nil). Entry cache hits do not count.
+func (zr *ZipReader) readDone(zipPath string, err error) {
+	if err == nil {
+		zr.integrity.readSucceeded(zipPath)
+	}
 }
 
 // openFromCacheEntry opens an entry from a cached zip part, optionally populating
@@ -108,25 +216,42 @@ func (zr *ZipReader) openFromCacheEntry(cacheEntry *ZipPartCacheEntry, zipPath,
 		return nil, fmt.Errorf("%w: zip entry missing", ErrNotFound)
 	}
 
-	rc, err := entry.Open()
+	// A read error is usually a blip, so the entry is retried once on the same reader, and
+	// the part (whose central directory would have to be read again) is only dropped after
+	// entryFailureThreshold consecutive failed requests.
+	rc, err := zr.openCachedEntry(entry, zipPath, entryName)
 	if err != nil {
-		zr.cache.Remove(zipPath)
-		if zr.integrity != nil {
-			zr.integrity.InvalidatePassed(zipPath)
+		rc, err = zr.openCachedEntry(entry, zipPath, entryName)
+	}
+	if err != nil {
+		if cacheEntry.entryFailures.Add(1) >= zr.entryFailureThreshold {
+			zr.cache.Remove(zipPath)
+			if zr.integrity != nil {
+				zr.integrity.InvalidatePassed(zipPath)
+			}
 		}
 		return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
 	}
+	cacheEntry.entryFailures.Store(0)
+	return rc, nil
+}
 
-	// If entry content cache is available, read fully, cache, and return from cache.
-	if zr.entryCache != nil {
+// openCachedEntry opens entry of a cached zip part. If the entry content cache is
+// available, the entry is read fully, cached, and returned from memory. When the fill
+// limit is reached it is streamed instead: a storm of distinct cold entries must not
+// buffer them all in memory at once.
+func (zr *ZipReader) openCachedEntry(entry *zip.File, zipPath, entryName string) (io.ReadCloser, error) {
+	rc, err := zr.openFile(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if zr.entryCache != nil && zr.tryAcquireFill() {
 		data, readErr := io.ReadAll(rc)
+		zr.releaseFill()
 		_ = rc.Close()
 		if readErr != nil {
-			zr.cache.Remove(zipPath)
-			if zr.integrity != nil {
-				zr.integrity.InvalidatePassed(zipPath)
-			}
-			return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, readErr)
+			return nil, readErr //nolint:wrapcheck // wrapped by openFromCacheEntry
 		}
 		zr.entryCache.Put(zipPath, entryName, data)
 		return io.NopCloser(bytes.NewReader(data)), nil
@@ -135,10 +260,19 @@ func (zr *ZipReader) openFromCacheEntry(cacheEntry *ZipPartCacheEntry, zipPath,
 	return &cachedZipEntryReadCloser{entry: rc}, nil
 }
 
+func (zr *ZipReader) tryAcquireFill() bool {
+	return zr.fillSem == nil || zr.fillSem.TryAcquire(1)
+}
+
+func (zr *ZipReader) releaseFill() {
+	if zr.fillSem != nil {
+		zr.fillSem.Release(1)
+	}
+}
+
 // openOnDemand opens a zip entry without using the cache (baseline behavior).
 func (zr *ZipReader) openOnDemand(zipPath, entryName string) (io.ReadCloser, error) {
-	//nolint:gosec // G304: path is validated internally from archive index, not user input
-	zrdr, err := zip.OpenReader(zipPath)
+	zrdr, err := openZipPart(zipPath)
 	if err != nil {
 		if zr.integrity != nil {
 			zr.integrity.InvalidatePassed(zipPath)
@@ -146,10 +280,8 @@ func (zr *ZipReader) openOnDemand(zipPath, entryName string) (io.ReadCloser, err
 		return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
 	}
 
-	for _, f := range zrdr.File {
-		if f.Name != entryName {
-			continue
-		}
+	f := findZipEntry(zrdr.File, entryName)
+	if f != nil {
 		rc, err := f.Open()
 		if err != nil {
 			_ = zrdr.Close()
@@ -165,9 +297,138 @@ func (zr *ZipReader) openOnDemand(zipPath, entryName string) (io.ReadCloser, err
 	return nil, fmt.Errorf("%w: zip entry missing", ErrNotFound)
 }
 
+// findZipEntry returns the file named entryName, preferring an exact match over one whose
+// backslash-separated name normalizes to entryName (see newZipEntryIndex).
+func findZipEntry(files []*zip.File, entryName string) *zip.File {
+	var normalized *zip.File
+	for _, f := range files {
+		if f.Name == entryName {
+			return f
+		}
+		if normalized == nil && normalizeZipEntryName(f.Name) == entryName {
+			normalized = f
+		}
+	}
+	return normalized
+}
+
+// ZipManifestEntry describes one entry of a zip part.
+type ZipManifestEntry struct {
+	Name           string `json:"name"`
+	Size           uint64 `json:"size"`
+	CompressedSize uint64 `json:"compressed_size"`
+}
+
+// ListEntries returns the entries of the zip part at zipPath, sorted by name. It uses the
+// zip part cache when available so listing a hot part does not re-parse its central directory.
+//
+// Errors follow OpenEntry: ErrNotFound if the part is missing, ErrZipTemporarilyUnavailable
+// if it fails integrity checks or cannot be opened.
+func (zr *ZipReader) ListEntries(ctx context.Context, zipPath string) ([]ZipManifestEntry, error) {
+	if zr == nil {
+		return nil, errors.New("zip reader is nil")
+	}
+	if !zr.integrity.allowRead(zipPath) {
+		return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, errLogCircuitOpen)
+	}
+
+	if _, err := os.Stat(zipPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: zip part missing", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+	}
+	if zr.integrity != nil {
+		if err := zr.integrity.Check(zipPath); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []*zip.File
+	if zr.cache != nil {
+		cacheEntry, err := zr.cache.Get(ctx, zipPath)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, fmt.Errorf("list entries: %w", ctxErr)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+		}
+		files = cacheEntry.reader.File
+	} else {
+		zrdr, err := openZipPart(zipPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+		}
+		defer func() { _ = zrdr.Close() }()
+		files = zrdr.File
+	}
+
+	out := make([]ZipManifestEntry, 0, len(files))
+	for _, f := range files {
+		out = append(out, ZipManifestEntry{
+			Name:           f.Name,
+			Size:           f.UncompressedSize64,
+			CompressedSize: f.CompressedSize64,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// WalkEntries calls fn for each entry of the zip part at zipPath, in name order, stopping
+// at the first error. The part is opened on its own handle rather than through the zip
+// part cache, so a long walk neither evicts hot parts nor has its reader closed by an
+// eviction; the open itself still runs on the cache's open worker pool.
+//
+// Errors follow ListEntries; errors returned by fn are passed through.
+func (zr *ZipReader) WalkEntries(ctx context.Context, zipPath string, fn func(f *zip.File) error) error {
+	if zr == nil {
+		return errors.New("zip reader is nil")
+	}
+	if !zr.integrity.allowRead(zipPath) {
+		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, errLogCircuitOpen)
+	}
+
+	if _, err := os.Stat(zipPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: zip part missing", ErrNotFound)
+		}
+		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+	}
+	if zr.integrity != nil {
+		if err := zr.integrity.Check(zipPath); err != nil {
+			return err
+		}
+	}
+
+	var zrdr *zipPartReader
+	var err error
+	if zr.cache != nil {
+		zrdr, err = zr.cache.openUncached(ctx, zipPath)
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+			return fmt.Errorf("walk entries: %w", ctxErr)
+		}
+	} else {
+		zrdr, err = openZipPart(zipPath)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrZipTemporarilyUnavailable, err)
+	}
+	defer func() { _ = zrdr.Close() }()
+
+	files := slices.Clone(zrdr.File)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	for _, f := range files {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type zipEntryReadCloser struct {
 	entry io.ReadCloser
-	zip   *zip.ReadCloser
+	zip   *zipPartReader
 }
 
 func (z *zipEntryReadCloser) Read(p []byte) (int, error) {
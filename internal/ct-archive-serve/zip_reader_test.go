@@ -3,6 +3,7 @@ package ctarchiveserve
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -24,7 +25,7 @@ func TestZipReader_OpenEntry_OK(t *testing.T) {
 	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
 	zr := NewZipReader(zic)
 
-	rc, err := zr.OpenEntry(zipPath, "checkpoint")
+	rc, err := zr.OpenEntry(context.Background(), zipPath, "checkpoint")
 	if err != nil {
 		t.Fatalf("OpenEntry() error = %v", err)
 	}
@@ -51,7 +52,7 @@ func TestZipReader_OpenEntry_NotFound(t *testing.T) {
 	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
 	zr := NewZipReader(zic)
 
-	_, err := zr.OpenEntry(zipPath, "nope")
+	_, err := zr.OpenEntry(context.Background(), zipPath, "nope")
 	if !errors.Is(err, ErrNotFound) {
 		t.Fatalf("OpenEntry() error = %v, want ErrNotFound", err)
 	}
@@ -68,12 +69,73 @@ func TestZipReader_OpenEntry_TemporarilyUnavailable(t *testing.T) {
 	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil)
 	zr := NewZipReader(zic)
 
-	_, err := zr.OpenEntry(zipPath, "checkpoint")
+	_, err := zr.OpenEntry(context.Background(), zipPath, "checkpoint")
 	if !errors.Is(err, ErrZipTemporarilyUnavailable) {
 		t.Fatalf("OpenEntry() error = %v, want ErrZipTemporarilyUnavailable", err)
 	}
 }
 
+func TestZipReader_OpenEntry_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		"checkpoint": []byte("hello"),
+	})
+
+	cache := NewZipPartCache(10, nil, 1)
+	defer saturateOpenPool(t, cache)()
+
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	zr.SetZipPartCache(cache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := zr.OpenEntry(ctx, zipPath, "checkpoint")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("OpenEntry() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestZipReader_OpenEntry_BackslashEntryName(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		`tile\0\x000`: []byte("windows tile"),
+		"tile/0/x001":   []byte("exact tile"),
+		`tile\0\x001`: []byte("shadowed tile"),
+	})
+
+	for _, withCache := range []bool{false, true} {
+		zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+		if withCache {
+			zr.SetZipPartCache(NewZipPartCache(10, nil, 1))
+		}
+
+		for entry, want := range map[string]string{
+			"tile/0/x000": "windows tile",
+			"tile/0/x001": "exact tile", // exact name wins over a normalized alias
+		} {
+			rc, err := zr.OpenEntry(context.Background(), zipPath, entry)
+			if err != nil {
+				t.Fatalf("OpenEntry(%q) cache=%v error = %v", entry, withCache, err)
+			}
+			got, err := io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != want {
+				t.Fatalf("OpenEntry(%q) cache=%v = %q, want %q", entry, withCache, got, want)
+			}
+		}
+	}
+}
+
 func mustCreateZip(t *testing.T, path string, files map[string][]byte) {
 	t.Helper()
 
@@ -99,3 +161,143 @@ func mustCreateZip(t *testing.T, path string, files map[string][]byte) {
 	}
 }
 
+
+func TestZipReader_OpenEntry_FillLimitStreams(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zipPath := filepath.Join(root, "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{
+		"tile/0/000": []byte("first"),
+		"tile/0/001": []byte("second"),
+	})
+
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	zr.SetZipPartCache(NewZipPartCache(16, nil, 1))
+	entryCache := NewEntryContentCache(1<<20, nil)
+	zr.SetEntryContentCache(entryCache)
+	zr.SetEntryCacheFillConcurrency(1)
+
+	read := func(name, want string) {
+		t.Helper()
+		rc, err := zr.OpenEntry(context.Background(), zipPath, name)
+		if err != nil {
+			t.Fatalf("OpenEntry(%q) error = %v", name, err)
+		}
+		defer func() { _ = rc.Close() }()
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("ReadAll(%q) error = %v", name, err)
+		}
+		if string(got) != want {
+			t.Fatalf("%q bytes = %q, want %q", name, got, want)
+		}
+	}
+
+	// Saturate the fill limit, as if another request were populating the cache.
+	if !zr.fillSem.TryAcquire(1) {
+		t.Fatalf("fill semaphore unexpectedly busy")
+	}
+	read("tile/0/000", "first")
+	if _, ok := entryCache.Get(zipPath, "tile/0/000"); ok {
+		t.Fatalf("entry cached while fill limit was saturated, want direct streaming")
+	}
+
+	// With a free slot the next miss populates the cache again.
+	zr.fillSem.Release(1)
+	read("tile/0/001", "second")
+	if _, ok := entryCache.Get(zipPath, "tile/0/001"); !ok {
+		t.Fatalf("entry not cached with a free fill slot")
+	}
+}
+
+func TestZipReader_EntryOpenFailureThreshold(t *testing.T) {
+	t.Parallel()
+
+	zipPath := filepath.Join(t.TempDir(), "000.zip")
+	mustCreateZip(t, zipPath, map[string][]byte{"tile/0/000": []byte("first")})
+
+	cache := NewZipPartCache(16, nil, 1)
+	t.Cleanup(func() { _ = cache.Close() })
+	zr := NewZipReader(NewZipIntegrityCache(5*time.Minute, time.Now, nil, nil))
+	zr.SetZipPartCache(cache)
+	zr.SetEntryFailureThreshold(2)
+
+	// failures is the number of upcoming opens the hook fails.
+	var failures, opens int
+	zr.openFile = func(f *zip.File) (io.ReadCloser, error) {
+		opens++
+		if failures > 0 {
+			failures--
+			return nil, errors.New("flaky read")
+		}
+		return f.Open()
+	}
+
+	open := func() (ContentSource, error) {
+		t.Helper()
+		rc, src, err := zr.OpenEntrySource(context.Background(), zipPath, "tile/0/000")
+		if err != nil {
+			return src, err
+		}
+		defer func() { _ = rc.Close() }()
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		if string(got) != "first" {
+			t.Fatalf("bytes = %q, want %q", got, "first")
+		}
+		return src, nil
+	}
+
+	// Warm the part cache.
+	if _, err := open(); err != nil {
+		t.Fatalf("warm open error = %v", err)
+	}
+
+	// A single failure is absorbed by the retry.
+	failures, opens = 1, 0
+	if _, err := open(); err != nil {
+		t.Fatalf("open with one flaky read error = %v, want success on retry", err)
+	}
+	if opens != 2 {
+		t.Errorf("entry opens = %d, want 2", opens)
+	}
+	if got := cache.OpenCount(); got != 1 {
+		t.Fatalf("open parts after retried read = %d, want 1", got)
+	}
+
+	// A failed request (both attempts) keeps the part until the threshold is reached.
+	failures = 2
+	if _, err := open(); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Fatalf("open error = %v, want ErrZipTemporarilyUnavailable", err)
+	}
+	if got := cache.OpenCount(); got != 1 {
+		t.Fatalf("open parts after 1 failed request = %d, want 1", got)
+	}
+
+	// A success resets the count, so another lone failed request still keeps the part.
+	if src, err := open(); err != nil || src != ContentSourcePartCache {
+		t.Fatalf("open = (%q, %v), want part cache hit", src, err)
+	}
+	failures = 2
+	if _, err := open(); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Fatalf("open error = %v, want ErrZipTemporarilyUnavailable", err)
+	}
+	if got := cache.OpenCount(); got != 1 {
+		t.Fatalf("open parts after a reset and 1 failed request = %d, want 1", got)
+	}
+
+	// The second consecutive failed request drops the part.
+	failures = 2
+	if _, err := open(); !errors.Is(err, ErrZipTemporarilyUnavailable) {
+		t.Fatalf("open error = %v, want ErrZipTemporarilyUnavailable", err)
+	}
+	if got := cache.OpenCount(); got != 0 {
+		t.Fatalf("open parts after 2 failed requests = %d, want 0", got)
+	}
+	if src, err := open(); err != nil || src == ContentSourcePartCache {
+		t.Fatalf("open after invalidation = (%q, %v), want a fresh open", src, err)
+	}
+}
@@ -0,0 +1,284 @@
+package ctarchiveserve
+
+import (
+	"archive/zip"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/sync/singleflight"
+)
+
+// zstdZipPartSuffix is the file suffix of zip parts stored zstd-compressed (NNN.zip.zst).
+const zstdZipPartSuffix = ".zip.zst"
+
+// DefaultZstdZipPartMaxBytes is the default CT_ZSTD_ZIP_PART_MAX_BYTES.
+const DefaultZstdZipPartMaxBytes = 8 << 30
+
+const (
+	// zstdMaxWindow bounds the decoder's window, and so its memory, per decompression.
+	zstdMaxWindow = 128 << 20
+
+	// zstdSpoolMaxIdle is how many decompressed parts nobody has open are kept spooled,
+	// so an integrity check followed by the cache open (or uncached per-request opens of
+	// a hot part) decompress the part once.
+	zstdSpoolMaxIdle = 4
+)
+
+// errZstdZipPartTooLarge is returned for a NNN.zip.zst part that decompresses to more than
+// CT_ZSTD_ZIP_PART_MAX_BYTES.
+var errZstdZipPartTooLarge = errors.New("decompressed zstd zip part exceeds CT_ZSTD_ZIP_PART_MAX_BYTES")
+
+// zipPartReader is an open zip part. Plain NNN.zip parts are read in place; NNN.zip.zst
+// parts are read from a decompressed spool file shared by every open of the part.
+type zipPartReader struct {
+	*zip.Reader
+	closer io.Closer
+}
+
+// Close releases the underlying file (or spool file reference, for NNN.zip.zst parts).
+func (z *zipPartReader) Close() error {
+	return z.closer.Close()
+}
+
+// openZipPart opens the zip part at path, transparently decompressing NNN.zip.zst parts.
+func openZipPart(path string) (*zipPartReader, error) {
+	if strings.HasSuffix(path, zstdZipPartSuffix) {
+		return zstdSpools.open(path)
+	}
+	//nolint:gosec // G304: path is validated internally from archive index, not user input
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with their own context
+	}
+	return &zipPartReader{Reader: &r.Reader, closer: r}, nil
+}
+
+// zstdSpools holds the decompressed NNN.zip.zst parts of the process.
+var zstdSpools = newZstdSpoolCache(DefaultZstdZipPartMaxBytes, zstdSpoolMaxIdle)
+
+// SetZstdZipPartMaxBytes bounds how large a NNN.zip.zst part may decompress to
+// (CT_ZSTD_ZIP_PART_MAX_BYTES); larger parts fail to open.
+func SetZstdZipPartMaxBytes(n int64) {
+	zstdSpools.maxBytes.Store(n)
+}
+
+// zstdSpoolCache decompresses NNN.zip.zst parts into temporary spool files (under
+// os.TempDir, i.e. $TMPDIR) that archive/zip can seek in, and shares each spool between
+// every open of the part: the integrity check, the zip part cache and uncached reads. A
+// spool is reused while the compressed file's size and modification time are unchanged,
+// and kept after its last reader closes until more than maxIdle spools are idle.
+type zstdSpoolCache struct {
+	maxBytes atomic.Int64
+	maxIdle  int
+
+	mu     sync.Mutex
+	spools map[string]*zstdSpool
+	idle   *list.List // of *zstdSpool, most recently released at the front
+
+	group     singleflight.Group // deduplicates concurrent decompressions of a path
+	spoolings atomic.Int64       // decompressions run, for tests
+}
+
+// zstdSpool is one decompressed part. refs, idle and stale are guarded by the cache mutex.
+type zstdSpool struct {
+	path    string
+	file    *spoolFile
+	zip     *zip.Reader
+	srcSize int64
+	srcMod  time.Time
+
+	refs  int
+	idle  *list.Element // set while no reader has it open
+	stale bool          // replaced after the source changed; closed once unreferenced
+}
+
+func newZstdSpoolCache(maxBytes int64, maxIdle int) *zstdSpoolCache {
+	c := &zstdSpoolCache{
+		maxIdle: maxIdle,
+		spools:  make(map[string]*zstdSpool),
+		idle:    list.New(),
+	}
+	c.maxBytes.Store(maxBytes)
+	return c
+}
+
+// open returns a reader of the decompressed part at path, decompressing it only if no
+// current spool of it exists.
+func (c *zstdSpoolCache) open(path string) (*zipPartReader, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("open zstd zip part: %w", err)
+	}
+	if sp := c.acquire(path, fi); sp != nil {
+		return c.reader(sp), nil
+	}
+
+	v, err, _ := c.group.Do(path, func() (any, error) {
+		sp, err := c.spool(path)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		if old, ok := c.spools[path]; ok {
+			c.retireLocked(old)
+		}
+		c.spools[path] = sp
+		c.mu.Unlock()
+		return sp, nil
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by spool
+	}
+	sp, _ := v.(*zstdSpool) //nolint:errcheck // the group only returns *zstdSpool
+	c.mu.Lock()
+	sp.refs++
+	c.mu.Unlock()
+	return c.reader(sp), nil
+}
+
+// acquire returns a referenced spool of path if one matches the source file fi, and
+// retires one that no longer does.
+func (c *zstdSpoolCache) acquire(path string, fi os.FileInfo) *zstdSpool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sp, ok := c.spools[path]
+	if !ok {
+		return nil
+	}
+	if sp.srcSize == fi.Size() && sp.srcMod.Equal(fi.ModTime()) {
+		if sp.idle != nil {
+			c.idle.Remove(sp.idle)
+			sp.idle = nil
+		}
+		sp.refs++
+		return sp
+	}
+	c.retireLocked(sp)
+	return nil
+}
+
+// retireLocked removes sp from the cache, closing it now if idle or else on its last
+// release. Caller must hold c.mu.
+func (c *zstdSpoolCache) retireLocked(sp *zstdSpool) {
+	if c.spools[sp.path] == sp {
+		delete(c.spools, sp.path)
+	}
+	sp.stale = true
+	if sp.idle != nil {
+		c.idle.Remove(sp.idle)
+		sp.idle = nil
+		_ = sp.file.Close()
+	}
+}
+
+// release drops a reference to sp, keeping it idle or closing it.
+func (c *zstdSpoolCache) release(sp *zstdSpool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sp.refs--
+	if sp.refs > 0 {
+		return nil
+	}
+	if sp.stale {
+		return sp.file.Close()
+	}
+	sp.idle = c.idle.PushFront(sp)
+	for c.idle.Len() > c.maxIdle {
+		old, _ := c.idle.Back().Value.(*zstdSpool) //nolint:errcheck // idle only holds *zstdSpool
+		c.retireLocked(old)
+	}
+	return nil
+}
+
+// reader wraps a referenced spool as a zipPartReader whose Close releases it once.
+func (c *zstdSpoolCache) reader(sp *zstdSpool) *zipPartReader {
+	var once sync.Once
+	return &zipPartReader{Reader: sp.zip, closer: closerFunc(func() error {
+		var err error
+		once.Do(func() { err = c.release(sp) })
+		return err
+	})}
+}
+
+// spool decompresses the part at path into a new spool file. The spool file is unlinked
+// immediately where the OS allows it, so it never outlives the process; otherwise it is
+// removed on Close.
+func (c *zstdSpoolCache) spool(path string) (*zstdSpool, error) {
+	c.spoolings.Add(1)
+	maxBytes := c.maxBytes.Load()
+
+	//nolint:gosec // G304: path is validated internally from archive index, not user input
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open zstd zip part: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+	fi, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat zstd zip part: %w", err)
+	}
+
+	dec, err := zstd.NewReader(src,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(zstdMaxWindow),
+		zstd.WithDecoderMaxMemory(uint64(maxBytes)), //nolint:gosec // G115: config requires > 0
+	)
+	if err != nil {
+		return nil, fmt.Errorf("zstd decoder: %w", err)
+	}
+	defer dec.Close()
+
+	f, err := os.CreateTemp("", "ct-archive-serve-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("create zstd spool file: %w", err)
+	}
+	spool := &spoolFile{File: f, unlinked: os.Remove(f.Name()) == nil}
+
+	// Read one byte past the limit to tell a part of exactly maxBytes from a larger one.
+	size, err := io.Copy(f, io.LimitReader(dec, maxBytes+1))
+	// The decoder also stops early on its own size checks: a declared content size over
+	// the limit, or a window over it (the window limit is clamped to the memory limit).
+	if (err == nil && size > maxBytes) || errors.Is(err, zstd.ErrDecoderSizeExceeded) ||
+		(errors.Is(err, zstd.ErrWindowSizeExceeded) && maxBytes < zstdMaxWindow) {
+		err = fmt.Errorf("%w (%d bytes)", errZstdZipPartTooLarge, maxBytes)
+	}
+	if err != nil {
+		_ = spool.Close()
+		return nil, fmt.Errorf("decompress %s: %w", path, err)
+	}
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		_ = spool.Close()
+		return nil, fmt.Errorf("read decompressed zip: %w", err)
+	}
+	return &zstdSpool{path: path, file: spool, zip: zr, srcSize: fi.Size(), srcMod: fi.ModTime()}, nil
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// spoolFile is a temporary file that is removed on Close unless already unlinked.
+type spoolFile struct {
+	*os.File
+	unlinked bool
+}
+
+func (s *spoolFile) Close() error {
+	err := s.File.Close()
+	if !s.unlinked {
+		_ = os.Remove(s.File.Name())
+	}
+	return err //nolint:wrapcheck // plain file close error
+}
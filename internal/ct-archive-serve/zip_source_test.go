@@ -0,0 +1,264 @@
+package ctarchiveserve
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// zstdCompress compresses data into a single zstd frame.
+func zstdCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter() error = %v", err)
+	}
+	defer func() { _ = enc.Close() }()
+	return enc.EncodeAll(data, nil)
+}
+
+// mustCreateZstdZip writes a zstd-compressed zip part at path.
+func mustCreateZstdZip(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+
+	plain := filepath.Join(t.TempDir(), "plain.zip")
+	mustCreateZip(t, plain, files)
+	//nolint:gosec // G304: path comes from t.TempDir
+	b, err := os.ReadFile(plain)
+	if err != nil {
+		t.Fatalf("ReadFile(%q) error = %v", plain, err)
+	}
+	if err := os.WriteFile(path, zstdCompress(t, b), 0o600); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", path, err)
+	}
+}
+
+// readZipPartEntry reads the named entry of an open zip part.
+func readZipPartEntry(t *testing.T, zr *zipPartReader, name string) string {
+	t.Helper()
+
+	rc, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("Open(%q) error = %v", name, err)
+	}
+	defer func() { _ = rc.Close() }()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll(%q) error = %v", name, err)
+	}
+	return string(b)
+}
+
+func TestServer_ZstdZipPart(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+
+	// Larger than one zstd block (128 KiB) so the part spans several.
+	big := make([]byte, 300<<10)
+	for i := range big {
+		big[i] = byte(i * 7)
+	}
+	mustCreateZstdZip(t, filepath.Join(logFolder, "000.zip.zst"), map[string][]byte{
+		"checkpoint":     []byte("checkpoint data"),
+		"tile/data/x000": big,
+	})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	logger := NewLogger(LoggerOptions{})
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	archiveIndex, err := NewArchiveIndex(cfg, logger, metrics)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	zic := NewZipIntegrityCache(5*time.Minute, time.Now, nil, metrics)
+	server := NewServer(cfg, logger, metrics, archiveIndex, NewZipReader(zic), nil)
+
+	for path, want := range map[string]string{
+		"/test_log/checkpoint":     "checkpoint data",
+		"/test_log/tile/data/x000": string(big),
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		if w.Body.String() != want {
+			t.Errorf("GET %s body length = %d, want %d", path, w.Body.Len(), len(want))
+		}
+	}
+}
+
+func TestBuildArchiveSnapshot_ZstdZipParts(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logFolder := filepath.Join(root, "ct_test_log")
+	mustMkdir(t, logFolder)
+	mustCreateZip(t, filepath.Join(logFolder, "000.zip"), map[string][]byte{"checkpoint": []byte("x")})
+	mustCreateZstdZip(t, filepath.Join(logFolder, "000.zip.zst"), map[string][]byte{"checkpoint": []byte("x")})
+	mustCreateZstdZip(t, filepath.Join(logFolder, "001.zip.zst"), map[string][]byte{"checkpoint": []byte("x")})
+
+	cfg := Config{
+		ArchivePath:          root,
+		ArchiveFolderPattern: "ct_*",
+		ArchiveFolderPrefix:  "ct_",
+	}
+	archiveIndex, err := NewArchiveIndex(cfg, NewLogger(LoggerOptions{}), nil)
+	if err != nil {
+		t.Fatalf("NewArchiveIndex() error = %v", err)
+	}
+	log, ok := archiveIndex.LookupLog("test_log")
+	if !ok {
+		t.Fatalf("LookupLog(test_log) ok = false, want true")
+	}
+
+	if !intSlicesEqual(log.ZipParts, []int{0, 1}) {
+		t.Errorf("ZipParts = %v, want [0 1]", log.ZipParts)
+	}
+	if got, want := log.ZipPartPath(0), filepath.Join(logFolder, "000.zip"); got != want {
+		t.Errorf("ZipPartPath(0) = %q, want %q (plain .zip preferred)", got, want)
+	}
+	if got, want := log.ZipPartPath(1), filepath.Join(logFolder, "001.zip.zst"); got != want {
+		t.Errorf("ZipPartPath(1) = %q, want %q", got, want)
+	}
+}
+
+func TestZstdSpoolCache_SharesSpoolUntilSourceChanges(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "000.zip.zst")
+	mustCreateZstdZip(t, path, map[string][]byte{"checkpoint": []byte("one")})
+	c := newZstdSpoolCache(1<<20, 1)
+
+	// The integrity check opens and closes the part, then the cache opens it and an
+	// uncached read opens it alongside: all three share one decompression.
+	check, err := c.open(path)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	_ = check.Close()
+	cached, err := c.open(path)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	uncached, err := c.open(path)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	if got := readZipPartEntry(t, uncached, "checkpoint"); got != "one" {
+		t.Errorf("checkpoint = %q, want %q", got, "one")
+	}
+	_ = uncached.Close()
+	_ = uncached.Close() // a second Close must not drop another reference
+	if got := c.spoolings.Load(); got != 1 {
+		t.Fatalf("spoolings = %d, want 1", got)
+	}
+
+	// Replacing the part re-spools it; the reader still holding the old spool keeps it.
+	mustCreateZstdZip(t, path, map[string][]byte{"checkpoint": []byte("two!")})
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	fresh, err := c.open(path)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	defer func() { _ = fresh.Close() }()
+	if got := c.spoolings.Load(); got != 2 {
+		t.Fatalf("spoolings after replace = %d, want 2", got)
+	}
+	if got := readZipPartEntry(t, fresh, "checkpoint"); got != "two!" {
+		t.Errorf("checkpoint after replace = %q, want %q", got, "two!")
+	}
+	if got := readZipPartEntry(t, cached, "checkpoint"); got != "one" {
+		t.Errorf("checkpoint of old reader = %q, want %q", got, "one")
+	}
+	_ = cached.Close()
+}
+
+func TestZstdSpoolCache_EvictsIdleSpools(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "000.zip.zst")
+	b := filepath.Join(dir, "001.zip.zst")
+	mustCreateZstdZip(t, a, map[string][]byte{"checkpoint": []byte("a")})
+	mustCreateZstdZip(t, b, map[string][]byte{"checkpoint": []byte("b")})
+	c := newZstdSpoolCache(1<<20, 1)
+
+	for _, path := range []string{a, b, b, a} {
+		zr, err := c.open(path)
+		if err != nil {
+			t.Fatalf("open(%q) error = %v", path, err)
+		}
+		_ = zr.Close()
+	}
+	// a was evicted by b going idle, so its second open decompresses again; b's did not.
+	if got := c.spoolings.Load(); got != 3 {
+		t.Errorf("spoolings = %d, want 3", got)
+	}
+	c.mu.Lock()
+	idle, spools := c.idle.Len(), len(c.spools)
+	c.mu.Unlock()
+	if idle != 1 || spools != 1 {
+		t.Errorf("idle = %d, spools = %d, want 1 and 1", idle, spools)
+	}
+}
+
+func TestZstdSpoolCache_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// Past the decoder's 1 KiB minimum window, and poorly compressible so the zip is too.
+	data := make([]byte, 4<<10)
+	for i := range data {
+		data[i] = byte(i * i >> 3)
+	}
+	files := map[string][]byte{"tile/data/x000": data}
+	plain := filepath.Join(dir, "000.zip")
+	mustCreateZip(t, plain, files)
+	fi, err := os.Stat(plain)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	path := filepath.Join(dir, "000.zip.zst")
+	mustCreateZstdZip(t, path, files)
+
+	c := newZstdSpoolCache(fi.Size()-1, 1)
+	if _, err := c.open(path); !errors.Is(err, errZstdZipPartTooLarge) {
+		t.Fatalf("open() error = %v, want %v", err, errZstdZipPartTooLarge)
+	}
+	c.mu.Lock()
+	spools := len(c.spools)
+	c.mu.Unlock()
+	if spools != 0 {
+		t.Errorf("spools = %d, want 0", spools)
+	}
+
+	// A part of exactly the limit is fine.
+	c.maxBytes.Store(fi.Size())
+	zr, err := c.open(path)
+	if err != nil {
+		t.Fatalf("open() at the limit error = %v", err)
+	}
+	_ = zr.Close()
+}